@@ -266,7 +266,7 @@ func (b *appendSortableBuffer) Write(w io.Writer) error {
 		if _, err := w.Write(entry.key); err != nil {
 			return err
 		}
-		lv := int64(len(entry.key))
+		lv := int64(len(entry.value))
 		if entry.value == nil {
 			lv = -1
 		}
@@ -24,10 +24,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/c2h5oh/datasize"
 	"github.com/ledgerwatch/log/v3"
+	"golang.org/x/exp/slices"
 
 	"github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/kv"
@@ -48,7 +50,13 @@ type Collector struct {
 	bufType       int
 	allFlushed    bool
 	autoClean     bool
+	compression   Compression
 	logger        log.Logger
+
+	withManifest bool      // see WithManifest
+	manifest     *manifest // non-nil if files of critical collector are recorded in tmpdir
+	lastKey      []byte    // progress of last Load: see Checkpoint
+	loaded       uint64
 }
 
 // NewCollectorFromFiles creates collector from existing files (left over from previous unsuccessful loading)
@@ -60,23 +68,39 @@ func NewCollectorFromFiles(logPrefix, tmpdir string, logger log.Logger) (*Collec
 	if err != nil {
 		return nil, fmt.Errorf("collector from files - reading directory %s: %w", tmpdir, err)
 	}
-	if len(dirEntries) == 0 {
-		return nil, nil
+	m, err := readManifest(tmpdir, logPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("collector from files - %w", err)
 	}
-	dataProviders := make([]dataProvider, len(dirEntries))
-	for i, dirEntry := range dirEntries {
-		fileInfo, err := dirEntry.Info()
-		if err != nil {
-			return nil, fmt.Errorf("collector from files - reading file info %s: %w", dirEntry.Name(), err)
+	var fileNames []string
+	if m != nil { // manifest knows order of files and progress of loading
+		fileNames = m.Files
+	} else {
+		for _, dirEntry := range dirEntries {
+			if strings.HasPrefix(dirEntry.Name(), manifestFilePrefix) {
+				continue
+			}
+			fileNames = append(fileNames, dirEntry.Name())
 		}
+	}
+	if len(fileNames) == 0 {
+		return nil, nil
+	}
+	dataProviders := make([]dataProvider, len(fileNames))
+	for i, fileName := range fileNames {
 		var dataProvider fileDataProvider
-		dataProvider.file, err = os.Open(filepath.Join(tmpdir, fileInfo.Name()))
+		dataProvider.compression = compressionByFileName(fileName)
+		dataProvider.file, err = os.Open(filepath.Join(tmpdir, fileName))
 		if err != nil {
-			return nil, fmt.Errorf("collector from files - opening file %s: %w", fileInfo.Name(), err)
+			return nil, fmt.Errorf("collector from files - opening file %s: %w", fileName, err)
 		}
 		dataProviders[i] = &dataProvider
 	}
-	return &Collector{dataProviders: dataProviders, allFlushed: true, autoClean: false, logPrefix: logPrefix}, nil
+	c := &Collector{dataProviders: dataProviders, allFlushed: true, autoClean: false, logPrefix: logPrefix, tmpdir: tmpdir, logger: logger, withManifest: m != nil, manifest: m}
+	if m != nil {
+		c.bufType = m.BufType
+	}
+	return c, nil
 }
 
 // NewCriticalCollector does not clean up temporary files if loading has failed
@@ -104,6 +128,14 @@ func (c *Collector) Collect(k, v []byte) error {
 
 func (c *Collector) LogLvl(v log.Lvl) { c.logLvl = v }
 
+// WithManifest - opt-in for critical collector: before loading it records it's files and then loading progress (see Checkpoint)
+// in manifest in tmpdir. Manifest is named by logPrefix: collectors which share tmpdir must have different logPrefix.
+// After restart NewCollectorFromFiles with same logPrefix and tmpdir finds it
+func (c *Collector) WithManifest() { c.withManifest = true }
+
+// Compress - codec of files flushed to tmpdir, files are decompressed transparently on Load
+func (c *Collector) Compress(v Compression) { c.compression = v }
+
 func (c *Collector) flushBuffer(canStoreInRam bool) error {
 	if c.buf.Len() == 0 {
 		return nil
@@ -121,7 +153,7 @@ func (c *Collector) flushBuffer(canStoreInRam bool) error {
 
 		doFsync := !c.autoClean /* is critical collector */
 		var err error
-		provider, err = FlushToDisk(c.logPrefix, fullBuf, c.tmpdir, doFsync, c.logLvl)
+		provider, err = flushToDisk(c.logPrefix, fullBuf, c.tmpdir, doFsync, c.logLvl, c.compression)
 		if err != nil {
 			return err
 		}
@@ -146,6 +178,62 @@ func (c *Collector) Flush() error {
 }
 
 func (c *Collector) Load(db kv.RwTx, toBucket string, loadFunc LoadFunc, args TransformArgs) error {
+	return c.load(db, toBucket, loadFunc, args, 0)
+}
+
+// Resume - same as Load, but skips entries which were loaded before last Checkpoint.
+// Usage: critical collector re-created by NewCollectorFromFiles after restart
+func (c *Collector) Resume(db kv.RwTx, toBucket string, loadFunc LoadFunc, args TransformArgs) error {
+	var skip uint64
+	if c.manifest != nil {
+		skip = c.manifest.Loaded
+	}
+	return c.load(db, toBucket, loadFunc, args, skip)
+}
+
+// Checkpoint - persists progress of last Load/Resume to manifest in tmpdir. Call it after commit of `db` passed to Load:
+// loading same entries twice is fine, but skipping not committed ones is not. During Load it's called after
+// every TransformArgs.Commit, after interrupted (by Quit) Load - by caller.
+// Does nothing without WithManifest, if collector is not critical or all data fit into RAM (nothing left in tmpdir to resume from)
+func (c *Collector) Checkpoint() error {
+	if c.manifest == nil {
+		return nil
+	}
+	c.manifest.LastKey, c.manifest.Loaded = common.Copy(c.lastKey), c.loaded
+	return c.manifest.write(c.tmpdir, c.logPrefix)
+}
+
+// writeManifest - critical collector records it's files before loading, then loading can be continued after restart
+func (c *Collector) writeManifest() error {
+	if !c.withManifest || c.tmpdir == "" { // no dir - nothing to resume from
+		return nil
+	}
+	files := make([]string, 0, len(c.dataProviders))
+	for _, p := range c.dataProviders {
+		fp, ok := p.(*fileDataProvider)
+		if !ok {
+			return nil
+		}
+		if err := fp.Wait(); err != nil {
+			return err
+		}
+		files = append(files, filepath.Base(fp.file.Name()))
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	if c.manifest != nil && slices.Equal(c.manifest.Files, files) { // keep progress
+		return nil
+	}
+	m := &manifest{BufType: c.bufType, Files: files}
+	if err := m.write(c.tmpdir, c.logPrefix); err != nil {
+		return err
+	}
+	c.manifest = m
+	return nil
+}
+
+func (c *Collector) load(db kv.RwTx, toBucket string, loadFunc LoadFunc, args TransformArgs, skip uint64) error {
 	if c.autoClean {
 		defer c.Close()
 	}
@@ -155,6 +243,12 @@ func (c *Collector) Load(db kv.RwTx, toBucket string, loadFunc LoadFunc, args Tr
 			return e
 		}
 	}
+	if !c.autoClean {
+		if err := c.writeManifest(); err != nil {
+			return err
+		}
+	}
+	c.lastKey, c.loaded = c.lastKey[:0], 0
 
 	bucket := toBucket
 
@@ -246,8 +340,39 @@ func (c *Collector) Load(db kv.RwTx, toBucket string, loadFunc LoadFunc, args Tr
 	}
 
 	currentTable := &currentTableReader{db, bucket}
+	var n uint64 // position in merged stream of all files: same after restart
 	simpleLoad := func(k, v []byte) error {
-		return loadFunc(k, v, currentTable, loadNextFunc)
+		if n < skip {
+			n++
+			if n == skip && !bytes.Equal(k, c.manifest.LastKey) {
+				return fmt.Errorf("%s: files don't match manifest: entry %d has key %x, expected %x", c.logPrefix, n, k, c.manifest.LastKey)
+			}
+			if c.bufType == SortableOldestAppearedBuffer {
+				prevK = common.Copy(k)
+			}
+			return nil
+		}
+		if err := loadFunc(k, v, currentTable, loadNextFunc); err != nil {
+			return err
+		}
+		n++
+		if c.manifest != nil {
+			c.lastKey, c.loaded = append(c.lastKey[:0], k...), n
+		}
+		if args.Commit == nil || args.CommitEvery == 0 || (n-skip)%args.CommitEvery != 0 {
+			return nil
+		}
+		newTx, err := args.Commit()
+		if err != nil {
+			return err
+		}
+		db, currentTable.getter = newTx, newTx
+		if bucket != "" {
+			if cursor, err = db.RwCursor(bucket); err != nil {
+				return err
+			}
+		}
+		return c.Checkpoint()
 	}
 	if err := mergeSortFiles(c.logPrefix, c.dataProviders, simpleLoad, args); err != nil {
 		return fmt.Errorf("loadIntoTable %s: %w", toBucket, err)
@@ -263,7 +388,13 @@ func (c *Collector) reset() {
 		}
 		c.dataProviders = nil
 	}
-	c.buf.Reset()
+	if c.buf != nil {
+		c.buf.Reset()
+	}
+	if c.manifest != nil {
+		removeManifest(c.tmpdir, c.logPrefix)
+		c.manifest = nil
+	}
 	c.allFlushed = false
 }
 
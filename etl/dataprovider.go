@@ -18,10 +18,14 @@ package etl
 
 import (
 	"bufio"
+	"compress/flate"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"os"
+	"strings"
+
+	"github.com/c2h5oh/datasize"
 
 	"github.com/ledgerwatch/log/v3"
 	"golang.org/x/sync/errgroup"
@@ -34,19 +38,60 @@ type dataProvider interface {
 }
 
 type fileDataProvider struct {
-	file       *os.File
-	reader     io.Reader
-	byteReader io.ByteReader // Different interface to the same object as reader
-	wg         *errgroup.Group
+	file        *os.File
+	reader      io.Reader
+	byteReader  io.ByteReader // Different interface to the same object as reader
+	wg          *errgroup.Group
+	compression Compression
+}
+
+// Compression - codec of spill files. Trade CPU for disk space: helps when tmpdir is small or slow.
+type Compression int
+
+const (
+	CompressionNone  Compression = iota
+	CompressionFlate             // DEFLATE with BestSpeed level
+)
+
+// extension - codec is encoded in file name, then NewCollectorFromFiles knows how to read left-over files
+func (c Compression) extension() string {
+	switch c {
+	case CompressionFlate:
+		return ".flate"
+	default:
+		return ""
+	}
+}
+
+func compressionByFileName(name string) Compression {
+	if strings.HasSuffix(name, CompressionFlate.extension()) {
+		return CompressionFlate
+	}
+	return CompressionNone
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
 }
 
 // FlushToDisk - `doFsync` is true only for 'critical' collectors (which should not loose).
 func FlushToDisk(logPrefix string, b Buffer, tmpdir string, doFsync bool, lvl log.Lvl) (dataProvider, error) {
+	return flushToDisk(logPrefix, b, tmpdir, doFsync, lvl, CompressionNone)
+}
+
+func flushToDisk(logPrefix string, b Buffer, tmpdir string, doFsync bool, lvl log.Lvl, compression Compression) (dataProvider, error) {
 	if b.Len() == 0 {
 		return nil, nil
 	}
 
-	provider := &fileDataProvider{reader: nil, wg: &errgroup.Group{}}
+	provider := &fileDataProvider{reader: nil, wg: &errgroup.Group{}, compression: compression}
 	provider.wg.Go(func() error {
 		b.Sort()
 
@@ -58,7 +103,7 @@ func FlushToDisk(logPrefix string, b Buffer, tmpdir string, doFsync bool, lvl lo
 			}
 		}
 
-		bufferFile, err := os.CreateTemp(tmpdir, "erigon-sortable-buf-")
+		bufferFile, err := os.CreateTemp(tmpdir, "erigon-sortable-buf-*"+compression.extension())
 		if err != nil {
 			return err
 		}
@@ -68,13 +113,31 @@ func FlushToDisk(logPrefix string, b Buffer, tmpdir string, doFsync bool, lvl lo
 			defer bufferFile.Sync() //nolint:errcheck
 		}
 
-		w := bufio.NewWriterSize(bufferFile, BufIOSize)
-		defer w.Flush() //nolint:errcheck
-
+		fileSize := &countingWriter{w: bufferFile}
+		var compressor *flate.Writer
+		rawSize := fileSize
+		if compression == CompressionFlate {
+			if compressor, err = flate.NewWriter(fileSize, flate.BestSpeed); err != nil {
+				return err
+			}
+			rawSize = &countingWriter{w: compressor}
+		}
+		w := bufio.NewWriterSize(rawSize, BufIOSize)
 		if err = b.Write(w); err != nil {
 			return fmt.Errorf("error writing entries to disk: %w", err)
 		}
-		log.Log(lvl, fmt.Sprintf("[%s] Flushed buffer file", logPrefix), "name", bufferFile.Name())
+		if err = w.Flush(); err != nil {
+			return fmt.Errorf("error writing entries to disk: %w", err)
+		}
+		if compressor != nil {
+			if err = compressor.Close(); err != nil {
+				return fmt.Errorf("error writing entries to disk: %w", err)
+			}
+			log.Log(lvl, fmt.Sprintf("[%s] Flushed buffer file", logPrefix), "name", bufferFile.Name(),
+				"size", datasize.ByteSize(fileSize.n).HR(), "saved", datasize.ByteSize(rawSize.n-fileSize.n).HR())
+			return nil
+		}
+		log.Log(lvl, fmt.Sprintf("[%s] Flushed buffer file", logPrefix), "name", bufferFile.Name(), "size", datasize.ByteSize(fileSize.n).HR())
 		return nil
 	})
 
@@ -87,7 +150,12 @@ func (p *fileDataProvider) Next(keyBuf, valBuf []byte) ([]byte, []byte, error) {
 		if err != nil {
 			return nil, nil, err
 		}
-		r := bufio.NewReaderSize(p.file, BufIOSize)
+		var r *bufio.Reader
+		if p.compression == CompressionFlate {
+			r = bufio.NewReaderSize(flate.NewReader(bufio.NewReaderSize(p.file, BufIOSize)), BufIOSize)
+		} else {
+			r = bufio.NewReaderSize(p.file, BufIOSize)
+		}
 		p.reader = r
 		p.byteReader = r
 
@@ -95,10 +163,15 @@ func (p *fileDataProvider) Next(keyBuf, valBuf []byte) ([]byte, []byte, error) {
 	return readElementFromDisk(p.reader, p.byteReader, keyBuf, valBuf)
 }
 
-func (p *fileDataProvider) Wait() error { return p.wg.Wait() }
+func (p *fileDataProvider) Wait() error {
+	if p.wg == nil { // created from existing file
+		return nil
+	}
+	return p.wg.Wait()
+}
 func (p *fileDataProvider) Dispose() {
+	_ = p.Wait()       // file is created by background goroutine
 	if p.file != nil { //invariant: safe to call multiple time
-		p.Wait()
 		_ = p.file.Close()
 		_ = os.Remove(p.file.Name())
 		p.file = nil
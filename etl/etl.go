@@ -18,6 +18,7 @@ package etl
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"reflect"
 	"time"
@@ -26,6 +27,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/log/v3"
+	"golang.org/x/sync/errgroup"
 )
 
 type CurrentTableReader interface {
@@ -71,6 +73,17 @@ type TransformArgs struct {
 	ExtractEndKey   []byte
 	BufferType      int
 	BufferSize      int
+	// Workers - amount of goroutines running `extractFunc` (<=1 means extraction in caller's goroutine).
+	// `extractFunc` must be thread-safe if Workers > 1. Order of loaded items is same as with 1 worker.
+	// RAM usage is about 2*Workers*BufferSize
+	Workers int
+	// Compression - codec of files flushed to tmpdir (see Collector.Compress)
+	Compression Compression
+	// CommitEvery, Commit - progress of long Load: after every CommitEvery loaded entries Load calls Commit, which must commit
+	// tx passed to Load and return new tx to continue loading with. Then progress is persisted by Collector.Checkpoint:
+	// after crash Collector.Resume skips committed entries (critical collector with manifest, see Collector.WithManifest)
+	CommitEvery uint64
+	Commit      func() (kv.RwTx, error)
 }
 
 func Transform(
@@ -90,10 +103,15 @@ func Transform(
 	}
 	buffer := getBufferByType(args.BufferType, bufferSize)
 	collector := NewCollector(logPrefix, tmpdir, buffer, logger)
+	collector.Compress(args.Compression)
 	defer collector.Close()
 
 	t := time.Now()
-	if err := extractBucketIntoFiles(logPrefix, db, fromBucket, args.ExtractStartKey, args.ExtractEndKey, collector, extractFunc, args.Quit, args.LogDetailsExtract, logger); err != nil {
+	if args.Workers > 1 {
+		if err := extractBucketIntoFilesParallel(logPrefix, db, fromBucket, args.ExtractStartKey, args.ExtractEndKey, collector, extractFunc, args.Quit, args.LogDetailsExtract, args.Workers, logger); err != nil {
+			return err
+		}
+	} else if err := extractBucketIntoFiles(logPrefix, db, fromBucket, args.ExtractStartKey, args.ExtractEndKey, collector, extractFunc, args.Quit, args.LogDetailsExtract, logger); err != nil {
 		return err
 	}
 	logger.Trace(fmt.Sprintf("[%s] Extraction finished", logPrefix), "took", time.Since(t))
@@ -155,6 +173,134 @@ func extractBucketIntoFiles(
 	return collector.flushBuffer(true)
 }
 
+// extractSegment - consecutive items of source bucket and data providers produced from them by extractFunc
+type extractSegment struct {
+	keys, vals    [][]byte
+	arena         []byte
+	canStoreInRam bool
+	providers     []dataProvider
+}
+
+// add - copies k, v to arena. Full slice expressions: append to returned k or v must not overwrite neighbours in arena
+func (s *extractSegment) add(k, v []byte) {
+	s.arena = append(s.arena, k...)
+	s.keys = append(s.keys, s.arena[len(s.arena)-len(k):len(s.arena):len(s.arena)])
+	s.arena = append(s.arena, v...)
+	s.vals = append(s.vals, s.arena[len(s.arena)-len(v):len(s.arena):len(s.arena)])
+}
+
+// extractBucketIntoFilesParallel - [startkey, endkey). Caller's goroutine reads bucket (kv.Tx is not thread-safe)
+// and splits it to segments of consecutive keys (of BufferSize bytes). Workers extract each segment into own collector.
+// Data providers of all segments are added to `collector` in order of segments - so merge at load time
+// processes equal keys in same order as single-threaded extraction.
+func extractBucketIntoFilesParallel(
+	logPrefix string,
+	db kv.Tx,
+	bucket string,
+	startkey []byte,
+	endkey []byte,
+	collector *Collector,
+	extractFunc ExtractFunc,
+	quit <-chan struct{},
+	additionalLogArguments AdditionalLogArguments,
+	workers int,
+	logger log.Logger,
+) error {
+	logEvery := time.NewTicker(30 * time.Second)
+	defer logEvery.Stop()
+
+	segmentSize := collector.buf.SizeLimit()
+	var segments []*extractSegment
+	defer func() { // also on error: collector disposes files
+		for _, seg := range segments {
+			collector.dataProviders = append(collector.dataProviders, seg.providers...)
+		}
+		collector.allFlushed = true
+	}()
+
+	g, ctx := errgroup.WithContext(context.Background())
+	work := make(chan *extractSegment)
+	for i := 0; i < workers; i++ {
+		g.Go(func() error {
+			for seg := range work {
+				c := NewCollector(logPrefix, collector.tmpdir, getBufferByType(collector.bufType, datasize.ByteSize(segmentSize)), logger)
+				c.logLvl, c.compression = collector.logLvl, collector.compression
+				for i := range seg.keys {
+					if err := extractFunc(seg.keys[i], seg.vals[i], c.extractNextFunc); err != nil {
+						c.Close()
+						return err
+					}
+				}
+				if err := c.flushBuffer(seg.canStoreInRam); err != nil {
+					c.Close()
+					return err
+				}
+				seg.providers, seg.keys, seg.vals, seg.arena = c.dataProviders, nil, nil, nil
+			}
+			return nil
+		})
+	}
+
+	send := func(seg *extractSegment) error {
+		select {
+		case work <- seg:
+			segments = append(segments, seg)
+			return nil
+		case <-ctx.Done(): // worker failed, g.Wait() returns it's error
+			return ctx.Err()
+		}
+	}
+	readErr := func() error {
+		defer close(work)
+		c, err := db.Cursor(bucket)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		seg := &extractSegment{arena: make([]byte, 0, segmentSize)}
+		for k, v, e := c.Seek(startkey); k != nil; k, v, e = c.Next() {
+			if e != nil {
+				return e
+			}
+			if err := common.Stopped(quit); err != nil {
+				return err
+			}
+			select {
+			default:
+			case <-logEvery.C:
+				logArs := []interface{}{"from", bucket, "workers", workers}
+				if additionalLogArguments != nil {
+					logArs = append(logArs, additionalLogArguments(k, v)...)
+				} else {
+					logArs = append(logArs, "current_prefix", makeCurrentKeyStr(k))
+				}
+
+				logger.Info(fmt.Sprintf("[%s] ETL [1/2] Extracting", logPrefix), logArs...)
+			}
+			if endkey != nil && bytes.Compare(k, endkey) >= 0 {
+				// endKey is exclusive bound: [startkey, endkey)
+				break
+			}
+			if len(seg.arena) > 0 && len(seg.arena)+len(k)+len(v) > segmentSize {
+				if err := send(seg); err != nil {
+					return err
+				}
+				seg = &extractSegment{arena: make([]byte, 0, segmentSize)}
+			}
+			seg.add(k, v)
+		}
+		if len(seg.keys) == 0 {
+			return nil
+		}
+		seg.canStoreInRam = len(segments) == 0 // whole range fits in 1 segment
+		return send(seg)
+	}()
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	return readErr
+}
+
 type currentTableReader struct {
 	getter kv.Tx
 	bucket string
@@ -17,14 +17,17 @@ package etl
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
 	"github.com/ledgerwatch/log/v3"
@@ -86,6 +89,22 @@ func TestEmptyValueIsNotANil(t *testing.T) {
 	})
 }
 
+func TestAppendBufferThroughFiles(t *testing.T) {
+	// length of value was written as length of key: values of other length were broken after flush to disk
+	collector := NewCollector(t.Name(), t.TempDir(), NewAppendBuffer(1), log.New())
+	defer collector.Close()
+	require := require.New(t)
+	require.NoError(collector.Collect([]byte{1}, []byte{1, 2, 3}))
+	require.NoError(collector.Collect([]byte{2, 2, 2}, []byte{2}))
+	require.Equal(2, len(collector.dataProviders))
+	var values [][]byte
+	require.NoError(collector.Load(nil, "", func(k, v []byte, table CurrentTableReader, next LoadNextFunc) error {
+		values = append(values, common.Copy(v))
+		return nil
+	}, TransformArgs{}))
+	require.Equal([][]byte{{1, 2, 3}, {2}}, values)
+}
+
 func TestEmptyKeyValue(t *testing.T) {
 	logger := log.New()
 	_, tx := memdb.NewTestTx(t)
@@ -513,3 +532,260 @@ func TestReuseCollectorAfterLoad(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 1, see)
 }
+
+func TestTransformParallel(t *testing.T) {
+	logger := log.New()
+	_, tx := memdb.NewTestTx(t) // tx is bound to goroutine of test: no sub-tests here
+	sourceBucket := kv.ChaindataTables[0]
+	generateTestData(t, tx, sourceBucket, 100)
+
+	// every source key also goes to same `shared` key: check that order of equal keys is preserved
+	extract := func(k, v []byte, next ExtractNextFunc) error {
+		if err := next(k, k, v); err != nil {
+			return err
+		}
+		return next(k, []byte("shared"), k)
+	}
+	transform := func(args TransformArgs) (seen []string) {
+		var prevK []byte
+		err := Transform("logPrefix", tx, sourceBucket, "", t.TempDir(), extract, func(k, v []byte, _ CurrentTableReader, _ LoadNextFunc) error {
+			// values of same key may be split between files by flush boundaries:
+			// SortableAppendBuffer - glue them, SortableOldestAppearedBuffer - keep oldest (as Collector.Load does)
+			if args.BufferType == SortableAppendBuffer && bytes.Equal(k, prevK) {
+				seen[len(seen)-1] += fmt.Sprintf("%x", v)
+				return nil
+			}
+			if args.BufferType == SortableOldestAppearedBuffer && bytes.Equal(k, prevK) {
+				return nil
+			}
+			prevK = append(prevK[:0], k...)
+			seen = append(seen, fmt.Sprintf("%x:%x", k, v))
+			return nil
+		}, args, logger)
+		require.NoError(t, err, args)
+		return seen
+	}
+	for _, bufType := range []int{SortableSliceBuffer, SortableAppendBuffer, SortableOldestAppearedBuffer} {
+		for _, bufSize := range []int{0, 1, 4 * 1024} {
+			label := fmt.Sprintf("bufType=%d,bufSize=%d", bufType, bufSize)
+			args := TransformArgs{BufferType: bufType, BufferSize: bufSize, ExtractStartKey: []byte(fmt.Sprintf("%10d-key-%010d", 5, 5))}
+			expect := transform(args)
+			require.NotEmpty(t, expect, label)
+			args.Workers = 4
+			require.Equal(t, expect, transform(args), label)
+			args.Compression = CompressionFlate
+			require.Equal(t, expect, transform(args), label)
+		}
+	}
+
+	// error
+	tmpdir := t.TempDir()
+	err := Transform("logPrefix", tx, sourceBucket, "", tmpdir, func(k, v []byte, next ExtractNextFunc) error {
+		if bytes.HasPrefix(k, []byte(fmt.Sprintf("%10d", 70))) {
+			return fmt.Errorf("expected error")
+		}
+		return next(k, k, v)
+	}, IdentityLoadFunc, TransformArgs{BufferSize: 1, Workers: 4}, logger)
+	require.ErrorContains(t, err, "expected error")
+	files, err := os.ReadDir(tmpdir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestCompressedFiles(t *testing.T) {
+	logger := log.New()
+	collect := func(compression Compression, tmpdir string) (c *Collector, size int64) {
+		c = NewCriticalCollector(t.Name(), tmpdir, NewSortableBuffer(4*1024), logger)
+		c.Compress(compression)
+		for i := 0; i < 1000; i++ {
+			require.NoError(t, c.Collect([]byte(fmt.Sprintf("key-%010d", (i*7)%1000)), []byte(fmt.Sprintf("value-%010d", i%10))))
+		}
+		require.NoError(t, c.Flush())
+		require.Greater(t, len(c.dataProviders), 1)
+		for _, p := range c.dataProviders {
+			fp := p.(*fileDataProvider)
+			require.NoError(t, fp.Wait())
+			st, err := fp.file.Stat()
+			require.NoError(t, err)
+			size += st.Size()
+		}
+		return c, size
+	}
+	load := func(c *Collector) (seen []string) {
+		err := c.Load(nil, "", func(k, v []byte, _ CurrentTableReader, _ LoadNextFunc) error {
+			seen = append(seen, string(k)+":"+string(v))
+			return nil
+		}, TransformArgs{})
+		require.NoError(t, err)
+		return seen
+	}
+
+	raw, rawSize := collect(CompressionNone, t.TempDir())
+	defer raw.Close()
+	compressed, compressedSize := collect(CompressionFlate, t.TempDir())
+	defer compressed.Close()
+	require.Less(t, compressedSize, rawSize)
+	expect := load(raw)
+	require.Len(t, expect, 1000)
+	require.Equal(t, expect, load(compressed))
+
+	// left-over compressed files can be loaded by new collector
+	tmpdir := t.TempDir()
+	c, _ := collect(CompressionFlate, tmpdir)
+	for _, p := range c.dataProviders {
+		require.NoError(t, p.(*fileDataProvider).file.Close())
+	}
+	fromFiles, err := NewCollectorFromFiles(t.Name(), tmpdir, logger)
+	require.NoError(t, err)
+	defer fromFiles.Close()
+	require.Equal(t, expect, load(fromFiles))
+}
+
+func TestResume(t *testing.T) {
+	logger := log.New()
+	_, tx := memdb.NewTestTx(t)
+	toBucket := kv.ChaindataTables[1]
+	tmpdir := t.TempDir()
+
+	c := NewCriticalCollector(t.Name(), tmpdir, NewSortableBuffer(1024), logger)
+	c.WithManifest()
+	for i := 0; i < 100; i++ {
+		require.NoError(t, c.Collect([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%d", i))))
+		require.NoError(t, c.Collect([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%d", i)))) // duplicates
+	}
+
+	// interrupt loading after 41 entries, commit and checkpoint
+	quit := make(chan struct{})
+	loaded := 0
+	err := c.Load(tx, toBucket, func(k, v []byte, table CurrentTableReader, next LoadNextFunc) error {
+		if loaded++; loaded == 41 {
+			close(quit)
+		}
+		return next(k, k, v)
+	}, TransformArgs{Quit: quit})
+	require.ErrorIs(t, err, common.ErrStopped)
+	require.NoError(t, c.Checkpoint())
+	require.FileExists(t, filepath.Join(tmpdir, manifestFileName(t.Name())))
+
+	// restart
+	c, err = NewCollectorFromFiles(t.Name(), tmpdir, logger)
+	require.NoError(t, err)
+	var firstKey []byte
+	loaded = 0
+	err = c.Resume(tx, toBucket, func(k, v []byte, table CurrentTableReader, next LoadNextFunc) error {
+		if loaded++; firstKey == nil {
+			firstKey = common.Copy(k)
+		}
+		return next(k, k, v)
+	}, TransformArgs{})
+	require.NoError(t, err)
+	require.Equal(t, 200-41, loaded)
+	require.Equal(t, "key-020", string(firstKey)) // 2nd entry of key-020
+
+	cnt := 0
+	require.NoError(t, tx.ForEach(toBucket, nil, func(k, v []byte) error {
+		cnt++
+		return nil
+	}))
+	require.Equal(t, 100, cnt)
+
+	c.Close()
+	files, err := os.ReadDir(tmpdir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestResumeAfterCrash(t *testing.T) {
+	logger := log.New()
+	ctx, db := context.Background(), memdb.NewTestDB(t)
+	toBucket := kv.ChaindataTables[1]
+	tmpdir := t.TempDir()
+
+	c := NewCriticalCollector(t.Name(), tmpdir, NewSortableBuffer(1024), logger)
+	c.WithManifest()
+	for i := 0; i < 100; i++ {
+		require.NoError(t, c.Collect([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%d", i))))
+		require.NoError(t, c.Collect([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%d", i)))) // duplicates
+	}
+
+	// progress is saved every 10 entries, crash after 55: not committed entries are lost
+	tx, err := db.BeginRw(ctx)
+	require.NoError(t, err)
+	commit := func() (kv.RwTx, error) {
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		tx, err = db.BeginRw(ctx)
+		return tx, err
+	}
+	errCrash := fmt.Errorf("crash")
+	loaded := 0
+	err = c.Load(tx, toBucket, func(k, v []byte, table CurrentTableReader, next LoadNextFunc) error {
+		if loaded++; loaded == 56 {
+			return errCrash
+		}
+		return next(k, k, v)
+	}, TransformArgs{CommitEvery: 10, Commit: commit})
+	require.ErrorIs(t, err, errCrash)
+	tx.Rollback()
+
+	// restart
+	c, err = NewCollectorFromFiles(t.Name(), tmpdir, logger)
+	require.NoError(t, err)
+	defer c.Close()
+	tx, err = db.BeginRw(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	count := func() (cnt int) {
+		require.NoError(t, tx.ForEach(toBucket, nil, func(k, v []byte) error {
+			cnt++
+			return nil
+		}))
+		return cnt
+	}
+	require.Equal(t, 25, count())
+
+	loaded = 0
+	require.NoError(t, c.Resume(tx, toBucket, func(k, v []byte, table CurrentTableReader, next LoadNextFunc) error {
+		loaded++
+		return next(k, k, v)
+	}, TransformArgs{}))
+	require.Equal(t, 200-50, loaded)
+	require.Equal(t, 100, count())
+}
+
+func TestManifestPerCollector(t *testing.T) {
+	logger := log.New()
+	tmpdir := t.TempDir()
+	collect := func(logPrefix string, withManifest bool) *Collector {
+		c := NewCriticalCollector(logPrefix, tmpdir, NewSortableBuffer(64), logger)
+		if withManifest {
+			c.WithManifest()
+		}
+		for i := 0; i < 10; i++ {
+			require.NoError(t, c.Collect([]byte(fmt.Sprintf("%s-%02d", logPrefix, i)), []byte{1}))
+		}
+		require.NoError(t, c.Load(nil, "", func(k, v []byte, _ CurrentTableReader, _ LoadNextFunc) error { return nil }, TransformArgs{}))
+		require.NoError(t, c.Checkpoint())
+		return c
+	}
+
+	// manifest is opt-in
+	c := collect("no-manifest", false)
+	require.NoFileExists(t, filepath.Join(tmpdir, manifestFileName("no-manifest")))
+	c.Close()
+
+	// collectors sharing tmpdir don't overwrite or remove manifests of each other
+	a, b := collect("[1/2 A]", true), collect("[2/2 B]", true)
+	require.FileExists(t, filepath.Join(tmpdir, manifestFileName("[1/2 A]")))
+	a.Close()
+	require.NoFileExists(t, filepath.Join(tmpdir, manifestFileName("[1/2 A]")))
+	require.FileExists(t, filepath.Join(tmpdir, manifestFileName("[2/2 B]")))
+	for _, p := range b.dataProviders {
+		require.NoError(t, p.(*fileDataProvider).file.Close())
+	}
+	fromFiles, err := NewCollectorFromFiles("[2/2 B]", tmpdir, logger)
+	require.NoError(t, err)
+	defer fromFiles.Close()
+	require.Equal(t, b.manifest.Files, fromFiles.manifest.Files)
+}
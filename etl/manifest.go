@@ -0,0 +1,89 @@
+/*
+   Copyright 2021 Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package etl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const manifestFilePrefix = "erigon-etl-manifest-"
+
+// manifestFileName - one manifest per collector: collectors may share tmpdir. logPrefix is hex-encoded - it's not a valid file name
+func manifestFileName(logPrefix string) string {
+	return fmt.Sprintf("%s%x.json", manifestFilePrefix, logPrefix)
+}
+
+// manifest - describes files of critical collector and progress of it's loading. Stored in tmpdir,
+// allows to continue loading after restart (see Collector.WithManifest, Collector.Checkpoint, Collector.Resume).
+type manifest struct {
+	BufType int      `json:"bufType"`
+	Files   []string `json:"files"` // in order of providers: order of equal keys depends on it
+	// LastKey - last key loaded before Collector.Checkpoint, Loaded - amount of loaded entries (including LastKey).
+	// Entries are counted because same key may appear in many entries
+	LastKey []byte `json:"lastKey"`
+	Loaded  uint64 `json:"loaded"`
+}
+
+func readManifest(tmpdir, logPrefix string) (*manifest, error) {
+	data, err := os.ReadFile(filepath.Join(tmpdir, manifestFileName(logPrefix)))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	m := &manifest{}
+	if err = json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", manifestFileName(logPrefix), err)
+	}
+	return m, nil
+}
+
+// write - atomic: write to tmp file then rename, a crash leaves old or new version
+func (m *manifest) write(tmpdir, logPrefix string) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	fPath := filepath.Join(tmpdir, manifestFileName(logPrefix))
+	f, err := os.Create(fPath + ".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(fPath+".tmp", fPath)
+}
+
+func removeManifest(tmpdir, logPrefix string) {
+	fPath := filepath.Join(tmpdir, manifestFileName(logPrefix))
+	_ = os.Remove(fPath)
+	_ = os.Remove(fPath + ".tmp")
+}
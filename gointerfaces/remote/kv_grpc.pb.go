@@ -26,6 +26,11 @@ const (
 	KV_StateChanges_FullMethodName = "/remote.KV/StateChanges"
 	KV_Snapshots_FullMethodName    = "/remote.KV/Snapshots"
 	KV_Range_FullMethodName        = "/remote.KV/Range"
+	KV_Stream_FullMethodName       = "/remote.KV/Stream"
+	KV_RangeDupSort_FullMethodName = "/remote.KV/RangeDupSort"
+	KV_Sequence_FullMethodName     = "/remote.KV/Sequence"
+	KV_Size_FullMethodName         = "/remote.KV/Size"
+	KV_Commit_FullMethodName       = "/remote.KV/Commit"
 	KV_DomainGet_FullMethodName    = "/remote.KV/DomainGet"
 	KV_HistoryGet_FullMethodName   = "/remote.KV/HistoryGet"
 	KV_IndexRange_FullMethodName   = "/remote.KV/IndexRange"
@@ -53,7 +58,20 @@ type KVClient interface {
 	// Range(nil, to)   means [StartOfTable, to)
 	// If orderAscend=false server expecting `from`<`to`. Example: Range("B", "A")
 	Range(ctx context.Context, in *RangeReq, opts ...grpc.CallOption) (*Pairs, error)
-	// Temporal methods
+	// Stream - same query as Range, but server pushes all pairs in batches of `page_size` (`page_token` is ignored).
+	// HTTP/2 flow control limits amount of un-consumed batches. Client can stop stream by cancelling call's context.
+	Stream(ctx context.Context, in *RangeReq, opts ...grpc.CallOption) (KV_StreamClient, error)
+	// RangeDupSort - like Range, but for fixed single key of DupSort table and iterating over range of values
+	RangeDupSort(ctx context.Context, in *RangeDupSortReq, opts ...grpc.CallOption) (*Pairs, error)
+	// Sequence returns current value of table's sequence. Doesn't increment it.
+	Sequence(ctx context.Context, in *SequenceReq, opts ...grpc.CallOption) (*SequenceReply, error)
+	// Size returns size of table in bytes. If table is empty - returns size of whole DB.
+	Size(ctx context.Context, in *SizeReq, opts ...grpc.CallOption) (*SizeReply, error)
+	// Commit - applies writes buffered by client-side RwTx in one server-side RwTx.
+	// Opt-in: server accepts writes only to tables from it's allow-list, otherwise returns `PermissionDenied` status.
+	// Optimistic concurrency: if DB was modified after `view_id` - returns `Aborted` status and writes nothing.
+	Commit(ctx context.Context, in *CommitReq, opts ...grpc.CallOption) (*CommitReply, error)
+	//Temporal methods
 	DomainGet(ctx context.Context, in *DomainGetReq, opts ...grpc.CallOption) (*DomainGetReply, error)
 	HistoryGet(ctx context.Context, in *HistoryGetReq, opts ...grpc.CallOption) (*HistoryGetReply, error)
 	IndexRange(ctx context.Context, in *IndexRangeReq, opts ...grpc.CallOption) (*IndexRangeReply, error)
@@ -159,6 +177,74 @@ func (c *kVClient) Range(ctx context.Context, in *RangeReq, opts ...grpc.CallOpt
 	return out, nil
 }
 
+func (c *kVClient) Stream(ctx context.Context, in *RangeReq, opts ...grpc.CallOption) (KV_StreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &KV_ServiceDesc.Streams[2], KV_Stream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &kVStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type KV_StreamClient interface {
+	Recv() (*Pairs, error)
+	grpc.ClientStream
+}
+
+type kVStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *kVStreamClient) Recv() (*Pairs, error) {
+	m := new(Pairs)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *kVClient) RangeDupSort(ctx context.Context, in *RangeDupSortReq, opts ...grpc.CallOption) (*Pairs, error) {
+	out := new(Pairs)
+	err := c.cc.Invoke(ctx, KV_RangeDupSort_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVClient) Sequence(ctx context.Context, in *SequenceReq, opts ...grpc.CallOption) (*SequenceReply, error) {
+	out := new(SequenceReply)
+	err := c.cc.Invoke(ctx, KV_Sequence_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVClient) Size(ctx context.Context, in *SizeReq, opts ...grpc.CallOption) (*SizeReply, error) {
+	out := new(SizeReply)
+	err := c.cc.Invoke(ctx, KV_Size_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVClient) Commit(ctx context.Context, in *CommitReq, opts ...grpc.CallOption) (*CommitReply, error) {
+	out := new(CommitReply)
+	err := c.cc.Invoke(ctx, KV_Commit_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *kVClient) DomainGet(ctx context.Context, in *DomainGetReq, opts ...grpc.CallOption) (*DomainGetReply, error) {
 	out := new(DomainGetReply)
 	err := c.cc.Invoke(ctx, KV_DomainGet_FullMethodName, in, out, opts...)
@@ -224,7 +310,20 @@ type KVServer interface {
 	// Range(nil, to)   means [StartOfTable, to)
 	// If orderAscend=false server expecting `from`<`to`. Example: Range("B", "A")
 	Range(context.Context, *RangeReq) (*Pairs, error)
-	// Temporal methods
+	// Stream - same query as Range, but server pushes all pairs in batches of `page_size` (`page_token` is ignored).
+	// HTTP/2 flow control limits amount of un-consumed batches. Client can stop stream by cancelling call's context.
+	Stream(*RangeReq, KV_StreamServer) error
+	// RangeDupSort - like Range, but for fixed single key of DupSort table and iterating over range of values
+	RangeDupSort(context.Context, *RangeDupSortReq) (*Pairs, error)
+	// Sequence returns current value of table's sequence. Doesn't increment it.
+	Sequence(context.Context, *SequenceReq) (*SequenceReply, error)
+	// Size returns size of table in bytes. If table is empty - returns size of whole DB.
+	Size(context.Context, *SizeReq) (*SizeReply, error)
+	// Commit - applies writes buffered by client-side RwTx in one server-side RwTx.
+	// Opt-in: server accepts writes only to tables from it's allow-list, otherwise returns `PermissionDenied` status.
+	// Optimistic concurrency: if DB was modified after `view_id` - returns `Aborted` status and writes nothing.
+	Commit(context.Context, *CommitReq) (*CommitReply, error)
+	//Temporal methods
 	DomainGet(context.Context, *DomainGetReq) (*DomainGetReply, error)
 	HistoryGet(context.Context, *HistoryGetReq) (*HistoryGetReply, error)
 	IndexRange(context.Context, *IndexRangeReq) (*IndexRangeReply, error)
@@ -252,6 +351,21 @@ func (UnimplementedKVServer) Snapshots(context.Context, *SnapshotsRequest) (*Sna
 func (UnimplementedKVServer) Range(context.Context, *RangeReq) (*Pairs, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Range not implemented")
 }
+func (UnimplementedKVServer) Stream(*RangeReq, KV_StreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
+}
+func (UnimplementedKVServer) RangeDupSort(context.Context, *RangeDupSortReq) (*Pairs, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RangeDupSort not implemented")
+}
+func (UnimplementedKVServer) Sequence(context.Context, *SequenceReq) (*SequenceReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Sequence not implemented")
+}
+func (UnimplementedKVServer) Size(context.Context, *SizeReq) (*SizeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Size not implemented")
+}
+func (UnimplementedKVServer) Commit(context.Context, *CommitReq) (*CommitReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Commit not implemented")
+}
 func (UnimplementedKVServer) DomainGet(context.Context, *DomainGetReq) (*DomainGetReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DomainGet not implemented")
 }
@@ -381,6 +495,99 @@ func _KV_Range_Handler(srv interface{}, ctx context.Context, dec func(interface{
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KV_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RangeReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KVServer).Stream(m, &kVStreamServer{stream})
+}
+
+type KV_StreamServer interface {
+	Send(*Pairs) error
+	grpc.ServerStream
+}
+
+type kVStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *kVStreamServer) Send(m *Pairs) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _KV_RangeDupSort_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RangeDupSortReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVServer).RangeDupSort(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KV_RangeDupSort_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVServer).RangeDupSort(ctx, req.(*RangeDupSortReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KV_Sequence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SequenceReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVServer).Sequence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KV_Sequence_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVServer).Sequence(ctx, req.(*SequenceReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KV_Size_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SizeReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVServer).Size(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KV_Size_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVServer).Size(ctx, req.(*SizeReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KV_Commit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommitReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVServer).Commit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KV_Commit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVServer).Commit(ctx, req.(*CommitReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _KV_DomainGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DomainGetReq)
 	if err := dec(in); err != nil {
@@ -490,6 +697,22 @@ var KV_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Range",
 			Handler:    _KV_Range_Handler,
 		},
+		{
+			MethodName: "RangeDupSort",
+			Handler:    _KV_RangeDupSort_Handler,
+		},
+		{
+			MethodName: "Sequence",
+			Handler:    _KV_Sequence_Handler,
+		},
+		{
+			MethodName: "Size",
+			Handler:    _KV_Size_Handler,
+		},
+		{
+			MethodName: "Commit",
+			Handler:    _KV_Commit_Handler,
+		},
 		{
 			MethodName: "DomainGet",
 			Handler:    _KV_DomainGet_Handler,
@@ -523,6 +746,11 @@ var KV_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _KV_StateChanges_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Stream",
+			Handler:       _KV_Stream_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "remote/kv.proto",
 }
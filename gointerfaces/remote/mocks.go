@@ -22,6 +22,9 @@ var _ KVClient = &KVClientMock{}
 //
 //		// make and configure a mocked KVClient
 //		mockedKVClient := &KVClientMock{
+//			CommitFunc: func(ctx context.Context, in *CommitReq, opts ...grpc.CallOption) (*CommitReply, error) {
+//				panic("mock out the Commit method")
+//			},
 //			DomainGetFunc: func(ctx context.Context, in *DomainGetReq, opts ...grpc.CallOption) (*DomainGetReply, error) {
 //				panic("mock out the DomainGet method")
 //			},
@@ -40,12 +43,24 @@ var _ KVClient = &KVClientMock{}
 //			RangeFunc: func(ctx context.Context, in *RangeReq, opts ...grpc.CallOption) (*Pairs, error) {
 //				panic("mock out the Range method")
 //			},
+//			RangeDupSortFunc: func(ctx context.Context, in *RangeDupSortReq, opts ...grpc.CallOption) (*Pairs, error) {
+//				panic("mock out the RangeDupSort method")
+//			},
+//			SequenceFunc: func(ctx context.Context, in *SequenceReq, opts ...grpc.CallOption) (*SequenceReply, error) {
+//				panic("mock out the Sequence method")
+//			},
+//			SizeFunc: func(ctx context.Context, in *SizeReq, opts ...grpc.CallOption) (*SizeReply, error) {
+//				panic("mock out the Size method")
+//			},
 //			SnapshotsFunc: func(ctx context.Context, in *SnapshotsRequest, opts ...grpc.CallOption) (*SnapshotsReply, error) {
 //				panic("mock out the Snapshots method")
 //			},
 //			StateChangesFunc: func(ctx context.Context, in *StateChangeRequest, opts ...grpc.CallOption) (KV_StateChangesClient, error) {
 //				panic("mock out the StateChanges method")
 //			},
+//			StreamFunc: func(ctx context.Context, in *RangeReq, opts ...grpc.CallOption) (KV_StreamClient, error) {
+//				panic("mock out the Stream method")
+//			},
 //			TxFunc: func(ctx context.Context, opts ...grpc.CallOption) (KV_TxClient, error) {
 //				panic("mock out the Tx method")
 //			},
@@ -59,6 +74,9 @@ var _ KVClient = &KVClientMock{}
 //
 //	}
 type KVClientMock struct {
+	// CommitFunc mocks the Commit method.
+	CommitFunc func(ctx context.Context, in *CommitReq, opts ...grpc.CallOption) (*CommitReply, error)
+
 	// DomainGetFunc mocks the DomainGet method.
 	DomainGetFunc func(ctx context.Context, in *DomainGetReq, opts ...grpc.CallOption) (*DomainGetReply, error)
 
@@ -77,12 +95,24 @@ type KVClientMock struct {
 	// RangeFunc mocks the Range method.
 	RangeFunc func(ctx context.Context, in *RangeReq, opts ...grpc.CallOption) (*Pairs, error)
 
+	// RangeDupSortFunc mocks the RangeDupSort method.
+	RangeDupSortFunc func(ctx context.Context, in *RangeDupSortReq, opts ...grpc.CallOption) (*Pairs, error)
+
+	// SequenceFunc mocks the Sequence method.
+	SequenceFunc func(ctx context.Context, in *SequenceReq, opts ...grpc.CallOption) (*SequenceReply, error)
+
+	// SizeFunc mocks the Size method.
+	SizeFunc func(ctx context.Context, in *SizeReq, opts ...grpc.CallOption) (*SizeReply, error)
+
 	// SnapshotsFunc mocks the Snapshots method.
 	SnapshotsFunc func(ctx context.Context, in *SnapshotsRequest, opts ...grpc.CallOption) (*SnapshotsReply, error)
 
 	// StateChangesFunc mocks the StateChanges method.
 	StateChangesFunc func(ctx context.Context, in *StateChangeRequest, opts ...grpc.CallOption) (KV_StateChangesClient, error)
 
+	// StreamFunc mocks the Stream method.
+	StreamFunc func(ctx context.Context, in *RangeReq, opts ...grpc.CallOption) (KV_StreamClient, error)
+
 	// TxFunc mocks the Tx method.
 	TxFunc func(ctx context.Context, opts ...grpc.CallOption) (KV_TxClient, error)
 
@@ -91,6 +121,15 @@ type KVClientMock struct {
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// Commit holds details about calls to the Commit method.
+		Commit []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In *CommitReq
+			// Opts is the opts argument value.
+			Opts []grpc.CallOption
+		}
 		// DomainGet holds details about calls to the DomainGet method.
 		DomainGet []struct {
 			// Ctx is the ctx argument value.
@@ -145,6 +184,33 @@ type KVClientMock struct {
 			// Opts is the opts argument value.
 			Opts []grpc.CallOption
 		}
+		// RangeDupSort holds details about calls to the RangeDupSort method.
+		RangeDupSort []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In *RangeDupSortReq
+			// Opts is the opts argument value.
+			Opts []grpc.CallOption
+		}
+		// Sequence holds details about calls to the Sequence method.
+		Sequence []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In *SequenceReq
+			// Opts is the opts argument value.
+			Opts []grpc.CallOption
+		}
+		// Size holds details about calls to the Size method.
+		Size []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In *SizeReq
+			// Opts is the opts argument value.
+			Opts []grpc.CallOption
+		}
 		// Snapshots holds details about calls to the Snapshots method.
 		Snapshots []struct {
 			// Ctx is the ctx argument value.
@@ -163,6 +229,15 @@ type KVClientMock struct {
 			// Opts is the opts argument value.
 			Opts []grpc.CallOption
 		}
+		// Stream holds details about calls to the Stream method.
+		Stream []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In *RangeReq
+			// Opts is the opts argument value.
+			Opts []grpc.CallOption
+		}
 		// Tx holds details about calls to the Tx method.
 		Tx []struct {
 			// Ctx is the ctx argument value.
@@ -180,18 +255,67 @@ type KVClientMock struct {
 			Opts []grpc.CallOption
 		}
 	}
+	lockCommit       sync.RWMutex
 	lockDomainGet    sync.RWMutex
 	lockDomainRange  sync.RWMutex
 	lockHistoryGet   sync.RWMutex
 	lockHistoryRange sync.RWMutex
 	lockIndexRange   sync.RWMutex
 	lockRange        sync.RWMutex
+	lockRangeDupSort sync.RWMutex
+	lockSequence     sync.RWMutex
+	lockSize         sync.RWMutex
 	lockSnapshots    sync.RWMutex
 	lockStateChanges sync.RWMutex
+	lockStream       sync.RWMutex
 	lockTx           sync.RWMutex
 	lockVersion      sync.RWMutex
 }
 
+// Commit calls CommitFunc.
+func (mock *KVClientMock) Commit(ctx context.Context, in *CommitReq, opts ...grpc.CallOption) (*CommitReply, error) {
+	callInfo := struct {
+		Ctx  context.Context
+		In   *CommitReq
+		Opts []grpc.CallOption
+	}{
+		Ctx:  ctx,
+		In:   in,
+		Opts: opts,
+	}
+	mock.lockCommit.Lock()
+	mock.calls.Commit = append(mock.calls.Commit, callInfo)
+	mock.lockCommit.Unlock()
+	if mock.CommitFunc == nil {
+		var (
+			commitReplyOut *CommitReply
+			errOut         error
+		)
+		return commitReplyOut, errOut
+	}
+	return mock.CommitFunc(ctx, in, opts...)
+}
+
+// CommitCalls gets all the calls that were made to Commit.
+// Check the length with:
+//
+//	len(mockedKVClient.CommitCalls())
+func (mock *KVClientMock) CommitCalls() []struct {
+	Ctx  context.Context
+	In   *CommitReq
+	Opts []grpc.CallOption
+} {
+	var calls []struct {
+		Ctx  context.Context
+		In   *CommitReq
+		Opts []grpc.CallOption
+	}
+	mock.lockCommit.RLock()
+	calls = mock.calls.Commit
+	mock.lockCommit.RUnlock()
+	return calls
+}
+
 // DomainGet calls DomainGetFunc.
 func (mock *KVClientMock) DomainGet(ctx context.Context, in *DomainGetReq, opts ...grpc.CallOption) (*DomainGetReply, error) {
 	callInfo := struct {
@@ -456,6 +580,138 @@ func (mock *KVClientMock) RangeCalls() []struct {
 	return calls
 }
 
+// RangeDupSort calls RangeDupSortFunc.
+func (mock *KVClientMock) RangeDupSort(ctx context.Context, in *RangeDupSortReq, opts ...grpc.CallOption) (*Pairs, error) {
+	callInfo := struct {
+		Ctx  context.Context
+		In   *RangeDupSortReq
+		Opts []grpc.CallOption
+	}{
+		Ctx:  ctx,
+		In:   in,
+		Opts: opts,
+	}
+	mock.lockRangeDupSort.Lock()
+	mock.calls.RangeDupSort = append(mock.calls.RangeDupSort, callInfo)
+	mock.lockRangeDupSort.Unlock()
+	if mock.RangeDupSortFunc == nil {
+		var (
+			pairsOut *Pairs
+			errOut   error
+		)
+		return pairsOut, errOut
+	}
+	return mock.RangeDupSortFunc(ctx, in, opts...)
+}
+
+// RangeDupSortCalls gets all the calls that were made to RangeDupSort.
+// Check the length with:
+//
+//	len(mockedKVClient.RangeDupSortCalls())
+func (mock *KVClientMock) RangeDupSortCalls() []struct {
+	Ctx  context.Context
+	In   *RangeDupSortReq
+	Opts []grpc.CallOption
+} {
+	var calls []struct {
+		Ctx  context.Context
+		In   *RangeDupSortReq
+		Opts []grpc.CallOption
+	}
+	mock.lockRangeDupSort.RLock()
+	calls = mock.calls.RangeDupSort
+	mock.lockRangeDupSort.RUnlock()
+	return calls
+}
+
+// Sequence calls SequenceFunc.
+func (mock *KVClientMock) Sequence(ctx context.Context, in *SequenceReq, opts ...grpc.CallOption) (*SequenceReply, error) {
+	callInfo := struct {
+		Ctx  context.Context
+		In   *SequenceReq
+		Opts []grpc.CallOption
+	}{
+		Ctx:  ctx,
+		In:   in,
+		Opts: opts,
+	}
+	mock.lockSequence.Lock()
+	mock.calls.Sequence = append(mock.calls.Sequence, callInfo)
+	mock.lockSequence.Unlock()
+	if mock.SequenceFunc == nil {
+		var (
+			sequenceReplyOut *SequenceReply
+			errOut           error
+		)
+		return sequenceReplyOut, errOut
+	}
+	return mock.SequenceFunc(ctx, in, opts...)
+}
+
+// SequenceCalls gets all the calls that were made to Sequence.
+// Check the length with:
+//
+//	len(mockedKVClient.SequenceCalls())
+func (mock *KVClientMock) SequenceCalls() []struct {
+	Ctx  context.Context
+	In   *SequenceReq
+	Opts []grpc.CallOption
+} {
+	var calls []struct {
+		Ctx  context.Context
+		In   *SequenceReq
+		Opts []grpc.CallOption
+	}
+	mock.lockSequence.RLock()
+	calls = mock.calls.Sequence
+	mock.lockSequence.RUnlock()
+	return calls
+}
+
+// Size calls SizeFunc.
+func (mock *KVClientMock) Size(ctx context.Context, in *SizeReq, opts ...grpc.CallOption) (*SizeReply, error) {
+	callInfo := struct {
+		Ctx  context.Context
+		In   *SizeReq
+		Opts []grpc.CallOption
+	}{
+		Ctx:  ctx,
+		In:   in,
+		Opts: opts,
+	}
+	mock.lockSize.Lock()
+	mock.calls.Size = append(mock.calls.Size, callInfo)
+	mock.lockSize.Unlock()
+	if mock.SizeFunc == nil {
+		var (
+			sizeReplyOut *SizeReply
+			errOut       error
+		)
+		return sizeReplyOut, errOut
+	}
+	return mock.SizeFunc(ctx, in, opts...)
+}
+
+// SizeCalls gets all the calls that were made to Size.
+// Check the length with:
+//
+//	len(mockedKVClient.SizeCalls())
+func (mock *KVClientMock) SizeCalls() []struct {
+	Ctx  context.Context
+	In   *SizeReq
+	Opts []grpc.CallOption
+} {
+	var calls []struct {
+		Ctx  context.Context
+		In   *SizeReq
+		Opts []grpc.CallOption
+	}
+	mock.lockSize.RLock()
+	calls = mock.calls.Size
+	mock.lockSize.RUnlock()
+	return calls
+}
+
 // Snapshots calls SnapshotsFunc.
 func (mock *KVClientMock) Snapshots(ctx context.Context, in *SnapshotsRequest, opts ...grpc.CallOption) (*SnapshotsReply, error) {
 	callInfo := struct {
@@ -544,6 +800,50 @@ func (mock *KVClientMock) StateChangesCalls() []struct {
 	return calls
 }
 
+// Stream calls StreamFunc.
+func (mock *KVClientMock) Stream(ctx context.Context, in *RangeReq, opts ...grpc.CallOption) (KV_StreamClient, error) {
+	callInfo := struct {
+		Ctx  context.Context
+		In   *RangeReq
+		Opts []grpc.CallOption
+	}{
+		Ctx:  ctx,
+		In:   in,
+		Opts: opts,
+	}
+	mock.lockStream.Lock()
+	mock.calls.Stream = append(mock.calls.Stream, callInfo)
+	mock.lockStream.Unlock()
+	if mock.StreamFunc == nil {
+		var (
+			kV_StreamClientOut KV_StreamClient
+			errOut             error
+		)
+		return kV_StreamClientOut, errOut
+	}
+	return mock.StreamFunc(ctx, in, opts...)
+}
+
+// StreamCalls gets all the calls that were made to Stream.
+// Check the length with:
+//
+//	len(mockedKVClient.StreamCalls())
+func (mock *KVClientMock) StreamCalls() []struct {
+	Ctx  context.Context
+	In   *RangeReq
+	Opts []grpc.CallOption
+} {
+	var calls []struct {
+		Ctx  context.Context
+		In   *RangeReq
+		Opts []grpc.CallOption
+	}
+	mock.lockStream.RLock()
+	calls = mock.calls.Stream
+	mock.lockStream.RUnlock()
+	return calls
+}
+
 // Tx calls TxFunc.
 func (mock *KVClientMock) Tx(ctx context.Context, opts ...grpc.CallOption) (KV_TxClient, error) {
 	callInfo := struct {
@@ -945,3 +1245,321 @@ func (mock *KV_StateChangesClientMock) TrailerCalls() []struct {
 	mock.lockTrailer.RUnlock()
 	return calls
 }
+
+// Ensure, that KV_StreamClientMock does implement KV_StreamClient.
+// If this is not the case, regenerate this file with moq.
+var _ KV_StreamClient = &KV_StreamClientMock{}
+
+// KV_StreamClientMock is a mock implementation of KV_StreamClient.
+//
+//	func TestSomethingThatUsesKV_StreamClient(t *testing.T) {
+//
+//		// make and configure a mocked KV_StreamClient
+//		mockedKV_StreamClient := &KV_StreamClientMock{
+//			CloseSendFunc: func() error {
+//				panic("mock out the CloseSend method")
+//			},
+//			ContextFunc: func() context.Context {
+//				panic("mock out the Context method")
+//			},
+//			HeaderFunc: func() (metadata.MD, error) {
+//				panic("mock out the Header method")
+//			},
+//			RecvFunc: func() (*Pairs, error) {
+//				panic("mock out the Recv method")
+//			},
+//			RecvMsgFunc: func(m any) error {
+//				panic("mock out the RecvMsg method")
+//			},
+//			SendMsgFunc: func(m any) error {
+//				panic("mock out the SendMsg method")
+//			},
+//			TrailerFunc: func() metadata.MD {
+//				panic("mock out the Trailer method")
+//			},
+//		}
+//
+//		// use mockedKV_StreamClient in code that requires KV_StreamClient
+//		// and then make assertions.
+//
+//	}
+type KV_StreamClientMock struct {
+	// CloseSendFunc mocks the CloseSend method.
+	CloseSendFunc func() error
+
+	// ContextFunc mocks the Context method.
+	ContextFunc func() context.Context
+
+	// HeaderFunc mocks the Header method.
+	HeaderFunc func() (metadata.MD, error)
+
+	// RecvFunc mocks the Recv method.
+	RecvFunc func() (*Pairs, error)
+
+	// RecvMsgFunc mocks the RecvMsg method.
+	RecvMsgFunc func(m any) error
+
+	// SendMsgFunc mocks the SendMsg method.
+	SendMsgFunc func(m any) error
+
+	// TrailerFunc mocks the Trailer method.
+	TrailerFunc func() metadata.MD
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// CloseSend holds details about calls to the CloseSend method.
+		CloseSend []struct {
+		}
+		// Context holds details about calls to the Context method.
+		Context []struct {
+		}
+		// Header holds details about calls to the Header method.
+		Header []struct {
+		}
+		// Recv holds details about calls to the Recv method.
+		Recv []struct {
+		}
+		// RecvMsg holds details about calls to the RecvMsg method.
+		RecvMsg []struct {
+			// M is the m argument value.
+			M any
+		}
+		// SendMsg holds details about calls to the SendMsg method.
+		SendMsg []struct {
+			// M is the m argument value.
+			M any
+		}
+		// Trailer holds details about calls to the Trailer method.
+		Trailer []struct {
+		}
+	}
+	lockCloseSend sync.RWMutex
+	lockContext   sync.RWMutex
+	lockHeader    sync.RWMutex
+	lockRecv      sync.RWMutex
+	lockRecvMsg   sync.RWMutex
+	lockSendMsg   sync.RWMutex
+	lockTrailer   sync.RWMutex
+}
+
+// CloseSend calls CloseSendFunc.
+func (mock *KV_StreamClientMock) CloseSend() error {
+	callInfo := struct {
+	}{}
+	mock.lockCloseSend.Lock()
+	mock.calls.CloseSend = append(mock.calls.CloseSend, callInfo)
+	mock.lockCloseSend.Unlock()
+	if mock.CloseSendFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.CloseSendFunc()
+}
+
+// CloseSendCalls gets all the calls that were made to CloseSend.
+// Check the length with:
+//
+//	len(mockedKV_StreamClient.CloseSendCalls())
+func (mock *KV_StreamClientMock) CloseSendCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockCloseSend.RLock()
+	calls = mock.calls.CloseSend
+	mock.lockCloseSend.RUnlock()
+	return calls
+}
+
+// Context calls ContextFunc.
+func (mock *KV_StreamClientMock) Context() context.Context {
+	callInfo := struct {
+	}{}
+	mock.lockContext.Lock()
+	mock.calls.Context = append(mock.calls.Context, callInfo)
+	mock.lockContext.Unlock()
+	if mock.ContextFunc == nil {
+		var (
+			contextOut context.Context
+		)
+		return contextOut
+	}
+	return mock.ContextFunc()
+}
+
+// ContextCalls gets all the calls that were made to Context.
+// Check the length with:
+//
+//	len(mockedKV_StreamClient.ContextCalls())
+func (mock *KV_StreamClientMock) ContextCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockContext.RLock()
+	calls = mock.calls.Context
+	mock.lockContext.RUnlock()
+	return calls
+}
+
+// Header calls HeaderFunc.
+func (mock *KV_StreamClientMock) Header() (metadata.MD, error) {
+	callInfo := struct {
+	}{}
+	mock.lockHeader.Lock()
+	mock.calls.Header = append(mock.calls.Header, callInfo)
+	mock.lockHeader.Unlock()
+	if mock.HeaderFunc == nil {
+		var (
+			mDOut  metadata.MD
+			errOut error
+		)
+		return mDOut, errOut
+	}
+	return mock.HeaderFunc()
+}
+
+// HeaderCalls gets all the calls that were made to Header.
+// Check the length with:
+//
+//	len(mockedKV_StreamClient.HeaderCalls())
+func (mock *KV_StreamClientMock) HeaderCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockHeader.RLock()
+	calls = mock.calls.Header
+	mock.lockHeader.RUnlock()
+	return calls
+}
+
+// Recv calls RecvFunc.
+func (mock *KV_StreamClientMock) Recv() (*Pairs, error) {
+	callInfo := struct {
+	}{}
+	mock.lockRecv.Lock()
+	mock.calls.Recv = append(mock.calls.Recv, callInfo)
+	mock.lockRecv.Unlock()
+	if mock.RecvFunc == nil {
+		var (
+			pairsOut *Pairs
+			errOut   error
+		)
+		return pairsOut, errOut
+	}
+	return mock.RecvFunc()
+}
+
+// RecvCalls gets all the calls that were made to Recv.
+// Check the length with:
+//
+//	len(mockedKV_StreamClient.RecvCalls())
+func (mock *KV_StreamClientMock) RecvCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockRecv.RLock()
+	calls = mock.calls.Recv
+	mock.lockRecv.RUnlock()
+	return calls
+}
+
+// RecvMsg calls RecvMsgFunc.
+func (mock *KV_StreamClientMock) RecvMsg(m any) error {
+	callInfo := struct {
+		M any
+	}{
+		M: m,
+	}
+	mock.lockRecvMsg.Lock()
+	mock.calls.RecvMsg = append(mock.calls.RecvMsg, callInfo)
+	mock.lockRecvMsg.Unlock()
+	if mock.RecvMsgFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.RecvMsgFunc(m)
+}
+
+// RecvMsgCalls gets all the calls that were made to RecvMsg.
+// Check the length with:
+//
+//	len(mockedKV_StreamClient.RecvMsgCalls())
+func (mock *KV_StreamClientMock) RecvMsgCalls() []struct {
+	M any
+} {
+	var calls []struct {
+		M any
+	}
+	mock.lockRecvMsg.RLock()
+	calls = mock.calls.RecvMsg
+	mock.lockRecvMsg.RUnlock()
+	return calls
+}
+
+// SendMsg calls SendMsgFunc.
+func (mock *KV_StreamClientMock) SendMsg(m any) error {
+	callInfo := struct {
+		M any
+	}{
+		M: m,
+	}
+	mock.lockSendMsg.Lock()
+	mock.calls.SendMsg = append(mock.calls.SendMsg, callInfo)
+	mock.lockSendMsg.Unlock()
+	if mock.SendMsgFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.SendMsgFunc(m)
+}
+
+// SendMsgCalls gets all the calls that were made to SendMsg.
+// Check the length with:
+//
+//	len(mockedKV_StreamClient.SendMsgCalls())
+func (mock *KV_StreamClientMock) SendMsgCalls() []struct {
+	M any
+} {
+	var calls []struct {
+		M any
+	}
+	mock.lockSendMsg.RLock()
+	calls = mock.calls.SendMsg
+	mock.lockSendMsg.RUnlock()
+	return calls
+}
+
+// Trailer calls TrailerFunc.
+func (mock *KV_StreamClientMock) Trailer() metadata.MD {
+	callInfo := struct {
+	}{}
+	mock.lockTrailer.Lock()
+	mock.calls.Trailer = append(mock.calls.Trailer, callInfo)
+	mock.lockTrailer.Unlock()
+	if mock.TrailerFunc == nil {
+		var (
+			mDOut metadata.MD
+		)
+		return mDOut
+	}
+	return mock.TrailerFunc()
+}
+
+// TrailerCalls gets all the calls that were made to Trailer.
+// Check the length with:
+//
+//	len(mockedKV_StreamClient.TrailerCalls())
+func (mock *KV_StreamClientMock) TrailerCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockTrailer.RLock()
+	calls = mock.calls.Trailer
+	mock.lockTrailer.RUnlock()
+	return calls
+}
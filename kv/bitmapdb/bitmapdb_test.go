@@ -17,10 +17,17 @@
 package bitmapdb_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/RoaringBitmap/roaring"
+	"github.com/RoaringBitmap/roaring/roaring64"
+	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/bitmapdb"
+	"github.com/ledgerwatch/erigon-lib/kv/iter"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon-lib/kv/order"
 	"github.com/stretchr/testify/require"
 )
 
@@ -69,3 +76,124 @@ func TestCutLeft(t *testing.T) {
 	require.True(t, lft == nil)
 	require.True(t, bm.GetCardinality() == 0)
 }
+
+func TestRange(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	bucket := kv.LogAddressIndex
+	key := []byte{0x01, 0x02}
+	write := func(k []byte, bm *roaring.Bitmap) {
+		buf := bytes.NewBuffer(nil)
+		err := bitmapdb.WalkChunkWithKeys(k, bm, 64, func(chunkKey []byte, chunk *roaring.Bitmap) error {
+			buf.Reset()
+			if _, err := chunk.WriteTo(buf); err != nil {
+				return err
+			}
+			return tx.Put(bucket, chunkKey, common.Copy(buf.Bytes()))
+		})
+		require.NoError(t, err)
+	}
+	bm := roaring.New()
+	for j := uint32(1); j < 2_000; j += 3 {
+		bm.Add(j)
+	}
+	expect := bm.ToArray()
+	write(key, bm)
+	write([]byte{0x01, 0x01}, roaring.BitmapOf(1, 5, 7))     // neighbours must not be visible
+	write([]byte{0x01, 0x03}, roaring.BitmapOf(2, 6, 1_000)) // neighbours must not be visible
+
+	filter := func(from, to int, asc order.By, limit int) (res []uint64) {
+		for _, v := range expect {
+			if asc && (from >= 0 && int(v) < from || to >= 0 && int(v) >= to) {
+				continue
+			}
+			if !asc && (from >= 0 && int(v) > from || to >= 0 && int(v) <= to) {
+				continue
+			}
+			res = append(res, uint64(v))
+		}
+		if !asc {
+			for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+				res[i], res[j] = res[j], res[i]
+			}
+		}
+		if limit >= 0 && len(res) > limit {
+			res = res[:limit]
+		}
+		return res
+	}
+	for _, tc := range []struct{ from, to, limit int }{{-1, -1, -1}, {100, 1_000, -1}, {101, 1_000, 5}, {0, 1, -1}, {3_000, -1, -1}} {
+		it, err := bitmapdb.Range(tx, bucket, key, tc.from, tc.to, order.Asc, tc.limit)
+		require.NoError(t, err)
+		res, err := iter.ToArr[uint64](it)
+		require.NoError(t, err)
+		require.Equal(t, filter(tc.from, tc.to, order.Asc, tc.limit), res, tc)
+
+		from, to := tc.to, tc.from // same range in Desc order
+		if from > 0 {
+			from--
+		}
+		if to > 0 {
+			to--
+		}
+		it, err = bitmapdb.Range(tx, bucket, key, from, to, order.Desc, tc.limit)
+		require.NoError(t, err)
+		res, err = iter.ToArr[uint64](it)
+		require.NoError(t, err)
+		require.Equal(t, filter(from, to, order.Desc, tc.limit), res, tc)
+	}
+
+	// seek
+	it, err := bitmapdb.Range(tx, bucket, key, -1, -1, order.Asc, -1)
+	require.NoError(t, err)
+	defer it.(iter.Closer).Close()
+	it.(iter.SeekableU64).Seek(1_500)
+	v, err := it.Next()
+	require.NoError(t, err)
+	require.Equal(t, 1_501, int(v))
+
+	it, err = bitmapdb.Range(tx, bucket, key, -1, -1, order.Desc, -1)
+	require.NoError(t, err)
+	defer it.(iter.Closer).Close()
+	it.(iter.SeekableU64).Seek(1_500)
+	v, err = it.Next()
+	require.NoError(t, err)
+	require.Equal(t, 1_498, int(v))
+
+	// missing key
+	it, err = bitmapdb.Range(tx, bucket, []byte{0x05, 0x05}, -1, -1, order.Desc, -1)
+	require.NoError(t, err)
+	defer it.(iter.Closer).Close()
+	require.False(t, it.HasNext())
+}
+
+func TestRange64(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	bucket := kv.LogAddressIndex
+	key := []byte{0x01, 0x02}
+	bm := roaring64.New()
+	for j := uint64(1); j < 2_000; j += 3 {
+		bm.Add(1<<32 + j) // values bigger than MaxUint32
+	}
+	expect := bm.ToArray()
+	buf := bytes.NewBuffer(nil)
+	err := bitmapdb.WalkChunkWithKeys64(key, bm, 64, func(chunkKey []byte, chunk *roaring64.Bitmap) error {
+		buf.Reset()
+		if _, err := chunk.WriteTo(buf); err != nil {
+			return err
+		}
+		return tx.Put(bucket, chunkKey, common.Copy(buf.Bytes()))
+	})
+	require.NoError(t, err)
+
+	it, err := bitmapdb.Range64(tx, bucket, key, -1, -1, order.Asc, -1)
+	require.NoError(t, err)
+	res, err := iter.ToArr[uint64](it)
+	require.NoError(t, err)
+	require.Equal(t, expect, res)
+
+	it, err = bitmapdb.Range64(tx, bucket, key, 1<<32+10, 1<<32+1, order.Desc, 2)
+	require.NoError(t, err)
+	res, err = iter.ToArr[uint64](it)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1<<32 + 10, 1<<32 + 7}, res)
+}
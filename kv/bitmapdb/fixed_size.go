@@ -20,15 +20,20 @@ import (
 	"bufio"
 	"encoding/binary"
 	"fmt"
+	"hash/crc32"
+	"io"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
 	"github.com/c2h5oh/datasize"
 	mmap2 "github.com/edsrzf/mmap-go"
 	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon-lib/common"
 )
 
 type FixedSizeBitmaps struct {
@@ -44,6 +49,9 @@ type FixedSizeBitmaps struct {
 	bitsPerBitmap int
 	size          int
 	modTime       time.Time
+
+	crc      checksums
+	verified []atomic.Bool // pages are verified on first access, nil for files without checksums
 }
 
 func OpenFixedSizeBitmaps(filePath string, bitsPerBitmap int) (*FixedSizeBitmaps, error) {
@@ -70,14 +78,102 @@ func OpenFixedSizeBitmaps(filePath string, bitsPerBitmap int) (*FixedSizeBitmaps
 		return nil, err
 	}
 	idx.metaData = idx.m[:MetaHeaderSize]
-	idx.data = castToArrU64(idx.m[MetaHeaderSize:])
-
 	idx.version = idx.metaData[0]
 	idx.amount = binary.BigEndian.Uint64(idx.metaData[1 : 8+1])
 
+	if idx.version < versionWithChecksum {
+		idx.data = castToArrU64(idx.m[MetaHeaderSize:])
+		return idx, nil
+	}
+	if idx.crc, err = newChecksums(idx.m); err != nil {
+		idx.Close()
+		return nil, fmt.Errorf("%s: %w", fName, err)
+	}
+	// only header is verified here, rows - on first access: opening doesn't read whole file.
+	// It's safe because published file is never modified: OpenFixedSizeBitmapsRw works on .tmp copy
+	if err = idx.crc.verify(0); err != nil {
+		idx.Close()
+		return nil, fmt.Errorf("%s: %w", fName, err)
+	}
+	idx.verified = make([]atomic.Bool, idx.crc.pages())
+	idx.verified[0].Store(true)
+	idx.data = castToArrU64(idx.m[MetaHeaderSize:idx.crc.tableStart])
 	return idx, nil
 }
 
+// verify - checks pages of blocks [blkFrom, blkTo) if they were not checked yet
+func (bm *FixedSizeBitmaps) verify(blkFrom, blkTo int) error {
+	if bm.verified == nil {
+		return nil
+	}
+	for page := pageOfBlock(blkFrom); page <= pageOfBlock(blkTo-1) && page < len(bm.verified); page++ {
+		if bm.verified[page].Load() {
+			continue
+		}
+		if err := bm.crc.verify(page); err != nil {
+			return fmt.Errorf("%s: %w", bm.fileName, err)
+		}
+		bm.verified[page].Store(true)
+	}
+	return nil
+}
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// crcPageSize - granularity of checksums. Doesn't depend on OS page size: file can be moved to other machine
+const crcPageSize = 4096
+
+// checksums - detects torn writes and corruption of file on disk.
+// Table at the end of file has crc32 of every crcPageSize bytes of file (table itself is not covered):
+// writer re-computes checksums only of modified pages, reader verifies page on first access.
+type checksums struct {
+	m          []byte
+	tableStart int
+}
+
+func checksumsSize(fileSize int) int { return fileSize / crcPageSize * 4 }
+
+func newChecksums(m []byte) (checksums, error) {
+	if len(m)%crcPageSize != 0 {
+		return checksums{}, fmt.Errorf("file size is not aligned: %d", len(m))
+	}
+	c := checksums{m: m, tableStart: len(m) - checksumsSize(len(m))}
+	if c.tableStart < MetaHeaderSize {
+		return checksums{}, fmt.Errorf("file is too small: %d", len(m))
+	}
+	return c, nil
+}
+
+func (c checksums) pages() int { return len(c.m) / crcPageSize }
+
+func (c checksums) compute(page int) uint32 {
+	from, to := page*crcPageSize, (page+1)*crcPageSize
+	if to > c.tableStart {
+		to = c.tableStart
+	}
+	if from >= to {
+		return 0
+	}
+	return crc32.Checksum(c.m[from:to], castagnoliTable)
+}
+
+func (c checksums) stored(page int) uint32 {
+	return binary.BigEndian.Uint32(c.m[c.tableStart+page*4:])
+}
+
+func (c checksums) store(page int, crc uint32) {
+	binary.BigEndian.PutUint32(c.m[c.tableStart+page*4:], crc)
+}
+
+func (c checksums) verify(page int) error {
+	if got, expect := c.compute(page), c.stored(page); got != expect {
+		return fmt.Errorf("checksum mismatch (torn write?) at page %d: %x != %x", page, got, expect)
+	}
+	return nil
+}
+
+func pageOfBlock(blk int) int { return (MetaHeaderSize + blk*8) / crcPageSize }
+
 func (bm *FixedSizeBitmaps) FileName() string { return bm.fileName }
 func (bm *FixedSizeBitmaps) FilePath() string { return bm.filePath }
 func (bm *FixedSizeBitmaps) Close() {
@@ -104,6 +200,9 @@ func (bm *FixedSizeBitmaps) At(item uint64) (res []uint64, err error) {
 	blkFrom, bitFrom := n/64, n%64
 	blkTo := (n+bm.bitsPerBitmap)/64 + 1
 	bitTo := 64
+	if err = bm.verify(blkFrom, blkTo); err != nil {
+		return nil, err
+	}
 
 	var j uint64
 	for i := blkFrom; i < blkTo; i++ {
@@ -130,6 +229,9 @@ func (bm *FixedSizeBitmaps) First2At(item, after uint64) (fst uint64, snd uint64
 	blkFrom, bitFrom := n/64, n%64
 	blkTo := (n+bm.bitsPerBitmap)/64 + 1
 	bitTo := 64
+	if err = bm.verify(blkFrom, blkTo); err != nil {
+		return 0, 0, false, false, err
+	}
 
 	var j uint64
 	for i := blkFrom; i < blkTo; i++ {
@@ -157,6 +259,8 @@ func (bm *FixedSizeBitmaps) First2At(item, after uint64) (fst uint64, snd uint64
 	return
 }
 
+// FixedSizeBitmapsWriter - creates new file (or opens existing one by OpenFixedSizeBitmapsRw).
+// Rows can be added by Append: file grows by whole pages.
 type FixedSizeBitmapsWriter struct {
 	f *os.File
 
@@ -170,24 +274,39 @@ type FixedSizeBitmapsWriter struct {
 	size          int
 	bitsPerBitmap uint64
 
+	crc        checksums
+	verified   []bool // page was verified before modification: Sync must not re-compute checksum of torn page
+	dirty      []bool // page was modified after last Sync
+	dirtyPages []int
+
 	logger  log.Logger
 	noFsync bool // fsync is enabled by default, but tests can manually disable
 }
 
 const MetaHeaderSize = 64
 
-func NewFixedSizeBitmapsWriter(indexFile string, bitsPerBitmap int, amount uint64, logger log.Logger) (*FixedSizeBitmapsWriter, error) {
+// versionWithChecksum - files of this version and newer have checksums table at the end (see `checksums`)
+const versionWithChecksum = 2
+
+// fileSize - page-size-aligned size of file with `amount` rows and checksums table. readers do allow access to row `amount` - reserve it
+func fileSize(bitsPerBitmap int, amount uint64) int {
 	pageSize := os.Getpagesize()
 	//TODO: use math.SafeMul()
-	bytesAmount := MetaHeaderSize + (bitsPerBitmap*int(amount))/8
+	bytesAmount := MetaHeaderSize + (bitsPerBitmap*int(amount+1)+63)/64*8
 	size := (bytesAmount/pageSize + 1) * pageSize // must be page-size-aligned
+	for bytesAmount+checksumsSize(size) > size {
+		size += pageSize
+	}
+	return size
+}
+
+func NewFixedSizeBitmapsWriter(indexFile string, bitsPerBitmap int, amount uint64, logger log.Logger) (*FixedSizeBitmapsWriter, error) {
 	idx := &FixedSizeBitmapsWriter{
 		indexFile:      indexFile,
 		tmpIdxFilePath: indexFile + ".tmp",
 		bitsPerBitmap:  uint64(bitsPerBitmap),
-		size:           size,
 		amount:         amount,
-		version:        1,
+		version:        versionWithChecksum,
 		logger:         logger,
 	}
 
@@ -199,26 +318,221 @@ func NewFixedSizeBitmapsWriter(indexFile string, bitsPerBitmap int, amount uint6
 		return nil, err
 	}
 
-	if err := growFileToSize(idx.f, idx.size); err != nil {
+	if err := idx.resize(fileSize(bitsPerBitmap, amount)); err != nil {
 		return nil, err
 	}
-
-	idx.m, err = mmap2.MapRegion(idx.f, idx.size, mmap2.RDWR, 0, 0)
-	if err != nil {
-		return nil, err
-	}
-
-	idx.metaData = idx.m[:MetaHeaderSize]
-	idx.data = castToArrU64(idx.m[MetaHeaderSize:])
 	//if err := mmap.MadviseNormal(idx.m); err != nil {
 	//	return nil, err
 	//}
 	idx.metaData[0] = idx.version
 	binary.BigEndian.PutUint64(idx.metaData[1:], idx.amount)
 	idx.amount = binary.BigEndian.Uint64(idx.metaData[1 : 8+1])
+	idx.modifiedAll()
 
 	return idx, nil
 }
+
+// OpenFixedSizeBitmapsRw - opens existing file for modifications: AddArray to existing rows, Append new rows.
+// Modifications go to .tmp copy of file, Build does replace file by it - readers which already opened file
+// keep seeing old version and never see partial changes. Sync makes .tmp durable (re-computes checksums of modified pages).
+// Corrupted pages of file are detected when page is verified (first modification). Files of old version (without checksums) are upgraded
+func OpenFixedSizeBitmapsRw(filePath string, bitsPerBitmap int, logger log.Logger) (*FixedSizeBitmapsWriter, error) {
+	idx := &FixedSizeBitmapsWriter{
+		indexFile:      filePath,
+		tmpIdxFilePath: filePath + ".tmp",
+		bitsPerBitmap:  uint64(bitsPerBitmap),
+		version:        versionWithChecksum,
+		logger:         logger,
+	}
+
+	var err error
+	if idx.f, err = copyToTmp(filePath, idx.tmpIdxFilePath); err != nil {
+		return nil, err
+	}
+	var stat os.FileInfo
+	if stat, err = idx.f.Stat(); err != nil {
+		idx.Close()
+		return nil, err
+	}
+	idx.size = int(stat.Size())
+	size := idx.size
+	if size < MetaHeaderSize {
+		idx.Close()
+		return nil, fmt.Errorf("%s: file is too small: %d", filePath, size)
+	}
+	header := make([]byte, MetaHeaderSize)
+	if _, err = idx.f.ReadAt(header, 0); err != nil {
+		idx.Close()
+		return nil, err
+	}
+	idx.amount = binary.BigEndian.Uint64(header[1 : 8+1])
+
+	if header[0] < versionWithChecksum {
+		// no checksums in file yet: bytes of future checksums table must not be used by rows
+		if need := fileSize(bitsPerBitmap, idx.amount); need > size {
+			size = need
+		}
+		if err = idx.resize(size); err != nil {
+			idx.Close()
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+		idx.modifiedAll()
+		return idx, nil
+	}
+
+	if err = idx.resize(size); err != nil {
+		idx.Close()
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	idx.verified, idx.dirty = make([]bool, idx.crc.pages()), make([]bool, idx.crc.pages())
+	if err = idx.crc.verify(0); err != nil {
+		idx.Close()
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	idx.verified[0] = true
+	return idx, nil
+}
+
+// copyToTmp - returns opened for read-write copy of file
+func copyToTmp(filePath, tmpFilePath string) (*os.File, error) {
+	src, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("OpenFile: %w", err)
+	}
+	defer src.Close()
+	_ = os.Remove(tmpFilePath)
+	dst, err := os.Create(tmpFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		dst.Close()
+		return nil, fmt.Errorf("copy %s: %w", filePath, err)
+	}
+	return dst, nil
+}
+
+// resize - grows file by whole pages (if it's smaller than `size`) and re-maps it. Checksums table is not moved
+func (w *FixedSizeBitmapsWriter) resize(size int) error {
+	if w.m != nil {
+		if err := w.m.Unmap(); err != nil {
+			return err
+		}
+		w.m = nil
+	}
+	if size > w.size {
+		if _, err := w.f.Seek(int64(w.size), io.SeekStart); err != nil {
+			return err
+		}
+		if err := growFileToSize(w.f, size-w.size); err != nil {
+			return err
+		}
+		w.size = size
+	}
+	var err error
+	w.m, err = mmap2.MapRegion(w.f, w.size, mmap2.RDWR, 0, 0)
+	if err != nil {
+		return err
+	}
+	if w.crc, err = newChecksums(w.m); err != nil {
+		return err
+	}
+	w.metaData = w.m[:MetaHeaderSize]
+	w.data = castToArrU64(w.m[MetaHeaderSize:w.crc.tableStart])
+	return nil
+}
+
+// grow - checksums table is at the end of file: it's moved, old place of it becomes part of rows
+func (w *FixedSizeBitmapsWriter) grow(size int) error {
+	if size <= w.size {
+		return nil
+	}
+	oldTableStart, oldPages := w.crc.tableStart, w.crc.pages()
+	firstMoved := oldTableStart / crcPageSize // coverage of pages starting from this one changes
+	if err := w.touch(firstMoved); err != nil {
+		return err
+	}
+	table := common.Copy(w.m[oldTableStart : oldTableStart+oldPages*4])
+	copy(w.m[oldTableStart:], make([]byte, len(w.m)-oldTableStart))
+	if err := w.resize(size); err != nil {
+		return err
+	}
+	copy(w.m[w.crc.tableStart:], table)
+	for page := len(w.verified); page < w.crc.pages(); page++ {
+		w.verified, w.dirty = append(w.verified, true), append(w.dirty, false)
+	}
+	for page := firstMoved; page < w.crc.pages(); page++ {
+		w.verified[page] = true
+		if err := w.touch(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// touch - page is going to be modified: verify it (once) and re-compute it's checksum on Sync
+func (w *FixedSizeBitmapsWriter) touch(page int) error {
+	if !w.verified[page] {
+		if err := w.crc.verify(page); err != nil {
+			return fmt.Errorf("%s: %w", w.indexFile, err)
+		}
+		w.verified[page] = true
+	}
+	if !w.dirty[page] {
+		w.dirty[page] = true
+		w.dirtyPages = append(w.dirtyPages, page)
+	}
+	return nil
+}
+
+// modifiedAll - new file or file without checksums: nothing to verify, all checksums must be computed
+func (w *FixedSizeBitmapsWriter) modifiedAll() {
+	pages := w.crc.pages()
+	w.verified, w.dirty, w.dirtyPages = make([]bool, pages), make([]bool, pages), make([]int, 0, pages)
+	for page := 0; page < pages; page++ {
+		w.verified[page] = true
+		_ = w.touch(page)
+	}
+}
+
+// Append - adds row after last one (it's number is returned). Grows file if needed - then amount doesn't need to be known upfront
+func (w *FixedSizeBitmapsWriter) Append(listOfValues []uint64) (item uint64, err error) {
+	item = w.amount
+	if need := fileSize(int(w.bitsPerBitmap), item+1); need > w.size {
+		// with headroom: every grow moves checksums table
+		pageSize := os.Getpagesize()
+		if headroom := (w.size + w.size/8) / pageSize * pageSize; headroom > need {
+			need = headroom
+		}
+		if err = w.grow(need); err != nil {
+			return 0, err
+		}
+	}
+	if err = w.touch(0); err != nil {
+		return 0, err
+	}
+	w.amount = item + 1
+	binary.BigEndian.PutUint64(w.metaData[1:], w.amount)
+	return item, w.AddArray(item, listOfValues)
+}
+
+// Sync - re-computes checksums of pages modified after previous Sync, then msync+fsync. Writer stays open
+func (w *FixedSizeBitmapsWriter) Sync() error {
+	if err := w.touch(0); err != nil {
+		return err
+	}
+	w.metaData[0] = w.version
+	binary.BigEndian.PutUint64(w.metaData[1:], w.amount)
+	for _, page := range w.dirtyPages {
+		w.crc.store(page, w.crc.compute(page))
+		w.dirty[page] = false
+	}
+	w.dirtyPages = w.dirtyPages[:0]
+	if err := w.m.Flush(); err != nil {
+		return err
+	}
+	return w.fsync()
+}
 func (w *FixedSizeBitmapsWriter) Close() {
 	if w.m != nil {
 		if err := w.m.Unmap(); err != nil {
@@ -270,19 +584,19 @@ func (w *FixedSizeBitmapsWriter) AddArray(item uint64, listOfValues []uint64) er
 		}
 		n := offset + v
 		blkAt, bitAt := int(n/64), int(n%64)
-		if blkAt > len(w.data) {
+		if blkAt >= len(w.data) {
 			return fmt.Errorf("too big value: %d, %d, max: %d", item, listOfValues, len(w.data))
 		}
+		if err := w.touch(pageOfBlock(blkAt)); err != nil {
+			return err
+		}
 		w.data[blkAt] |= (1 << bitAt)
 	}
 	return nil
 }
 
 func (w *FixedSizeBitmapsWriter) Build() error {
-	if err := w.m.Flush(); err != nil {
-		return err
-	}
-	if err := w.fsync(); err != nil {
+	if err := w.Sync(); err != nil {
 		return err
 	}
 
@@ -313,7 +627,7 @@ func (w *FixedSizeBitmapsWriter) fsync() error {
 		return nil
 	}
 	if err := w.f.Sync(); err != nil {
-		w.logger.Warn("couldn't fsync", "err", err, "file", w.tmpIdxFilePath)
+		w.logger.Warn("couldn't fsync", "err", err, "file", w.f.Name())
 		return err
 	}
 	return nil
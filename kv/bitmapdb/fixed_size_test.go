@@ -106,3 +106,172 @@ func TestPageAlined(t *testing.T) {
 	require.Equal((128/8*1000/os.Getpagesize()+1)*os.Getpagesize(), bm3.size)
 	defer bm3.Close()
 }
+
+func TestFixedSizeBitmapsAppend(t *testing.T) {
+	tmpDir, require := t.TempDir(), require.New(t)
+	idxPath := filepath.Join(tmpDir, "idx.tmp")
+
+	// amount is unknown upfront
+	wr, err := NewFixedSizeBitmapsWriter(idxPath, 14, 0, log.New())
+	require.NoError(err)
+	defer wr.Close()
+	wr.DisableFsync()
+	for i := uint64(0); i < 10_000; i++ {
+		item, err := wr.Append([]uint64{i % 14, 13})
+		require.NoError(err)
+		require.Equal(i, item)
+	}
+	require.Zero(wr.size % os.Getpagesize())
+	require.NoError(wr.Build())
+
+	// in-place modifications
+	rw, err := OpenFixedSizeBitmapsRw(idxPath, 14, log.New())
+	require.NoError(err)
+	defer rw.Close()
+	rw.DisableFsync()
+	require.NoError(rw.AddArray(1, []uint64{5}))
+	item, err := rw.Append([]uint64{7})
+	require.NoError(err)
+	require.Equal(uint64(10_000), item)
+	require.NoError(rw.Build())
+
+	bm, err := OpenFixedSizeBitmaps(idxPath, 14)
+	require.NoError(err)
+	defer bm.Close()
+	require.Equal(uint64(10_001), bm.amount)
+	for i := uint64(0); i < 10_000; i++ {
+		expect := []uint64{i % 14, 13}
+		if i%14 == 13 {
+			expect = []uint64{13}
+		} else if i == 1 {
+			expect = []uint64{1, 5, 13}
+		}
+		n, err := bm.At(i)
+		require.NoError(err)
+		require.Equal(expect, n, i)
+	}
+	n, err := bm.At(10_000)
+	require.NoError(err)
+	require.Equal([]uint64{7}, n)
+}
+
+// corruptPage - simulates torn write: page content doesn't match it's checksum
+func corruptPage(t *testing.T, filePath string, page int) {
+	t.Helper()
+	f, err := os.OpenFile(filePath, os.O_RDWR, 0)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteAt([]byte{0xff}, int64(page*crcPageSize+MetaHeaderSize))
+	require.NoError(t, err)
+}
+
+func TestFixedSizeBitmapsTornWrite(t *testing.T) {
+	tmpDir, require := t.TempDir(), require.New(t)
+	idxPath := filepath.Join(tmpDir, "idx.tmp")
+
+	wr, err := NewFixedSizeBitmapsWriter(idxPath, 14, 7, log.New())
+	require.NoError(err)
+	defer wr.Close()
+	require.NoError(wr.AddArray(0, []uint64{3, 9, 11}))
+	require.NoError(wr.Build())
+
+	corruptPage(t, idxPath, 0)
+	_, err = OpenFixedSizeBitmaps(idxPath, 14)
+	require.ErrorContains(err, "checksum mismatch")
+	_, err = OpenFixedSizeBitmapsRw(idxPath, 14, log.New())
+	require.ErrorContains(err, "checksum mismatch")
+}
+
+func TestFixedSizeBitmapsTornWriteLazy(t *testing.T) {
+	tmpDir, require := t.TempDir(), require.New(t)
+	idxPath := filepath.Join(tmpDir, "idx.tmp")
+
+	wr, err := NewFixedSizeBitmapsWriter(idxPath, 14, 10_000, log.New())
+	require.NoError(err)
+	defer wr.Close()
+	wr.DisableFsync()
+	require.NoError(wr.AddArray(0, []uint64{3}))
+	require.NoError(wr.Build())
+
+	// Sync re-computes checksums only of modified pages
+	rw, err := OpenFixedSizeBitmapsRw(idxPath, 14, log.New())
+	require.NoError(err)
+	rw.DisableFsync()
+	require.Greater(rw.crc.pages(), 2)
+	require.NoError(rw.AddArray(9_000, []uint64{4}))
+	require.Equal([]int{pageOfBlock(9_000 * 14 / 64)}, rw.dirtyPages)
+	rw.Close()
+
+	// torn page is detected on access, other pages are readable
+	corruptPage(t, idxPath, pageOfBlock(9_000*14/64))
+	bm, err := OpenFixedSizeBitmaps(idxPath, 14)
+	require.NoError(err)
+	defer bm.Close()
+	n, err := bm.At(0)
+	require.NoError(err)
+	require.Equal([]uint64{3}, n)
+	_, err = bm.At(9_000)
+	require.ErrorContains(err, "checksum mismatch")
+	_, _, _, _, err = bm.First2At(9_000, 0)
+	require.ErrorContains(err, "checksum mismatch")
+
+	rw, err = OpenFixedSizeBitmapsRw(idxPath, 14, log.New())
+	require.NoError(err)
+	defer rw.Close()
+	require.ErrorContains(rw.AddArray(9_000, []uint64{5}), "checksum mismatch")
+}
+
+func TestFixedSizeBitmapsRwWithOpenedReader(t *testing.T) {
+	tmpDir, require := t.TempDir(), require.New(t)
+	idxPath := filepath.Join(tmpDir, "idx.tmp")
+
+	wr, err := NewFixedSizeBitmapsWriter(idxPath, 14, 10_000, log.New())
+	require.NoError(err)
+	defer wr.Close()
+	wr.DisableFsync()
+	for i := uint64(0); i <= 10_000; i++ {
+		require.NoError(wr.AddArray(i, []uint64{i % 14}))
+	}
+	require.NoError(wr.Build())
+
+	bm, err := OpenFixedSizeBitmaps(idxPath, 14)
+	require.NoError(err)
+	defer bm.Close()
+
+	// reader doesn't see not-synced modifications, nor moved checksums table
+	rw, err := OpenFixedSizeBitmapsRw(idxPath, 14, log.New())
+	require.NoError(err)
+	defer rw.Close()
+	rw.DisableFsync()
+	for i := uint64(0); i <= 10_000; i++ {
+		require.NoError(rw.AddArray(i, []uint64{13}))
+	}
+	for i := 0; i < 10_000; i++ {
+		_, err = rw.Append([]uint64{1})
+		require.NoError(err)
+	}
+	require.NoError(rw.Sync())
+	for i := uint64(0); i <= 10_000; i++ {
+		n, err := bm.At(i)
+		require.NoError(err)
+		require.Equal([]uint64{i % 14}, n, i)
+	}
+
+	// after Build: old reader still sees old version, new reader - new one
+	require.NoError(rw.Build())
+	for i := uint64(0); i <= 10_000; i++ {
+		n, err := bm.At(i)
+		require.NoError(err)
+		require.Equal([]uint64{i % 14}, n, i)
+	}
+	bm2, err := OpenFixedSizeBitmaps(idxPath, 14)
+	require.NoError(err)
+	defer bm2.Close()
+	require.Equal(uint64(20_000), bm2.amount)
+	n, err := bm2.At(5)
+	require.NoError(err)
+	require.Equal([]uint64{5, 13}, n)
+	n, err = bm2.At(15_000)
+	require.NoError(err)
+	require.Equal([]uint64{1}, n)
+}
@@ -17,7 +17,17 @@ limitations under the License.
 package bitmapdb
 
 import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/RoaringBitmap/roaring"
 	"github.com/RoaringBitmap/roaring/roaring64"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/iter"
+	"github.com/ledgerwatch/erigon-lib/kv/order"
 )
 
 type BitmapStream struct {
@@ -32,3 +42,227 @@ func (it *BitmapStream) HasNext() bool                        { return it.it.Has
 func (it *BitmapStream) Close()                               { ReturnToPool64(it.bm) }
 func (it *BitmapStream) Next() (uint64, error)                { return it.it.Next(), nil }
 func (it *BitmapStream) ToBitmap() (*roaring64.Bitmap, error) { return it.bm, nil }
+
+// Range - streams values of bitmap stored in chunks (see WalkChunkWithKeys). Unlike Get - doesn't join chunks:
+// reads them lazily one by one. Semantic is same as kv.TemporalTx.IndexRange:
+// Asc:  [from, to) AND from < to
+// Desc: [from, to) AND from > to
+// Limit -1 means Unlimited, from -1, to -1 means unbounded
+func Range(tx kv.Tx, bucket string, key []byte, from, to int, asc order.By, limit int) (iter.U64, error) {
+	return newRangeIter(tx, bucket, key, from, to, asc, limit, 4)
+}
+
+// Range64 - same as Range, but for chunks of 64-bit bitmaps (see WalkChunkWithKeys64)
+func Range64(tx kv.Tx, bucket string, key []byte, from, to int, asc order.By, limit int) (iter.U64, error) {
+	return newRangeIter(tx, bucket, key, from, to, asc, limit, 8)
+}
+
+type chunkIter interface {
+	HasNext() bool
+	Next() uint64
+}
+
+type chunkIter32 struct{ roaring.IntIterable }
+
+func (it chunkIter32) Next() uint64 { return uint64(it.IntIterable.Next()) }
+
+var _ iter.SeekableU64 = (*RangeIter)(nil) // compile-time interface check
+
+type RangeIter struct {
+	c         kv.Cursor
+	key       []byte
+	seekKey   []byte
+	suffixLen int // 4 - chunks of 32-bit bitmaps, 8 - 64-bit
+	bm        *roaring.Bitmap
+	bm64      *roaring64.Bitmap
+	chunk     chunkIter // values of current chunk in `asc` order
+
+	from, to int
+	asc      bool
+	limit    int
+
+	hasNext bool
+	nextN   uint64
+	err     error
+}
+
+func newRangeIter(tx kv.Tx, bucket string, key []byte, from, to int, asc order.By, limit int, suffixLen int) (*RangeIter, error) {
+	if asc && (from >= 0 && to >= 0) && from > to {
+		return nil, fmt.Errorf("from=%d epected to be lower than to=%d", from, to)
+	}
+	if !asc && (from >= 0 && to >= 0) && from < to {
+		return nil, fmt.Errorf("from=%d epected to be greater than to=%d", from, to)
+	}
+	c, err := tx.Cursor(bucket)
+	if err != nil {
+		return nil, err
+	}
+	it := &RangeIter{c: c, key: key, suffixLen: suffixLen, from: from, to: to, asc: bool(asc), limit: limit}
+	var start uint64
+	if from >= 0 {
+		start = uint64(from)
+	} else if !asc {
+		start = math.MaxUint64
+	}
+	it.seek(start)
+	return it, nil
+}
+
+// seek - positions cursor to chunk which may contain first value not before `n`
+func (it *RangeIter) seek(n uint64) {
+	it.chunk = nil
+	if it.suffixLen == 4 && n > MaxUint32 {
+		if it.asc {
+			it.hasNext = false
+			return
+		}
+		n = MaxUint32
+	}
+	it.seekKey = append(append(it.seekKey[:0], it.key...), make([]byte, it.suffixLen)...)
+	if it.suffixLen == 4 {
+		binary.BigEndian.PutUint32(it.seekKey[len(it.key):], uint32(n))
+	} else {
+		binary.BigEndian.PutUint64(it.seekKey[len(it.key):], n)
+	}
+	k, v, err := it.c.Seek(it.seekKey)
+	if err != nil {
+		it.err = err
+		return
+	}
+	// chunk key is it's max value (last chunk has max possible value), so found chunk has values >= n
+	// in Desc order values <= n may be only in this chunk or in previous ones
+	if !it.asc && !it.isChunkKey(k) {
+		if k == nil {
+			k, v, err = it.c.Last()
+		} else {
+			k, v, err = it.c.Prev()
+		}
+		if err != nil {
+			it.err = err
+			return
+		}
+	}
+	if !it.loadChunk(k, v) {
+		it.hasNext = false
+		return
+	}
+	it.advance()
+}
+
+func (it *RangeIter) isChunkKey(k []byte) bool {
+	return len(k) == len(it.key)+it.suffixLen && bytes.HasPrefix(k, it.key)
+}
+
+func (it *RangeIter) loadChunk(k, v []byte) bool {
+	if it.err != nil || !it.isChunkKey(k) {
+		return false
+	}
+	if it.suffixLen == 4 {
+		if it.bm == nil {
+			it.bm = roaring.New()
+		}
+		it.bm.Clear()
+		if _, it.err = it.bm.ReadFrom(bytes.NewReader(v)); it.err != nil {
+			return false
+		}
+		if !it.asc {
+			it.chunk = chunkIter32{it.bm.ReverseIterator()}
+			return true
+		}
+		peekable := it.bm.Iterator()
+		if it.from > 0 && it.from <= MaxUint32 {
+			peekable.AdvanceIfNeeded(uint32(it.from))
+		}
+		it.chunk = chunkIter32{peekable}
+		return true
+	}
+
+	if it.bm64 == nil {
+		it.bm64 = roaring64.New()
+	}
+	it.bm64.Clear()
+	if _, it.err = it.bm64.ReadFrom(bytes.NewReader(v)); it.err != nil {
+		return false
+	}
+	if !it.asc {
+		it.chunk = it.bm64.ReverseIterator()
+		return true
+	}
+	peekable := it.bm64.Iterator()
+	if it.from > 0 {
+		peekable.AdvanceIfNeeded(uint64(it.from))
+	}
+	it.chunk = peekable
+	return true
+}
+
+func (it *RangeIter) advance() {
+	for {
+		for it.chunk != nil && it.chunk.HasNext() {
+			n := it.chunk.Next()
+			if it.asc {
+				if it.to >= 0 && n >= uint64(it.to) {
+					it.hasNext = false
+					return
+				}
+				if it.from >= 0 && n < uint64(it.from) {
+					continue
+				}
+			} else {
+				if it.to >= 0 && n <= uint64(it.to) {
+					it.hasNext = false
+					return
+				}
+				if it.from >= 0 && n > uint64(it.from) {
+					continue
+				}
+			}
+			it.hasNext, it.nextN = true, n
+			return
+		}
+
+		var k, v []byte
+		var err error
+		if it.asc {
+			k, v, err = it.c.Next()
+		} else {
+			k, v, err = it.c.Prev()
+		}
+		if err != nil {
+			it.err = err
+			return
+		}
+		if !it.loadChunk(k, v) {
+			it.hasNext = false
+			return
+		}
+	}
+}
+
+// Seek - jumps to chunk of `n` instead of reading all values before it
+func (it *RangeIter) Seek(n uint64) {
+	if it.err != nil || !it.hasNext {
+		return
+	}
+	if (it.asc && it.nextN >= n) || (!it.asc && it.nextN <= n) {
+		return
+	}
+	it.from = int(n)
+	it.seek(n)
+}
+
+func (it *RangeIter) HasNext() bool { return it.err != nil || (it.limit != 0 && it.hasNext) }
+func (it *RangeIter) Next() (uint64, error) {
+	if it.err != nil {
+		return 0, it.err
+	}
+	it.limit--
+	n := it.nextN
+	it.advance()
+	return n, nil
+}
+func (it *RangeIter) Close() {
+	if it.c != nil {
+		it.c.Close()
+	}
+}
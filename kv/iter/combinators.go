@@ -0,0 +1,338 @@
+/*
+   Copyright 2021 Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package iter
+
+import (
+	"golang.org/x/exp/slices"
+)
+
+func closeIt(it any) {
+	if x, ok := it.(Closer); ok {
+		x.Close()
+	}
+}
+
+// LimitIter - returns first `limit` items
+type LimitIter[T any] struct {
+	it    Unary[T]
+	limit int
+}
+
+func Limit[T any](it Unary[T], limit int) *LimitIter[T] { return &LimitIter[T]{it: it, limit: limit} }
+func (m *LimitIter[T]) HasNext() bool                   { return m.limit > 0 && m.it.HasNext() }
+func (m *LimitIter[T]) Next() (T, error) {
+	m.limit--
+	return m.it.Next()
+}
+func (m *LimitIter[T]) Close() { closeIt(m.it) }
+
+type LimitDualIter[K, V any] struct {
+	it    Dual[K, V]
+	limit int
+}
+
+func LimitKV(it KV, limit int) *LimitDualIter[[]byte, []byte] {
+	return LimitDual[[]byte, []byte](it, limit)
+}
+func LimitDual[K, V any](it Dual[K, V], limit int) *LimitDualIter[K, V] {
+	return &LimitDualIter[K, V]{it: it, limit: limit}
+}
+func (m *LimitDualIter[K, V]) HasNext() bool { return m.limit > 0 && m.it.HasNext() }
+func (m *LimitDualIter[K, V]) Next() (K, V, error) {
+	m.limit--
+	return m.it.Next()
+}
+func (m *LimitDualIter[K, V]) Close() { closeIt(m.it) }
+
+// SkipIter - skips first `n` items. Skipping is lazy: happens on first HasNext/Next call
+type SkipIter[T any] struct {
+	it  Unary[T]
+	n   int
+	err error
+}
+
+func Skip[T any](it Unary[T], n int) *SkipIter[T] { return &SkipIter[T]{it: it, n: n} }
+func (m *SkipIter[T]) skip() {
+	for ; m.n > 0 && m.err == nil && m.it.HasNext(); m.n-- {
+		_, m.err = m.it.Next()
+	}
+}
+func (m *SkipIter[T]) HasNext() bool {
+	m.skip()
+	return m.err != nil || m.it.HasNext()
+}
+func (m *SkipIter[T]) Next() (v T, err error) {
+	m.skip()
+	if m.err != nil {
+		return v, m.err
+	}
+	return m.it.Next()
+}
+func (m *SkipIter[T]) Close() { closeIt(m.it) }
+
+type SkipDualIter[K, V any] struct {
+	it  Dual[K, V]
+	n   int
+	err error
+}
+
+func SkipKV(it KV, n int) *SkipDualIter[[]byte, []byte] { return SkipDual[[]byte, []byte](it, n) }
+func SkipDual[K, V any](it Dual[K, V], n int) *SkipDualIter[K, V] {
+	return &SkipDualIter[K, V]{it: it, n: n}
+}
+func (m *SkipDualIter[K, V]) skip() {
+	for ; m.n > 0 && m.err == nil && m.it.HasNext(); m.n-- {
+		_, _, m.err = m.it.Next()
+	}
+}
+func (m *SkipDualIter[K, V]) HasNext() bool {
+	m.skip()
+	return m.err != nil || m.it.HasNext()
+}
+func (m *SkipDualIter[K, V]) Next() (k K, v V, err error) {
+	m.skip()
+	if m.err != nil {
+		return k, v, m.err
+	}
+	return m.it.Next()
+}
+func (m *SkipDualIter[K, V]) Close() { closeIt(m.it) }
+
+// MapIter - analog `map` (in terms of map-filter-reduce pattern). Unlike TransformDual can change type of items
+type MapIter[T, R any] struct {
+	it Unary[T]
+	f  func(T) (R, error)
+}
+
+func Map[T, R any](it Unary[T], f func(T) (R, error)) *MapIter[T, R] {
+	return &MapIter[T, R]{it: it, f: f}
+}
+func (m *MapIter[T, R]) HasNext() bool { return m.it.HasNext() }
+func (m *MapIter[T, R]) Next() (r R, err error) {
+	v, err := m.it.Next()
+	if err != nil {
+		return r, err
+	}
+	return m.f(v)
+}
+func (m *MapIter[T, R]) Close() { closeIt(m.it) }
+
+type MapDualIter[K, V, K2, V2 any] struct {
+	it Dual[K, V]
+	f  func(K, V) (K2, V2, error)
+}
+
+func MapDual[K, V, K2, V2 any](it Dual[K, V], f func(K, V) (K2, V2, error)) *MapDualIter[K, V, K2, V2] {
+	return &MapDualIter[K, V, K2, V2]{it: it, f: f}
+}
+func (m *MapDualIter[K, V, K2, V2]) HasNext() bool { return m.it.HasNext() }
+func (m *MapDualIter[K, V, K2, V2]) Next() (k K2, v V2, err error) {
+	k1, v1, err := m.it.Next()
+	if err != nil {
+		return k, v, err
+	}
+	return m.f(k1, v1)
+}
+func (m *MapDualIter[K, V, K2, V2]) Close() { closeIt(m.it) }
+
+// ChunkIter - groups items to batches of `n` (last batch may be smaller).
+// Items must stay valid after 2 .Next() calls - see ChunkKV for []byte
+type ChunkIter[T any] struct {
+	it Unary[T]
+	n  int
+}
+
+func Chunk[T any](it Unary[T], n int) *ChunkIter[T] { return &ChunkIter[T]{it: it, n: n} }
+func (m *ChunkIter[T]) HasNext() bool               { return m.it.HasNext() }
+func (m *ChunkIter[T]) Next() ([]T, error) {
+	chunk := make([]T, 0, m.n)
+	for len(chunk) < m.n && m.it.HasNext() {
+		v, err := m.it.Next()
+		if err != nil {
+			return chunk, err
+		}
+		chunk = append(chunk, v)
+	}
+	return chunk, nil
+}
+func (m *ChunkIter[T]) Close() { closeIt(m.it) }
+
+type ChunkDualIter[K, V any] struct {
+	it    Dual[K, V]
+	n     int
+	clone func(K, V) (K, V)
+}
+
+// ChunkKV - same as ChunkDual, but copies keys and values: they must outlive underlying iterator's buffers
+func ChunkKV(it KV, n int) *ChunkDualIter[[]byte, []byte] {
+	m := ChunkDual[[]byte, []byte](it, n)
+	m.clone = func(k, v []byte) ([]byte, []byte) { return slices.Clone(k), slices.Clone(v) }
+	return m
+}
+func ChunkDual[K, V any](it Dual[K, V], n int) *ChunkDualIter[K, V] {
+	return &ChunkDualIter[K, V]{it: it, n: n}
+}
+func (m *ChunkDualIter[K, V]) HasNext() bool { return m.it.HasNext() }
+func (m *ChunkDualIter[K, V]) Next() ([]K, []V, error) {
+	keys, values := make([]K, 0, m.n), make([]V, 0, m.n)
+	for len(keys) < m.n && m.it.HasNext() {
+		k, v, err := m.it.Next()
+		if err != nil {
+			return keys, values, err
+		}
+		if m.clone != nil {
+			k, v = m.clone(k, v)
+		}
+		keys, values = append(keys, k), append(values, v)
+	}
+	return keys, values, nil
+}
+func (m *ChunkDualIter[K, V]) Close() { closeIt(m.it) }
+
+// TakeWhileIter - returns items until first item which doesn't match `pred`
+type TakeWhileIter[T any] struct {
+	it      Unary[T]
+	pred    func(T) bool
+	hasNext bool
+	err     error
+	nextV   T
+}
+
+func TakeWhile[T any](it Unary[T], pred func(T) bool) *TakeWhileIter[T] {
+	m := &TakeWhileIter[T]{it: it, pred: pred}
+	m.advance()
+	return m
+}
+func (m *TakeWhileIter[T]) advance() {
+	m.hasNext = false
+	if m.err != nil || !m.it.HasNext() {
+		return
+	}
+	v, err := m.it.Next()
+	if err != nil {
+		m.err = err
+		return
+	}
+	if m.pred(v) {
+		m.hasNext, m.nextV = true, v
+	}
+}
+func (m *TakeWhileIter[T]) HasNext() bool { return m.err != nil || m.hasNext }
+func (m *TakeWhileIter[T]) Next() (v T, err error) {
+	v, err = m.nextV, m.err
+	m.advance()
+	return v, err
+}
+func (m *TakeWhileIter[T]) Close() { closeIt(m.it) }
+
+type TakeWhileDualIter[K, V any] struct {
+	it      Dual[K, V]
+	pred    func(K, V) bool
+	hasNext bool
+	err     error
+	nextK   K
+	nextV   V
+}
+
+func TakeWhileKV(it KV, pred func(k, v []byte) bool) *TakeWhileDualIter[[]byte, []byte] {
+	return TakeWhileDual[[]byte, []byte](it, pred)
+}
+func TakeWhileDual[K, V any](it Dual[K, V], pred func(K, V) bool) *TakeWhileDualIter[K, V] {
+	m := &TakeWhileDualIter[K, V]{it: it, pred: pred}
+	m.advance()
+	return m
+}
+func (m *TakeWhileDualIter[K, V]) advance() {
+	m.hasNext = false
+	if m.err != nil || !m.it.HasNext() {
+		return
+	}
+	k, v, err := m.it.Next()
+	if err != nil {
+		m.err = err
+		return
+	}
+	if m.pred(k, v) {
+		m.hasNext, m.nextK, m.nextV = true, k, v
+	}
+}
+func (m *TakeWhileDualIter[K, V]) HasNext() bool { return m.err != nil || m.hasNext }
+func (m *TakeWhileDualIter[K, V]) Next() (k K, v V, err error) {
+	k, v, err = m.nextK, m.nextV, m.err
+	m.advance()
+	return k, v, err
+}
+func (m *TakeWhileDualIter[K, V]) Close() { closeIt(m.it) }
+
+// PeekableIter - allows to look at next item without consuming it
+type PeekableIter[T any] struct {
+	it     Unary[T]
+	peeked bool
+	v      T
+	err    error
+}
+
+func Peekable[T any](it Unary[T]) *PeekableIter[T] { return &PeekableIter[T]{it: it} }
+func (m *PeekableIter[T]) HasNext() bool           { return m.peeked || m.it.HasNext() }
+
+// Peek - returns same item as next .Next() call. Call it only if HasNext() is true
+func (m *PeekableIter[T]) Peek() (T, error) {
+	if !m.peeked {
+		m.v, m.err = m.it.Next()
+		m.peeked = true
+	}
+	return m.v, m.err
+}
+func (m *PeekableIter[T]) Next() (T, error) {
+	if m.peeked {
+		m.peeked = false
+		return m.v, m.err
+	}
+	return m.it.Next()
+}
+func (m *PeekableIter[T]) Close() { closeIt(m.it) }
+
+type PeekableDualIter[K, V any] struct {
+	it     Dual[K, V]
+	peeked bool
+	k      K
+	v      V
+	err    error
+}
+
+func PeekableKV(it KV) *PeekableDualIter[[]byte, []byte] { return PeekableDual[[]byte, []byte](it) }
+func PeekableDual[K, V any](it Dual[K, V]) *PeekableDualIter[K, V] {
+	return &PeekableDualIter[K, V]{it: it}
+}
+func (m *PeekableDualIter[K, V]) HasNext() bool { return m.peeked || m.it.HasNext() }
+
+// Peek - returns same item as next .Next() call. Call it only if HasNext() is true
+func (m *PeekableDualIter[K, V]) Peek() (K, V, error) {
+	if !m.peeked {
+		m.k, m.v, m.err = m.it.Next()
+		m.peeked = true
+	}
+	return m.k, m.v, m.err
+}
+func (m *PeekableDualIter[K, V]) Next() (K, V, error) {
+	if m.peeked {
+		m.peeked = false
+		return m.k, m.v, m.err
+	}
+	return m.it.Next()
+}
+func (m *PeekableDualIter[K, V]) Close() { closeIt(m.it) }
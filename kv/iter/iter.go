@@ -166,6 +166,17 @@ func (m *UnionKVIter) Next() ([]byte, []byte, error) {
 	return k, v, err
 }
 
+// Seek - implements Seekable: seeks underlying streams (or skips their items if they are not Seekable)
+func (m *UnionKVIter) Seek(k []byte) {
+	before := func(a, b []byte) bool { return bytes.Compare(a, b) < 0 }
+	if m.err == nil && m.xHasNext && before(m.xNextK, k) {
+		m.xNextK, m.xNextV, m.xHasNext, m.err = seekDual[[]byte, []byte](m.x, k, before)
+	}
+	if m.err == nil && m.yHasNext && before(m.yNextK, k) {
+		m.yNextK, m.yNextV, m.yHasNext, m.err = seekDual[[]byte, []byte](m.y, k, before)
+	}
+}
+
 // func (m *UnionKVIter) ToArray() (keys, values [][]byte, err error) { return ToKVArray(m) }
 func (m *UnionKVIter) Close() {
 	if x, ok := m.x.(Closer); ok {
@@ -230,8 +241,19 @@ func (m *UnionUnary[T]) advanceY() {
 	}
 }
 
-func (m *UnionUnary[T]) less() bool {
-	return (m.asc && m.xNextK < m.yNextK) || (!m.asc && m.xNextK > m.yNextK)
+func (m *UnionUnary[T]) less() bool { return m.before(m.xNextK, m.yNextK) }
+func (m *UnionUnary[T]) before(a, b T) bool {
+	return (m.asc && a < b) || (!m.asc && a > b)
+}
+
+// Seek - implements Seekable: seeks underlying streams (or skips their items if they are not Seekable)
+func (m *UnionUnary[T]) Seek(v T) {
+	if m.err == nil && m.xHas && m.before(m.xNextK, v) {
+		m.xNextK, m.xHas, m.err = seekUnary(m.x, v, m.before)
+	}
+	if m.err == nil && m.yHas && m.before(m.yNextK, v) {
+		m.yNextK, m.yHas, m.err = seekUnary(m.y, v, m.before)
+	}
 }
 
 func (m *UnionUnary[T]) Next() (res T, err error) {
@@ -295,23 +317,50 @@ func (m *IntersectIter[T]) HasNext() bool {
 func (m *IntersectIter[T]) advance() {
 	m.advanceX()
 	m.advanceY()
+	m.align()
+}
+
+// align - stream which is behind jumps to head of another stream, until heads are equal
+func (m *IntersectIter[T]) align() {
 	for m.xHasNext && m.yHasNext {
 		if m.err != nil {
 			break
 		}
 		if m.xNextK < m.yNextK {
-			m.advanceX()
+			m.seekX(m.yNextK)
 			continue
 		} else if m.xNextK == m.yNextK {
 			return
 		} else {
-			m.advanceY()
+			m.seekY(m.xNextK)
 			continue
 		}
 	}
 	m.xHasNext = false
 }
 
+func (m *IntersectIter[T]) less(a, b T) bool { return a < b }
+func (m *IntersectIter[T]) seekX(v T) {
+	if m.err == nil {
+		m.xNextK, m.xHasNext, m.err = seekUnary(m.x, v, m.less)
+	}
+}
+func (m *IntersectIter[T]) seekY(v T) {
+	if m.err == nil {
+		m.yNextK, m.yHasNext, m.err = seekUnary(m.y, v, m.less)
+	}
+}
+
+// Seek - implements Seekable
+func (m *IntersectIter[T]) Seek(v T) {
+	if m.err != nil || !m.xHasNext || m.xNextK >= v {
+		return
+	}
+	m.seekX(v)
+	m.seekY(v)
+	m.align()
+}
+
 func (m *IntersectIter[T]) advanceX() {
 	if m.err != nil {
 		return
@@ -569,3 +618,36 @@ func (it *PaginatedDual[K, V]) Next() (k K, v V, err error) {
 	it.i++
 	return k, v, nil
 }
+
+// seekUnary - moves `it` to first item which is not `before` `v` and returns it. Uses Seek if `it` is Seekable.
+// ok=false if stream has no such item
+func seekUnary[T any](it Unary[T], v T, before func(a, b T) bool) (head T, ok bool, err error) {
+	if s, isSeekable := it.(Seekable[T]); isSeekable {
+		s.Seek(v)
+	}
+	for it.HasNext() {
+		if head, err = it.Next(); err != nil {
+			return head, true, err
+		}
+		if !before(head, v) {
+			return head, true, nil
+		}
+	}
+	return head, false, nil
+}
+
+// seekDual - same as seekUnary, but seeks by key
+func seekDual[K, V any](it Dual[K, V], k K, before func(a, b K) bool) (headK K, headV V, ok bool, err error) {
+	if s, isSeekable := it.(Seekable[K]); isSeekable {
+		s.Seek(k)
+	}
+	for it.HasNext() {
+		if headK, headV, err = it.Next(); err != nil {
+			return headK, headV, true, err
+		}
+		if !before(headK, k) {
+			return headK, headV, true, nil
+		}
+	}
+	return headK, headV, false, nil
+}
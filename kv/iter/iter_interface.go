@@ -61,6 +61,15 @@ type Unary[V any] interface {
 	HasNext() bool
 }
 
+// Seekable - stream which can jump forward: after Seek(v) stream returns items starting from first item which is not
+// before `v` in stream's order (>= v for asc, <= v for desc). Dual streams seek by key.
+// Allows skipping big parts of stream without decoding them. Only forward seeks are supported.
+// Seek has no error in signature: error is returned by next .Next() call.
+// Example: eliasfano32.EliasFanoIter, state.FrozenInvertedIdxIter
+type Seekable[V any] interface {
+	Seek(v V)
+}
+
 // KV - return 2 items of type []byte - usually called Key and Value (or `k` and `v`). Example:
 //
 //	for s.HasNext() {
@@ -76,6 +85,17 @@ type (
 	KV  Dual[[]byte, []byte]
 )
 
+type (
+	SeekableU64 interface {
+		U64
+		Seekable[uint64]
+	}
+	SeekableKV interface {
+		KV
+		Seekable[[]byte]
+	}
+)
+
 func ToU64Arr(s U64) ([]uint64, error)           { return ToArr[uint64](s) }
 func ToKVArray(s KV) ([][]byte, [][]byte, error) { return ToDualArray[[]byte, []byte](s) }
 
@@ -414,3 +414,221 @@ func TestFiler(t *testing.T) {
 		require.Nil(t, res)
 	})
 }
+
+func pairs(keys, values [][]byte) iter.KV {
+	return iter.PaginateKV(func(string) ([][]byte, [][]byte, string, error) { return keys, values, "", nil })
+}
+
+type closeTracker struct {
+	iter.KV
+	closed bool
+}
+
+func (c *closeTracker) Close() { c.closed = true }
+
+func TestMergeN(t *testing.T) {
+	t.Run("unary", func(t *testing.T) {
+		s := iter.MergeN[uint64]([]iter.Unary[uint64]{iter.Array([]uint64{1, 4, 7}), nil, iter.Array([]uint64{2, 4, 8}), iter.EmptyU64, iter.Array([]uint64{3, 4})}, order.Asc, iter.KeepAll, -1)
+		require.Equal(t, []uint64{1, 2, 3, 4, 4, 4, 7, 8}, iter.ToArrU64Must(s))
+		s = iter.MergeN[uint64]([]iter.Unary[uint64]{iter.Array([]uint64{1, 4, 7}), iter.Array([]uint64{2, 4, 8})}, order.Asc, iter.KeepFirst, 4)
+		require.Equal(t, []uint64{1, 2, 4, 7}, iter.ToArrU64Must(s))
+		s = iter.MergeN[uint64]([]iter.Unary[uint64]{iter.ReverseArray([]uint64{1, 4, 7}), iter.ReverseArray([]uint64{2, 4, 8})}, order.Desc, iter.KeepLast, -1)
+		require.Equal(t, []uint64{8, 7, 4, 2, 1}, iter.ToArrU64Must(s))
+	})
+	t.Run("kv", func(t *testing.T) {
+		older := pairs([][]byte{{1}, {3}}, [][]byte{{10}, {30}})
+		newer := pairs([][]byte{{2}, {3}}, [][]byte{{20}, {31}})
+		keys, values := iter.ToArrKVMust(iter.MergeNKV([]iter.KV{older, newer}, order.Asc, iter.KeepLast, -1))
+		require.Equal(t, [][]byte{{1}, {2}, {3}}, keys)
+		require.Equal(t, [][]byte{{10}, {20}, {31}}, values)
+
+		older = pairs([][]byte{{1}, {3}}, [][]byte{{10}, {30}})
+		newer = pairs([][]byte{{2}, {3}}, [][]byte{{20}, {31}})
+		keys, values = iter.ToArrKVMust(iter.MergeNKV([]iter.KV{older, newer}, order.Asc, iter.KeepFirst, -1))
+		require.Equal(t, [][]byte{{1}, {2}, {3}}, keys)
+		require.Equal(t, [][]byte{{10}, {20}, {30}}, values)
+
+		older = pairs([][]byte{{1}, {3}}, [][]byte{{10}, {30}})
+		newer = pairs([][]byte{{2}, {3}}, [][]byte{{20}, {31}})
+		_, values = iter.ToArrKVMust(iter.MergeNKV([]iter.KV{older, newer}, order.Asc, iter.KeepAll, -1))
+		require.Equal(t, [][]byte{{10}, {20}, {30}, {31}}, values)
+	})
+	t.Run("error and close", func(t *testing.T) {
+		tracker := &closeTracker{KV: iter.PairsWithError(2)}
+		m := iter.MergeNKV([]iter.KV{tracker, iter.EmptyKV}, order.Asc, iter.KeepAll, -1)
+		_, _, err := iter.ToKVArray(m)
+		require.Error(t, err)
+		m.Close()
+		require.True(t, tracker.closed)
+	})
+}
+
+func TestCombinators(t *testing.T) {
+	arr := func() iter.Unary[uint64] { return iter.Array([]uint64{1, 2, 3, 4, 5}) }
+	require.Equal(t, []uint64{1, 2}, iter.ToArrU64Must(iter.Limit(arr(), 2)))
+	require.Equal(t, []uint64{4, 5}, iter.ToArrU64Must(iter.Skip(arr(), 3)))
+	require.Empty(t, iter.ToArrU64Must(iter.Skip(arr(), 10)))
+	require.Equal(t, []uint64{3}, iter.ToArrU64Must(iter.Limit[uint64](iter.Skip(arr(), 2), 1)))
+	require.Equal(t, []uint64{1, 2}, iter.ToArrU64Must(iter.TakeWhile(arr(), func(v uint64) bool { return v < 3 })))
+
+	strs, err := iter.ToArr[string](iter.Map[uint64, string](arr(), func(v uint64) (string, error) { return fmt.Sprint(v * 10), nil }))
+	require.NoError(t, err)
+	require.Equal(t, []string{"10", "20", "30", "40", "50"}, strs)
+
+	chunks, err := iter.ToArr[[]uint64](iter.Chunk(arr(), 2))
+	require.NoError(t, err)
+	require.Equal(t, [][]uint64{{1, 2}, {3, 4}, {5}}, chunks)
+
+	p := iter.Peekable(arr())
+	require.True(t, p.HasNext())
+	v, _ := p.Peek()
+	require.Equal(t, uint64(1), v)
+	v, _ = p.Peek()
+	require.Equal(t, uint64(1), v)
+	require.Equal(t, []uint64{1, 2, 3, 4, 5}, iter.ToArrU64Must(p))
+
+	t.Run("kv", func(t *testing.T) {
+		kv := func() iter.KV {
+			return pairs([][]byte{{1}, {2}, {3}}, [][]byte{{10}, {20}, {30}})
+		}
+		keys, _ := iter.ToArrKVMust(iter.SkipKV(iter.LimitKV(kv(), 2), 1))
+		require.Equal(t, [][]byte{{2}}, keys)
+		keys, _ = iter.ToArrKVMust(iter.TakeWhileKV(kv(), func(k, v []byte) bool { return k[0] != 2 }))
+		require.Equal(t, [][]byte{{1}}, keys)
+		lens, _, err := iter.ToDualArray[int, int](iter.MapDual[[]byte, []byte, int, int](kv(), func(k, v []byte) (int, int, error) { return int(k[0]), int(v[0]), nil }))
+		require.NoError(t, err)
+		require.Equal(t, []int{1, 2, 3}, lens)
+
+		chunks, _, err := iter.ToDualArray[[][]byte, [][]byte](iter.ChunkKV(kv(), 2))
+		require.NoError(t, err)
+		require.Equal(t, [][][]byte{{{1}, {2}}, {{3}}}, chunks)
+
+		pk := iter.PeekableKV(kv())
+		require.True(t, pk.HasNext())
+		k, v, err := pk.Peek()
+		require.NoError(t, err)
+		require.Equal(t, []byte{1}, k)
+		require.Equal(t, []byte{10}, v)
+		cnt, err := iter.CountKV(pk)
+		require.NoError(t, err)
+		require.Equal(t, 3, cnt)
+
+		tracker := &closeTracker{KV: kv()}
+		iter.PeekableDual[[][]byte, [][]byte](iter.ChunkKV(iter.TakeWhileKV(iter.SkipKV(iter.LimitKV(tracker, 1), 0), func(k, v []byte) bool { return true }), 1)).Close()
+		require.True(t, tracker.closed)
+	})
+}
+
+// seekableArr - asc array which counts items returned by Next
+type seekableArr struct {
+	arr   []uint64
+	i     int
+	nexts int
+}
+
+func (s *seekableArr) HasNext() bool { return s.i < len(s.arr) }
+func (s *seekableArr) Next() (uint64, error) {
+	s.nexts++
+	s.i++
+	return s.arr[s.i-1], nil
+}
+func (s *seekableArr) Seek(v uint64) {
+	for s.i < len(s.arr) && s.arr[s.i] < v {
+		s.i++
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	arr := func(v ...uint64) iter.Unary[uint64] { return iter.Array(v) }
+	t.Run("union", func(t *testing.T) {
+		s := iter.UnionN[uint64]([]iter.Unary[uint64]{arr(1, 3, 5), arr(2, 3), nil, arr(5, 6)}, order.Asc, -1)
+		require.Equal(t, []uint64{1, 2, 3, 5, 6}, iter.ToArrU64Must(s))
+		s = iter.UnionN[uint64]([]iter.Unary[uint64]{iter.ReverseArray([]uint64{1, 3, 5}), iter.ReverseArray([]uint64{2, 3})}, order.Desc, 3)
+		require.Equal(t, []uint64{5, 3, 2}, iter.ToArrU64Must(s))
+	})
+	t.Run("intersect", func(t *testing.T) {
+		s := iter.IntersectN[uint64]([]iter.Unary[uint64]{arr(1, 3, 4, 5, 7, 9), arr(3, 5, 7, 9), arr(2, 3, 7, 9)}, order.Asc, -1)
+		require.Equal(t, []uint64{3, 7, 9}, iter.ToArrU64Must(s))
+		s = iter.IntersectN[uint64]([]iter.Unary[uint64]{arr(1, 3, 4, 5, 7, 9), arr(3, 5, 7, 9), arr(2, 3, 7, 9)}, order.Asc, 2)
+		require.Equal(t, []uint64{3, 7}, iter.ToArrU64Must(s))
+		s = iter.IntersectN[uint64]([]iter.Unary[uint64]{iter.ReverseArray([]uint64{1, 3, 5, 7}), iter.ReverseArray([]uint64{3, 4, 7})}, order.Desc, -1)
+		require.Equal(t, []uint64{7, 3}, iter.ToArrU64Must(s))
+		require.Empty(t, iter.ToArrU64Must(iter.IntersectN[uint64]([]iter.Unary[uint64]{arr(1, 2), nil}, order.Asc, -1)))
+		require.Empty(t, iter.ToArrU64Must(iter.IntersectN[uint64](nil, order.Asc, -1)))
+		require.Equal(t, []uint64{1, 2}, iter.ToArrU64Must(iter.IntersectN[uint64]([]iter.Unary[uint64]{arr(1, 2)}, order.Asc, -1)))
+	})
+	t.Run("except", func(t *testing.T) {
+		require.Equal(t, []uint64{1, 4, 6}, iter.ToArrU64Must(iter.Except[uint64](arr(1, 2, 3, 4, 5, 6), arr(0, 2, 3, 5, 7), order.Asc, -1)))
+		require.Equal(t, []uint64{1, 4}, iter.ToArrU64Must(iter.Except[uint64](arr(1, 2, 3, 4, 5, 6), arr(2, 3, 5), order.Asc, 2)))
+		require.Equal(t, []uint64{6, 4, 1}, iter.ToArrU64Must(iter.Except[uint64](iter.ReverseArray([]uint64{1, 2, 3, 4, 5, 6}), iter.ReverseArray([]uint64{2, 3, 5}), order.Desc, -1)))
+		require.Equal(t, []uint64{1, 2}, iter.ToArrU64Must(iter.Except[uint64](arr(1, 2), nil, order.Asc, -1)))
+		require.Empty(t, iter.ToArrU64Must(iter.Except[uint64](nil, arr(1, 2), order.Asc, -1)))
+	})
+	t.Run("seek", func(t *testing.T) {
+		big := &seekableArr{}
+		for i := uint64(0); i < 1000; i++ {
+			big.arr = append(big.arr, i)
+		}
+		s := iter.IntersectN[uint64]([]iter.Unary[uint64]{arr(10, 500, 999), big}, order.Asc, -1)
+		require.Equal(t, []uint64{10, 500, 999}, iter.ToArrU64Must(s))
+		require.Equal(t, 4, big.nexts) // first head + 3 matches
+
+		big.i, big.nexts = 0, 0
+		require.Equal(t, []uint64{1000}, iter.ToArrU64Must(iter.Except[uint64](arr(10, 500, 999, 1000), big, order.Asc, -1)))
+		require.Equal(t, 3, big.nexts)
+	})
+	t.Run("error", func(t *testing.T) {
+		_, err := iter.ToArr[uint64](iter.IntersectN[uint64]([]iter.Unary[uint64]{arr(1, 2), iter.Unary[uint64](failing{})}, order.Asc, -1))
+		require.Error(t, err)
+		_, err = iter.ToArr[uint64](iter.Except[uint64](arr(1, 2), failing{}, order.Asc, -1))
+		require.Error(t, err)
+	})
+}
+
+type failing struct{}
+
+func (failing) HasNext() bool         { return true }
+func (failing) Next() (uint64, error) { return 0, fmt.Errorf("expected error") }
+
+func TestSeek(t *testing.T) {
+	newBig := func() *seekableArr {
+		big := &seekableArr{}
+		for i := uint64(0); i < 1000; i++ {
+			big.arr = append(big.arr, i)
+		}
+		return big
+	}
+	t.Run("intersect uses seek", func(t *testing.T) {
+		big := newBig()
+		require.Equal(t, []uint64{10, 500}, iter.ToArrU64Must(iter.Intersect[uint64](iter.Array([]uint64{10, 500, 1001}), big, -1)))
+		require.Equal(t, 5, big.nexts) // 0, 10, 11, 500, 501
+	})
+	t.Run("intersect", func(t *testing.T) {
+		s := iter.Intersect[uint64](newBig(), iter.Array([]uint64{1, 3, 5, 700, 800}), -1)
+		s.(iter.SeekableU64).Seek(4)
+		require.Equal(t, []uint64{5, 700, 800}, iter.ToArrU64Must(s))
+	})
+	t.Run("union", func(t *testing.T) {
+		s := iter.Union[uint64](iter.Array([]uint64{1, 3, 5, 7}), iter.Array([]uint64{2, 4, 6}), order.Asc, -1)
+		s.(iter.SeekableU64).Seek(4)
+		require.Equal(t, []uint64{4, 5, 6, 7}, iter.ToArrU64Must(s))
+
+		s = iter.Union[uint64](iter.ReverseArray([]uint64{1, 3, 5, 7}), iter.ReverseArray([]uint64{2, 4, 6}), order.Desc, -1)
+		s.(iter.SeekableU64).Seek(4)
+		require.Equal(t, []uint64{4, 3, 2, 1}, iter.ToArrU64Must(s))
+
+		big := newBig()
+		s = iter.Union[uint64](big, iter.Array([]uint64{1, 2000}), order.Asc, -1)
+		s.(iter.SeekableU64).Seek(998)
+		require.Equal(t, []uint64{998, 999, 2000}, iter.ToArrU64Must(s))
+		require.Equal(t, 3, big.nexts)
+	})
+	t.Run("union kv", func(t *testing.T) {
+		x := pairs([][]byte{{1}, {3}, {5}}, [][]byte{{1}, {3}, {5}})
+		y := pairs([][]byte{{2}, {4}}, [][]byte{{2}, {4}})
+		s := iter.UnionKV(x, y, -1)
+		s.(iter.SeekableKV).Seek([]byte{3})
+		keys, _ := iter.ToArrKVMust(s)
+		require.Equal(t, [][]byte{{3}, {4}, {5}}, keys)
+	})
+}
@@ -0,0 +1,167 @@
+/*
+   Copyright 2021 Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package iter
+
+import (
+	"bytes"
+	"container/heap"
+
+	"github.com/ledgerwatch/erigon-lib/kv/order"
+	"golang.org/x/exp/constraints"
+)
+
+// Dedup - what MergeN does with equal keys
+type Dedup int
+
+const (
+	KeepAll   Dedup = iota // return all items. Items with equal keys are returned in order of iterators
+	KeepFirst              // return 1 item per key: from iterator with lowest index (like UnionKV: `x` has priority)
+	KeepLast               // return 1 item per key: from iterator with highest index (for example: newer file overrides older)
+)
+
+// MergeNIter - k-way merge of sorted streams (by binary heap). Streams must be sorted by same `cmp`.
+type MergeNIter[K, V any] struct {
+	its   []Dual[K, V]
+	h     mergeHeap[K, V]
+	dedup Dedup
+	limit int
+	err   error
+}
+
+func MergeNDual[K, V any](its []Dual[K, V], cmp func(a, b K) int, dedup Dedup, limit int) *MergeNIter[K, V] {
+	m := &MergeNIter[K, V]{its: its, h: mergeHeap[K, V]{cmp: cmp}, dedup: dedup, limit: limit}
+	for i := range its {
+		m.advance(i)
+	}
+	return m
+}
+
+// MergeNKV - merge of streams sorted by bytes.Compare (asc) or reverse (desc)
+func MergeNKV(its []KV, asc order.By, dedup Dedup, limit int) *MergeNIter[[]byte, []byte] {
+	duals := make([]Dual[[]byte, []byte], len(its))
+	for i := range its {
+		duals[i] = its[i]
+	}
+	cmp := bytes.Compare
+	if !asc {
+		cmp = func(a, b []byte) int { return bytes.Compare(b, a) }
+	}
+	return MergeNDual[[]byte, []byte](duals, cmp, dedup, limit)
+}
+
+func (m *MergeNIter[K, V]) advance(i int) {
+	if m.err != nil || m.its[i] == nil || !m.its[i].HasNext() {
+		return
+	}
+	k, v, err := m.its[i].Next()
+	if err != nil {
+		m.err = err
+		return
+	}
+	heap.Push(&m.h, mergeHead[K, V]{k: k, v: v, i: i})
+}
+
+func (m *MergeNIter[K, V]) HasNext() bool { return m.err != nil || (m.limit != 0 && m.h.Len() > 0) }
+func (m *MergeNIter[K, V]) Next() (k K, v V, err error) {
+	if m.err != nil {
+		return k, v, m.err
+	}
+	m.limit--
+	top := heap.Pop(&m.h).(mergeHead[K, V])
+	m.advance(top.i)
+	if m.dedup != KeepAll {
+		for m.h.Len() > 0 && m.h.cmp(m.h.items[0].k, top.k) == 0 {
+			dup := heap.Pop(&m.h).(mergeHead[K, V])
+			m.advance(dup.i)
+			if m.dedup == KeepLast {
+				top = dup
+			}
+		}
+	}
+	return top.k, top.v, nil
+}
+func (m *MergeNIter[K, V]) Close() {
+	for _, it := range m.its {
+		closeIt(it)
+	}
+}
+
+// MergeN - k-way merge of sorted Unary streams. For Unary KeepFirst and KeepLast are same: remove duplicates.
+func MergeN[T constraints.Ordered](its []Unary[T], asc order.By, dedup Dedup, limit int) *MergeNUnaryIter[T] {
+	duals := make([]Dual[T, struct{}], len(its))
+	for i := range its {
+		if its[i] != nil {
+			duals[i] = unaryAsDual[T]{its[i]}
+		}
+	}
+	cmp := func(a, b T) int {
+		if a == b {
+			return 0
+		}
+		if (a < b) == bool(asc) {
+			return -1
+		}
+		return 1
+	}
+	return &MergeNUnaryIter[T]{m: MergeNDual[T, struct{}](duals, cmp, dedup, limit)}
+}
+
+type MergeNUnaryIter[T any] struct {
+	m *MergeNIter[T, struct{}]
+}
+
+func (m *MergeNUnaryIter[T]) HasNext() bool { return m.m.HasNext() }
+func (m *MergeNUnaryIter[T]) Next() (T, error) {
+	k, _, err := m.m.Next()
+	return k, err
+}
+func (m *MergeNUnaryIter[T]) Close() { m.m.Close() }
+
+type unaryAsDual[T any] struct{ it Unary[T] }
+
+func (u unaryAsDual[T]) HasNext() bool { return u.it.HasNext() }
+func (u unaryAsDual[T]) Next() (T, struct{}, error) {
+	v, err := u.it.Next()
+	return v, struct{}{}, err
+}
+func (u unaryAsDual[T]) Close() { closeIt(u.it) }
+
+type mergeHead[K, V any] struct {
+	k K
+	v V
+	i int // index of iterator
+}
+
+type mergeHeap[K, V any] struct {
+	items []mergeHead[K, V]
+	cmp   func(a, b K) int
+}
+
+func (h *mergeHeap[K, V]) Len() int { return len(h.items) }
+func (h *mergeHeap[K, V]) Less(i, j int) bool {
+	if c := h.cmp(h.items[i].k, h.items[j].k); c != 0 {
+		return c < 0
+	}
+	return h.items[i].i < h.items[j].i
+}
+func (h *mergeHeap[K, V]) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *mergeHeap[K, V]) Push(x any)    { h.items = append(h.items, x.(mergeHead[K, V])) }
+func (h *mergeHeap[K, V]) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
@@ -0,0 +1,192 @@
+/*
+   Copyright 2021 Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package iter
+
+import (
+	"github.com/ledgerwatch/erigon-lib/kv/order"
+	"golang.org/x/exp/constraints"
+)
+
+// Set operations over sorted streams without duplicates (for example: results of InvertedIndex.IdxRange).
+// All streams must be sorted in `asc` order. If stream implements Seekable - it's used to skip non-matching items.
+
+// UnionN - k-way union (OR). Same as MergeN with KeepFirst
+func UnionN[T constraints.Ordered](its []Unary[T], asc order.By, limit int) Unary[T] {
+	return MergeN[T](its, asc, KeepFirst, limit)
+}
+
+// IntersectNIter - k-way intersection (AND). Leapfrog: streams which are behind jump to the biggest head
+type IntersectNIter[T constraints.Ordered] struct {
+	its     []Unary[T]
+	heads   []T
+	asc     bool
+	limit   int
+	hasNext bool
+	nextV   T
+	err     error
+}
+
+// IntersectN - nil stream is empty stream, so result is empty
+func IntersectN[T constraints.Ordered](its []Unary[T], asc order.By, limit int) Unary[T] {
+	if len(its) == 0 {
+		return &EmptyUnary[T]{}
+	}
+	for i := range its {
+		if its[i] == nil || !its[i].HasNext() {
+			for _, it := range its {
+				closeIt(it)
+			}
+			return &EmptyUnary[T]{}
+		}
+	}
+	m := &IntersectNIter[T]{its: its, heads: make([]T, len(its)), asc: bool(asc), limit: limit}
+	m.advance(true)
+	return m
+}
+
+func (m *IntersectNIter[T]) before(a, b T) bool { return (m.asc && a < b) || (!m.asc && a > b) }
+
+func (m *IntersectNIter[T]) next(i int) bool {
+	if m.err != nil || !m.its[i].HasNext() {
+		return false
+	}
+	m.heads[i], m.err = m.its[i].Next()
+	return m.err == nil
+}
+
+// seek - moves i-th stream to first item which is not before `target`
+func (m *IntersectNIter[T]) seek(i int, target T) bool {
+	var ok bool
+	m.heads[i], ok, m.err = seekUnary(m.its[i], target, m.before)
+	return ok && m.err == nil
+}
+
+// advance - on first call reads head of each stream, later reads only first stream: others are behind it and jump
+func (m *IntersectNIter[T]) advance(first bool) {
+	m.hasNext = false
+	for i := range m.its {
+		if (i == 0 || first) && !m.next(i) {
+			return
+		}
+	}
+	target := m.heads[0]
+	for i := 1; i < len(m.its) && !first; i++ {
+		if !m.seek(i, target) {
+			return
+		}
+	}
+	for {
+		changed := false
+		for i := range m.heads {
+			if m.before(m.heads[i], target) && !m.seek(i, target) {
+				return
+			}
+			if m.before(target, m.heads[i]) {
+				target, changed = m.heads[i], true
+			}
+		}
+		if !changed {
+			m.hasNext, m.nextV = true, target
+			return
+		}
+	}
+}
+
+func (m *IntersectNIter[T]) HasNext() bool { return m.err != nil || (m.limit != 0 && m.hasNext) }
+func (m *IntersectNIter[T]) Next() (v T, err error) {
+	if m.err != nil {
+		return v, m.err
+	}
+	m.limit--
+	v = m.nextV
+	m.advance(false)
+	return v, nil
+}
+func (m *IntersectNIter[T]) Close() {
+	for _, it := range m.its {
+		closeIt(it)
+	}
+}
+
+// ExceptIter - set difference: items of `x` which are not in `y`
+type ExceptIter[T constraints.Ordered] struct {
+	x, y       Unary[T]
+	asc        bool
+	yHas, yEnd bool
+	yHead      T
+	limit      int
+	hasNext    bool
+	nextV      T
+	err        error
+}
+
+func Except[T constraints.Ordered](x, y Unary[T], asc order.By, limit int) Unary[T] {
+	if x == nil {
+		closeIt(y)
+		return &EmptyUnary[T]{}
+	}
+	if y == nil {
+		y = &EmptyUnary[T]{}
+	}
+	m := &ExceptIter[T]{x: x, y: y, asc: bool(asc), limit: limit}
+	m.advance()
+	return m
+}
+
+func (m *ExceptIter[T]) before(a, b T) bool { return (m.asc && a < b) || (!m.asc && a > b) }
+
+// inY - moves `y` to first item which is not before `v`, then checks if it's `v`
+func (m *ExceptIter[T]) inY(v T) bool {
+	if m.yHas && !m.before(m.yHead, v) {
+		return m.yHead == v
+	}
+	if m.yEnd {
+		return false
+	}
+	m.yHead, m.yHas, m.err = seekUnary(m.y, v, m.before)
+	m.yEnd = !m.yHas
+	return m.yHas && m.err == nil && m.yHead == v
+}
+
+func (m *ExceptIter[T]) advance() {
+	m.hasNext = false
+	for m.err == nil && m.x.HasNext() {
+		var v T
+		if v, m.err = m.x.Next(); m.err != nil {
+			return
+		}
+		if !m.inY(v) && m.err == nil {
+			m.hasNext, m.nextV = true, v
+			return
+		}
+	}
+}
+
+func (m *ExceptIter[T]) HasNext() bool { return m.err != nil || (m.limit != 0 && m.hasNext) }
+func (m *ExceptIter[T]) Next() (v T, err error) {
+	if m.err != nil {
+		return v, m.err
+	}
+	m.limit--
+	v = m.nextV
+	m.advance()
+	return v, nil
+}
+func (m *ExceptIter[T]) Close() {
+	closeIt(m.x)
+	closeIt(m.y)
+}
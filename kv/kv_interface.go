@@ -369,16 +369,18 @@ type Tx interface {
 	// Range(from, nil) means [from, EndOfTable)
 	// Range(nil, to)   means [StartOfTable, to)
 	Range(table string, fromPrefix, toPrefix []byte) (iter.KV, error)
-	// Stream is like Range, but for requesting huge data (Example: full table scan). Client can't stop it.
-	//Stream(table string, fromPrefix, toPrefix []byte) (iter.KV, error)
+	// Stream is like Range, but for requesting huge data (Example: full table scan).
+	// Remote implementation doesn't wait for next page request - server pushes data until end of range.
+	// Client can stop it by `Close()` of returned iterator (if it implements kv.Closer) or by `tx.Rollback()`.
+	Stream(table string, fromPrefix, toPrefix []byte) (iter.KV, error)
 	// RangeAscend - like Range [from, to) but also allow pass Limit parameters
 	// Limit -1 means Unlimited
 	RangeAscend(table string, fromPrefix, toPrefix []byte, limit int) (iter.KV, error)
-	//StreamAscend(table string, fromPrefix, toPrefix []byte, limit int) (iter.KV, error)
+	StreamAscend(table string, fromPrefix, toPrefix []byte, limit int) (iter.KV, error)
 	// RangeDescend - is like Range [from, to), but expecing `from`<`to`
 	// example: RangeDescend("Table", "B", "A", -1)
 	RangeDescend(table string, fromPrefix, toPrefix []byte, limit int) (iter.KV, error)
-	//StreamDescend(table string, fromPrefix, toPrefix []byte, limit int) (iter.KV, error)
+	StreamDescend(table string, fromPrefix, toPrefix []byte, limit int) (iter.KV, error)
 	// Prefix - is exactly Range(Table, prefix, kv.NextSubtree(prefix))
 	Prefix(table string, prefix []byte) (iter.KV, error)
 
@@ -34,7 +34,9 @@ import (
 	"github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/types"
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/iter"
 )
 
 type CacheValidationResult struct {
@@ -54,9 +56,19 @@ type Cache interface {
 	Len() int
 	ValidateCurrentRoot(ctx context.Context, tx kv.Tx) (*CacheValidationResult, error)
 }
+
+// SupportedActions - state change actions which Coherent can apply. Send it in remote.StateChangeRequest.
+// Other actions are handled by invalidation of affected account.
+var SupportedActions = []remote.Action{remote.Action_STORAGE, remote.Action_UPSERT, remote.Action_CODE, remote.Action_UPSERT_CODE, remote.Action_REMOVE}
+
 type CacheView interface {
 	Get(k []byte) ([]byte, error)
 	GetCode(k []byte) ([]byte, error)
+	// GetMany - like Get, but for batch of keys: i-th value corresponds to i-th key (nil if key doesn't exist)
+	GetMany(keys [][]byte) ([][]byte, error)
+	// Range - iterates over all keys of PlainState with given prefix (accounts or storage slots of one account).
+	// Cached entries are merged with db entries of underlying kv.Tx. Range doesn't fill cache.
+	Range(prefix []byte) (iter.KV, error)
 }
 
 // Coherent works on top of Database Transaction and pair Coherent+ReadTransaction must
@@ -101,6 +113,7 @@ type CacheView interface {
 type Coherent struct {
 	hasher               hash.Hash
 	codeEvictLen         *metrics.Counter
+	unknownActions       *metrics.Counter
 	codeKeys             *metrics.Counter
 	keys                 *metrics.Counter
 	evict                *metrics.Counter
@@ -110,13 +123,16 @@ type Coherent struct {
 	hits                 *metrics.Counter
 	codeHits             *metrics.Counter
 	roots                map[uint64]*CoherentRoot
-	stateEvict           *ThreadSafeEvictionList
-	codeEvict            *ThreadSafeEvictionList
+	stateEvict           EvictionPolicy
+	codeEvict            EvictionPolicy
 	miss                 *metrics.Counter
 	cfg                  CoherentConfig
 	latestStateVersionID uint64
+	latestBlockNum       uint64      // block of latestStateView, used by SaveSnapshot
+	latestBlockHash      common.Hash // empty if unknown
 	lock                 sync.Mutex
-	waitExceededCount    atomic.Int32 // used as a circuit breaker to stop the cache waiting for new blocks
+	waitExceededCount    atomic.Int32             // used as a circuit breaker to stop the cache waiting for new blocks
+	snapshot             atomic.Pointer[snapshot] // loaded by LoadSnapshot, waiting for first View
 }
 
 type CoherentRoot struct {
@@ -144,6 +160,12 @@ func (c *CoherentView) Get(k []byte) ([]byte, error) { return c.cache.Get(k, c.t
 func (c *CoherentView) GetCode(k []byte) ([]byte, error) {
 	return c.cache.GetCode(k, c.tx, c.stateVersionID)
 }
+func (c *CoherentView) GetMany(keys [][]byte) ([][]byte, error) {
+	return c.cache.GetMany(keys, c.tx, c.stateVersionID)
+}
+func (c *CoherentView) Range(prefix []byte) (iter.KV, error) {
+	return c.cache.Range(prefix, c.tx, c.stateVersionID)
+}
 
 var _ Cache = (*Coherent)(nil)         // compile-time interface check
 var _ CacheView = (*CoherentView)(nil) // compile-time interface check
@@ -159,8 +181,9 @@ type CoherentConfig struct {
 	WaitForNewBlock bool // should we wait 10ms for a new block message to arrive when calling View?
 	WithStorage     bool
 	MetricsLabel    string
-	NewBlockWait    time.Duration // how long wait
-	KeepViews       uint64        // keep in memory up to this amount of views, evict older
+	NewBlockWait    time.Duration      // how long wait
+	KeepViews       uint64             // keep in memory up to this amount of views, evict older
+	Eviction        EvictionPolicyKind // LRU by default
 }
 
 var DefaultCoherentConfig = CoherentConfig{
@@ -179,20 +202,21 @@ func New(cfg CoherentConfig) *Coherent {
 	}
 
 	return &Coherent{
-		roots:        map[uint64]*CoherentRoot{},
-		stateEvict:   &ThreadSafeEvictionList{l: NewList()},
-		codeEvict:    &ThreadSafeEvictionList{l: NewList()},
-		hasher:       sha3.NewLegacyKeccak256(),
-		cfg:          cfg,
-		miss:         metrics.GetOrCreateCounter(fmt.Sprintf(`cache_total{result="miss",name="%s"}`, cfg.MetricsLabel)),
-		hits:         metrics.GetOrCreateCounter(fmt.Sprintf(`cache_total{result="hit",name="%s"}`, cfg.MetricsLabel)),
-		timeout:      metrics.GetOrCreateCounter(fmt.Sprintf(`cache_timeout_total{name="%s"}`, cfg.MetricsLabel)),
-		keys:         metrics.GetOrCreateCounter(fmt.Sprintf(`cache_keys_total{name="%s"}`, cfg.MetricsLabel)),
-		evict:        metrics.GetOrCreateCounter(fmt.Sprintf(`cache_list_total{name="%s"}`, cfg.MetricsLabel)),
-		codeMiss:     metrics.GetOrCreateCounter(fmt.Sprintf(`cache_code_total{result="miss",name="%s"}`, cfg.MetricsLabel)),
-		codeHits:     metrics.GetOrCreateCounter(fmt.Sprintf(`cache_code_total{result="hit",name="%s"}`, cfg.MetricsLabel)),
-		codeKeys:     metrics.GetOrCreateCounter(fmt.Sprintf(`cache_code_keys_total{name="%s"}`, cfg.MetricsLabel)),
-		codeEvictLen: metrics.GetOrCreateCounter(fmt.Sprintf(`cache_code_list_total{name="%s"}`, cfg.MetricsLabel)),
+		roots:          map[uint64]*CoherentRoot{},
+		stateEvict:     newEvictionPolicy(cfg.Eviction, int(cfg.CacheSize.Bytes()), cfg.MetricsLabel, "state"),
+		codeEvict:      newEvictionPolicy(cfg.Eviction, int(cfg.CodeCacheSize.Bytes()), cfg.MetricsLabel, "code"),
+		hasher:         sha3.NewLegacyKeccak256(),
+		cfg:            cfg,
+		miss:           metrics.GetOrCreateCounter(fmt.Sprintf(`cache_total{result="miss",name="%s"}`, cfg.MetricsLabel)),
+		hits:           metrics.GetOrCreateCounter(fmt.Sprintf(`cache_total{result="hit",name="%s"}`, cfg.MetricsLabel)),
+		timeout:        metrics.GetOrCreateCounter(fmt.Sprintf(`cache_timeout_total{name="%s"}`, cfg.MetricsLabel)),
+		keys:           metrics.GetOrCreateCounter(fmt.Sprintf(`cache_keys_total{name="%s"}`, cfg.MetricsLabel)),
+		evict:          metrics.GetOrCreateCounter(fmt.Sprintf(`cache_list_total{name="%s"}`, cfg.MetricsLabel)),
+		codeMiss:       metrics.GetOrCreateCounter(fmt.Sprintf(`cache_code_total{result="miss",name="%s"}`, cfg.MetricsLabel)),
+		codeHits:       metrics.GetOrCreateCounter(fmt.Sprintf(`cache_code_total{result="hit",name="%s"}`, cfg.MetricsLabel)),
+		codeKeys:       metrics.GetOrCreateCounter(fmt.Sprintf(`cache_code_keys_total{name="%s"}`, cfg.MetricsLabel)),
+		codeEvictLen:   metrics.GetOrCreateCounter(fmt.Sprintf(`cache_code_list_total{name="%s"}`, cfg.MetricsLabel)),
+		unknownActions: metrics.GetOrCreateCounter(fmt.Sprintf(`cache_unknown_actions_total{name="%s"}`, cfg.MetricsLabel)),
 	}
 }
 
@@ -242,13 +266,13 @@ func (c *Coherent) advanceRoot(stateVersionID uint64) (r *CoherentRoot) {
 		} else {
 			r.cache.Walk(func(items []*Element) bool {
 				for _, i := range items {
-					c.stateEvict.PushFront(i)
+					c.stateEvict.Restore(i)
 				}
 				return true
 			})
 			r.codeCache.Walk(func(items []*Element) bool {
 				for _, i := range items {
-					c.codeEvict.PushFront(i)
+					c.codeEvict.Restore(i)
 				}
 				return true
 			})
@@ -273,6 +297,13 @@ func (c *Coherent) OnNewBlock(stateChanges *remote.StateChangeBatch) {
 	c.waitExceededCount.Store(0) // reset the circuit breaker
 	id := stateChanges.StateVersionId
 	r := c.advanceRoot(id)
+	if n := len(stateChanges.ChangeBatch); n > 0 {
+		last := stateChanges.ChangeBatch[n-1]
+		c.latestBlockNum, c.latestBlockHash = last.BlockHeight, common.Hash{}
+		if last.Direction == remote.Direction_FORWARD && last.BlockHash != nil {
+			c.latestBlockHash = gointerfaces.ConvertH256ToHash(last.BlockHash)
+		}
+	}
 	for _, sc := range stateChanges.ChangeBatch {
 		for i := range sc.Changes {
 			switch sc.Changes[i].Action {
@@ -301,8 +332,10 @@ func (c *Coherent) OnNewBlock(stateChanges *remote.StateChangeBatch) {
 				k := make([]byte, 32)
 				c.hasher.Sum(k)
 				c.addCode(k, sc.Changes[i].Code, r, id)
-			default:
-				panic("not implemented yet")
+			default: // action of newer producer: forget what it may have changed
+				c.unknownActions.Inc()
+				c.invalidate(sc.Changes[i].Address, r)
+				continue
 			}
 			if c.cfg.WithStorage && len(sc.Changes[i].StorageChanges) > 0 {
 				addr := gointerfaces.ConvertH160toAddress(sc.Changes[i].Address)
@@ -325,6 +358,29 @@ func (c *Coherent) OnNewBlock(stateChanges *remote.StateChangeBatch) {
 	//log.Info("on new block handled", "viewID", stateChanges.StateVersionID)
 }
 
+// invalidate - forgets account and all it's storage in view `r`. Forgets whole state of view if address is unknown.
+// Code is addressed by hash - so it's never invalid.
+func (c *Coherent) invalidate(address *types.H160, r *CoherentRoot) {
+	if address == nil {
+		r.cache.Clear()
+		c.stateEvict.Init()
+		return
+	}
+	addr := gointerfaces.ConvertH160toAddress(address)
+	var invalid []*Element
+	r.cache.Ascend(&Element{K: addr[:]}, func(e *Element) bool {
+		if !bytes.HasPrefix(e.K, addr[:]) {
+			return false
+		}
+		invalid = append(invalid, e)
+		return true
+	})
+	for _, e := range invalid {
+		r.cache.Delete(e)
+		c.stateEvict.Remove(e)
+	}
+}
+
 func (c *Coherent) View(ctx context.Context, tx kv.Tx) (CacheView, error) {
 	idBytes, err := tx.GetOne(kv.Sequence, kv.PlainStateVersion)
 	if err != nil {
@@ -336,6 +392,9 @@ func (c *Coherent) View(ctx context.Context, tx kv.Tx) (CacheView, error) {
 	} else {
 		id = binary.BigEndian.Uint64(idBytes)
 	}
+	if err = c.applySnapshot(tx, id); err != nil {
+		return nil, err
+	}
 	r := c.selectOrCreateRoot(id)
 
 	if !c.cfg.WaitForNewBlock || c.waitExceededCount.Load() >= MAX_WAITS {
@@ -379,8 +438,14 @@ func (c *Coherent) getFromCache(k []byte, id uint64, code bool) (*Element, *Cohe
 	} else {
 		it, _ = r.cache.Get(&Element{K: k})
 	}
-	if it != nil && isLatest {
-		c.stateEvict.MoveToFront(it)
+	evict := c.stateEvict
+	if code {
+		evict = c.codeEvict
+	}
+	if it == nil {
+		evict.Miss()
+	} else if isLatest {
+		evict.Touch(it)
 	}
 
 	return it, r, nil
@@ -434,17 +499,88 @@ func (c *Coherent) GetCode(k []byte, tx kv.Tx, id uint64) ([]byte, error) {
 	v = c.addCode(common.Copy(k), common.Copy(v), r, id).V
 	return v, nil
 }
+
+// GetMany - takes cache lock once for all keys. Missed keys are read from db in sorted order.
+func (c *Coherent) GetMany(keys [][]byte, tx kv.Tx, id uint64) ([][]byte, error) {
+	res := make([][]byte, len(keys))
+	missed := make([]int, 0, len(keys))
+
+	c.lock.Lock()
+	r, ok := c.roots[id]
+	if !ok {
+		c.lock.Unlock()
+		return nil, fmt.Errorf("too old ViewID: %d, latestStateVersionID=%d", id, c.latestStateVersionID)
+	}
+	isLatest := c.latestStateVersionID == id
+	for i, k := range keys {
+		it, _ := r.cache.Get(&Element{K: k})
+		if it == nil {
+			c.stateEvict.Miss()
+			missed = append(missed, i)
+			continue
+		}
+		if isLatest {
+			c.stateEvict.Touch(it)
+		}
+		res[i] = it.V
+	}
+	c.lock.Unlock()
+	c.hits.Add(len(keys) - len(missed))
+	c.miss.Add(len(missed))
+	if len(missed) == 0 {
+		return res, nil
+	}
+
+	sort.Slice(missed, func(i, j int) bool { return bytes.Compare(keys[missed[i]], keys[missed[j]]) < 0 })
+	for _, i := range missed {
+		v, err := tx.GetOne(kv.PlainState, keys[i])
+		if err != nil {
+			return nil, err
+		}
+		res[i] = common.Copy(v)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, i := range missed {
+		res[i] = c.add(common.Copy(keys[i]), res[i], r, id).V
+	}
+	return res, nil
+}
+
+// Range - view `id` is coherent with `tx`, so cached entries must be equal to db entries. Anyway cached entries have
+// priority over db entries (same as in Get): nil cached value is a marker of absence of key.
+func (c *Coherent) Range(prefix []byte, tx kv.Tx, id uint64) (iter.KV, error) {
+	var cached []*Element
+	c.lock.Lock()
+	r, ok := c.roots[id]
+	if !ok {
+		c.lock.Unlock()
+		return nil, fmt.Errorf("too old ViewID: %d, latestStateVersionID=%d", id, c.latestStateVersionID)
+	}
+	r.cache.Ascend(&Element{K: prefix}, func(it *Element) bool {
+		if !bytes.HasPrefix(it.K, prefix) {
+			return false
+		}
+		cached = append(cached, it)
+		return true
+	})
+	c.lock.Unlock()
+
+	fromDB, err := tx.Prefix(kv.PlainState, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return newRangeIter(cached, fromDB), nil
+}
+
 func (c *Coherent) removeOldest(r *CoherentRoot) {
-	e := c.stateEvict.Oldest()
-	if e != nil {
-		c.stateEvict.Remove(e)
+	if e := c.stateEvict.Evict(); e != nil {
 		r.cache.Delete(e)
 	}
 }
 func (c *Coherent) removeOldestCode(r *CoherentRoot) {
-	e := c.codeEvict.Oldest()
-	if e != nil {
-		c.codeEvict.Remove(e)
+	if e := c.codeEvict.Evict(); e != nil {
 		r.codeCache.Delete(e)
 	}
 }
@@ -456,9 +592,10 @@ func (c *Coherent) add(k, v []byte, r *CoherentRoot, id uint64) *Element {
 		return it
 	}
 	if replaced != nil {
-		c.stateEvict.Remove(replaced)
+		c.stateEvict.Replace(replaced, it)
+	} else {
+		c.stateEvict.Add(it)
 	}
-	c.stateEvict.PushFront(it)
 
 	// clear down cache until size below the configured limit
 	for c.stateEvict.Size() > int(c.cfg.CacheSize.Bytes()) {
@@ -475,9 +612,10 @@ func (c *Coherent) addCode(k, v []byte, r *CoherentRoot, id uint64) *Element {
 		return it
 	}
 	if replaced != nil {
-		c.codeEvict.Remove(replaced)
+		c.codeEvict.Replace(replaced, it)
+	} else {
+		c.codeEvict.Add(it)
 	}
-	c.codeEvict.PushFront(it)
 
 	for c.codeEvict.Size() > int(c.cfg.CodeCacheSize.Bytes()) {
 		c.removeOldestCode(r)
@@ -607,6 +745,77 @@ func (c *Coherent) clearCaches(r *CoherentRoot) {
 	r.codeCache.Clear()
 }
 
+// rangeIter - merges sorted cached elements with sorted db iterator. On equal keys cached element wins,
+// cached element with nil value hides db key.
+type rangeIter struct {
+	cached       []*Element
+	db           iter.KV
+	dbK, dbV     []byte
+	dbHasNext    bool
+	nextK, nextV []byte
+	hasNext      bool
+	err          error
+}
+
+func newRangeIter(cached []*Element, db iter.KV) *rangeIter {
+	it := &rangeIter{cached: cached, db: db}
+	it.advanceDB()
+	it.advance()
+	return it
+}
+
+func (it *rangeIter) advanceDB() {
+	if it.dbHasNext = it.db.HasNext(); it.dbHasNext {
+		it.dbK, it.dbV, it.err = it.db.Next()
+	}
+}
+
+func (it *rangeIter) advance() {
+	for it.err == nil {
+		if len(it.cached) == 0 {
+			it.hasNext, it.nextK, it.nextV = it.dbHasNext, it.dbK, it.dbV
+			if it.dbHasNext {
+				it.advanceDB()
+			}
+			return
+		}
+		e := it.cached[0]
+		cmp := -1
+		if it.dbHasNext {
+			cmp = bytes.Compare(e.K, it.dbK)
+		}
+		if cmp > 0 {
+			it.hasNext, it.nextK, it.nextV = true, it.dbK, it.dbV
+			it.advanceDB()
+			return
+		}
+		it.cached = it.cached[1:]
+		if cmp == 0 {
+			it.advanceDB()
+		}
+		if e.V == nil { // deleted
+			continue
+		}
+		it.hasNext, it.nextK, it.nextV = true, e.K, e.V
+		return
+	}
+}
+
+func (it *rangeIter) HasNext() bool { return it.err != nil || it.hasNext }
+func (it *rangeIter) Next() ([]byte, []byte, error) {
+	if it.err != nil {
+		return nil, nil, it.err
+	}
+	k, v := it.nextK, it.nextV
+	it.advance()
+	return k, v, nil
+}
+func (it *rangeIter) Close() {
+	if casted, ok := it.db.(iter.Closer); ok {
+		casted.Close()
+	}
+}
+
 type Stat struct {
 	BlockNum  uint64
 	BlockHash [32]byte
@@ -719,8 +928,10 @@ func (e *Element) Size() int { return len(e.K) + len(e.V) }
 
 func Less(a, b *Element) bool { return bytes.Compare(a.K, b.K) < 0 }
 
+// ThreadSafeEvictionList - LRU EvictionPolicy
 type ThreadSafeEvictionList struct {
 	l    *List
+	m    policyMetrics
 	lock sync.Mutex
 }
 
@@ -729,15 +940,30 @@ func (l *ThreadSafeEvictionList) Init() {
 	l.l.Init()
 	l.lock.Unlock()
 }
-func (l *ThreadSafeEvictionList) PushFront(e *Element) {
+func (l *ThreadSafeEvictionList) Add(e *Element) {
 	l.lock.Lock()
 	l.l.PushFront(e)
 	l.lock.Unlock()
 }
 
-func (l *ThreadSafeEvictionList) MoveToFront(e *Element) {
+func (l *ThreadSafeEvictionList) Restore(e *Element) { l.Add(e) }
+
+func (l *ThreadSafeEvictionList) Touch(e *Element) {
 	l.lock.Lock()
+	defer l.lock.Unlock()
+	if e.list != l.l {
+		return
+	}
+	l.m.hit.Inc()
 	l.l.MoveToFront(e)
+}
+
+func (l *ThreadSafeEvictionList) Miss() { l.m.miss.Inc() }
+
+func (l *ThreadSafeEvictionList) Replace(old, new *Element) {
+	l.lock.Lock()
+	l.l.Remove(old)
+	l.l.PushFront(new)
 	l.lock.Unlock()
 }
 
@@ -747,10 +973,15 @@ func (l *ThreadSafeEvictionList) Remove(e *Element) {
 	l.lock.Unlock()
 }
 
-func (l *ThreadSafeEvictionList) Oldest() *Element {
+func (l *ThreadSafeEvictionList) Evict() *Element {
 	l.lock.Lock()
+	defer l.lock.Unlock()
 	e := l.l.Back()
-	l.lock.Unlock()
+	if e == nil {
+		return nil
+	}
+	l.l.Remove(e)
+	l.m.evict.Inc()
 	return e
 }
 
@@ -19,13 +19,17 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 
 	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/iter"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
 	"github.com/stretchr/testify/require"
 )
@@ -372,3 +376,214 @@ func TestCode(t *testing.T) {
 		return nil
 	})
 }
+
+func TestGetManyAndRange(t *testing.T) {
+	require, ctx := require.New(t), context.Background()
+	c := New(DefaultCoherentConfig)
+	db := memdb.NewTestDB(t)
+	a1, a2, a3 := [20]byte{1}, [20]byte{2}, [20]byte{3}
+	storageKey := func(addr [20]byte, loc byte) []byte {
+		k := make([]byte, 20+8+32)
+		copy(k, addr[:])
+		binary.BigEndian.PutUint64(k[20:], 1)
+		k[20+8] = loc
+		return k
+	}
+	put := func(f func(tx kv.RwTx)) uint64 {
+		var txID uint64
+		require.NoError(db.Update(ctx, func(tx kv.RwTx) error {
+			f(tx)
+			txID = tx.ViewID()
+			var versionID [8]byte
+			binary.BigEndian.PutUint64(versionID[:], txID)
+			return tx.Put(kv.Sequence, kv.PlainStateVersion, versionID[:])
+		}))
+		return txID
+	}
+
+	// existing state (no notifications about this data will come to client)
+	put(func(tx kv.RwTx) {
+		require.NoError(tx.Put(kv.PlainState, a1[:], []byte{1}))
+		require.NoError(tx.Put(kv.PlainState, a2[:], []byte{2}))
+		require.NoError(tx.Put(kv.PlainState, storageKey(a1, 1), []byte{3}))
+	})
+	id := put(func(tx kv.RwTx) {
+		require.NoError(tx.Delete(kv.PlainState, a2[:]))
+		require.NoError(tx.Put(kv.PlainState, a3[:], []byte{7}))
+		require.NoError(tx.Put(kv.PlainState, storageKey(a1, 2), []byte{4}))
+	})
+	c.OnNewBlock(&remote.StateChangeBatch{
+		StateVersionId: id,
+		ChangeBatch: []*remote.StateChange{{
+			Direction: remote.Direction_FORWARD,
+			Changes: []*remote.AccountChange{
+				{Action: remote.Action_REMOVE, Address: gointerfaces.ConvertAddressToH160(a2)},
+				{Action: remote.Action_UPSERT, Address: gointerfaces.ConvertAddressToH160(a3), Data: []byte{7}},
+				{Action: remote.Action_STORAGE, Address: gointerfaces.ConvertAddressToH160(a1), Incarnation: 1, StorageChanges: []*remote.StorageChange{
+					{Location: gointerfaces.ConvertHashToH256([32]byte{2}), Data: []byte{4}},
+				}},
+			},
+		}},
+	})
+
+	require.NoError(db.View(ctx, func(tx kv.Tx) error {
+		view, err := c.View(ctx, tx)
+		require.NoError(err)
+
+		vals, err := view.GetMany([][]byte{a3[:], a2[:], a1[:], {9}, a1[:]})
+		require.NoError(err)
+		require.Equal([][]byte{{7}, nil, {1}, nil, {1}}, vals)
+		// misses are cached now
+		cached, _, err := c.getFromCache(a1[:], id, false)
+		require.NoError(err)
+		require.Equal([]byte{1}, cached.V)
+
+		it, err := view.Range(nil)
+		require.NoError(err)
+		keys, vals, err := iter.ToKVArray(it)
+		require.NoError(err)
+		require.Equal([][]byte{a1[:], storageKey(a1, 1), storageKey(a1, 2), a3[:]}, keys)
+		require.Equal([][]byte{{1}, {3}, {4}, {7}}, vals)
+
+		it, err = view.Range(storageKey(a1, 0)[:20+8])
+		require.NoError(err)
+		keys, _, err = iter.ToKVArray(it)
+		require.NoError(err)
+		require.Equal([][]byte{storageKey(a1, 1), storageKey(a1, 2)}, keys)
+
+		// cached entries have priority over db: nil value hides key
+		func() {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			c.add(storageKey(a1, 1), nil, c.roots[id], id)
+		}()
+		it, err = view.Range(a1[:])
+		require.NoError(err)
+		keys, _, err = iter.ToKVArray(it)
+		require.NoError(err)
+		require.Equal([][]byte{a1[:], storageKey(a1, 2)}, keys)
+		return nil
+	}))
+}
+
+func TestSnapshot(t *testing.T) {
+	require, ctx := require.New(t), context.Background()
+	db := memdb.NewTestDB(t)
+	a1, a2 := [20]byte{1}, [20]byte{2}
+	code := []byte{0x60, 0x01}
+	blockHash := [32]byte{0xaa}
+	var id uint64
+	require.NoError(db.Update(ctx, func(tx kv.RwTx) error {
+		require.NoError(tx.Put(kv.PlainState, a1[:], []byte{1}))
+		require.NoError(tx.Put(kv.HeaderCanonical, hexutility.EncodeTs(10), blockHash[:]))
+		id = tx.ViewID()
+		var versionID [8]byte
+		binary.BigEndian.PutUint64(versionID[:], id)
+		return tx.Put(kv.Sequence, kv.PlainStateVersion, versionID[:])
+	}))
+
+	c := New(DefaultCoherentConfig)
+	c.OnNewBlock(&remote.StateChangeBatch{
+		StateVersionId: id,
+		ChangeBatch: []*remote.StateChange{{
+			Direction:   remote.Direction_FORWARD,
+			BlockHeight: 10,
+			BlockHash:   gointerfaces.ConvertHashToH256(blockHash),
+			Changes: []*remote.AccountChange{
+				{Action: remote.Action_UPSERT, Address: gointerfaces.ConvertAddressToH160(a1), Data: []byte{1}},
+				{Action: remote.Action_REMOVE, Address: gointerfaces.ConvertAddressToH160(a2)},
+				{Action: remote.Action_CODE, Address: gointerfaces.ConvertAddressToH160(a1), Code: code},
+			},
+		}},
+	})
+	path := filepath.Join(t.TempDir(), "kvcache.snapshot")
+	require.NoError(c.SaveSnapshot(path))
+
+	t.Run("accepted", func(t *testing.T) {
+		c := New(DefaultCoherentConfig)
+		require.NoError(c.LoadSnapshot(path))
+		require.NoError(db.View(ctx, func(tx kv.Tx) error {
+			_, err := c.View(ctx, tx)
+			require.NoError(err)
+			return nil
+		}))
+		require.Equal(id, c.latestStateVersionID)
+		require.True(c.roots[id].isCanonical)
+		require.Equal(2, c.latestStateView.cache.Len())
+		require.Equal(1, c.latestStateView.codeCache.Len())
+		require.Equal(2, c.stateEvict.Len())
+		it, _, err := c.getFromCache(a2[:], id, false)
+		require.NoError(err)
+		require.NotNil(it)
+		require.Nil(it.V) // absence marker survived
+	})
+	t.Run("hash mismatch", func(t *testing.T) {
+		require.NoError(db.Update(ctx, func(tx kv.RwTx) error {
+			// not increment PlainStateVersion - only canonical hash differs
+			return tx.Put(kv.HeaderCanonical, hexutility.EncodeTs(10), []byte{0xbb})
+		}))
+		c := New(DefaultCoherentConfig)
+		require.NoError(c.LoadSnapshot(path))
+		require.NoError(db.View(ctx, func(tx kv.Tx) error {
+			_, err := c.View(ctx, tx)
+			require.NoError(err)
+			return nil
+		}))
+		require.Nil(c.latestStateView)
+		require.Equal(0, c.roots[id].cache.Len())
+	})
+	t.Run("corrupted", func(t *testing.T) {
+		data, err := os.ReadFile(path)
+		require.NoError(err)
+		data[len(data)/2]++
+		require.NoError(os.WriteFile(path, data, 0o644))
+		require.ErrorIs(New(DefaultCoherentConfig).LoadSnapshot(path), ErrSnapshotCorrupted)
+		require.NoError(New(DefaultCoherentConfig).LoadSnapshot(path + ".not-exists"))
+	})
+}
+
+func TestUnknownAction(t *testing.T) {
+	require := require.New(t)
+	c := New(DefaultCoherentConfig)
+	a1, a2 := [20]byte{1}, [20]byte{2}
+	c.OnNewBlock(&remote.StateChangeBatch{
+		StateVersionId: 1,
+		ChangeBatch: []*remote.StateChange{{
+			Direction: remote.Direction_FORWARD,
+			Changes: []*remote.AccountChange{
+				{Action: remote.Action_UPSERT, Address: gointerfaces.ConvertAddressToH160(a1), Data: []byte{1}},
+				{Action: remote.Action_UPSERT, Address: gointerfaces.ConvertAddressToH160(a2), Data: []byte{2}},
+				{Action: remote.Action_STORAGE, Address: gointerfaces.ConvertAddressToH160(a1), StorageChanges: []*remote.StorageChange{
+					{Location: gointerfaces.ConvertHashToH256([32]byte{}), Data: []byte{3}},
+				}},
+			},
+		}},
+	})
+	require.Equal(3, c.latestStateView.cache.Len())
+	unknownBefore := c.unknownActions.Get()
+
+	newAction := remote.Action(100)
+	c.OnNewBlock(&remote.StateChangeBatch{
+		StateVersionId: 2,
+		ChangeBatch: []*remote.StateChange{{
+			Direction: remote.Direction_FORWARD,
+			Changes:   []*remote.AccountChange{{Action: newAction, Address: gointerfaces.ConvertAddressToH160(a1), Data: []byte{4}}},
+		}},
+	})
+	require.Equal(unknownBefore+1, c.unknownActions.Get())
+	require.Equal(1, c.latestStateView.cache.Len()) // account and it's storage are forgotten
+	require.Equal(1, c.stateEvict.Len())
+	it, _ := c.latestStateView.cache.Get(&Element{K: a2[:]})
+	require.Equal([]byte{2}, it.V)
+	require.Equal(3, c.roots[1].cache.Len()) // previous view not affected
+
+	c.OnNewBlock(&remote.StateChangeBatch{
+		StateVersionId: 3,
+		ChangeBatch: []*remote.StateChange{{
+			Direction: remote.Direction_FORWARD,
+			Changes:   []*remote.AccountChange{{Action: newAction}},
+		}},
+	})
+	require.Equal(0, c.latestStateView.cache.Len()) // no address - whole view is forgotten
+	require.Equal(0, c.stateEvict.Len())
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/iter"
 )
 
 // DummyCache - doesn't remember anything - can be used when service is not remote
@@ -41,6 +42,20 @@ func (c *DummyCache) Get(k []byte, tx kv.Tx, id uint64) ([]byte, error) {
 func (c *DummyCache) GetCode(k []byte, tx kv.Tx, id uint64) ([]byte, error) {
 	return tx.GetOne(kv.Code, k)
 }
+func (c *DummyCache) GetMany(keys [][]byte, tx kv.Tx, id uint64) ([][]byte, error) {
+	res := make([][]byte, len(keys))
+	for i, k := range keys {
+		v, err := tx.GetOne(kv.PlainState, k)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = v
+	}
+	return res, nil
+}
+func (c *DummyCache) Range(prefix []byte, tx kv.Tx, id uint64) (iter.KV, error) {
+	return tx.Prefix(kv.PlainState, prefix)
+}
 func (c *DummyCache) ValidateCurrentRoot(_ context.Context, _ kv.Tx) (*CacheValidationResult, error) {
 	return &CacheValidationResult{Enabled: false}, nil
 }
@@ -52,3 +67,7 @@ type DummyView struct {
 
 func (c *DummyView) Get(k []byte) ([]byte, error)     { return c.cache.Get(k, c.tx, 0) }
 func (c *DummyView) GetCode(k []byte) ([]byte, error) { return c.cache.GetCode(k, c.tx, 0) }
+func (c *DummyView) GetMany(keys [][]byte) ([][]byte, error) {
+	return c.cache.GetMany(keys, c.tx, 0)
+}
+func (c *DummyView) Range(prefix []byte) (iter.KV, error) { return c.cache.Range(prefix, c.tx, 0) }
@@ -0,0 +1,368 @@
+/*
+Copyright 2021 Erigon contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package kvcache
+
+import (
+	"container/list"
+	"fmt"
+	"hash/maphash"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// EvictionPolicy - tracks elements of latest canonical view and decides which of them to evict
+// when cache exceeds CoherentConfig.CacheSize/CodeCacheSize.
+// Coherent calls all methods under own lock: implementations don't need own locks (ThreadSafeEvictionList has it anyway).
+type EvictionPolicy interface {
+	Init()                     // forget all elements
+	Add(e *Element)            // new element added to cache
+	Restore(e *Element)        // element of cache is tracked again after Init (switch of views, snapshot): it's not an access
+	Touch(e *Element)          // cache hit
+	Miss()                     // cache miss: key is read from db
+	Replace(old, new *Element) // value of key changed. `new` inherits history of `old`
+	Remove(e *Element)         // element deleted from cache not by eviction
+	Evict() (victim *Element)  // remove element from policy and return it. nil if policy is empty
+	Len() int                  // amount of elements
+	Size() int                 // size of elements in bytes
+}
+
+type EvictionPolicyKind string
+
+const (
+	LRU      EvictionPolicyKind = "lru"     // least recently used
+	TwoQueue EvictionPolicyKind = "2q"      // scan-resistant: elements accessed once don't push out frequently used
+	TinyLFU  EvictionPolicyKind = "tinylfu" // W-TinyLFU: admission by estimated frequency of access
+)
+
+func newEvictionPolicy(kind EvictionPolicyKind, maxSize int, metricsLabel, cacheName string) EvictionPolicy {
+	m := newPolicyMetrics(kind, metricsLabel, cacheName)
+	switch kind {
+	case LRU, "":
+		return &ThreadSafeEvictionList{l: NewList(), m: m}
+	case TwoQueue:
+		return newTwoQueue(maxSize, m)
+	case TinyLFU:
+		return newTinyLFU(maxSize, m)
+	default:
+		panic(fmt.Sprintf("kvcache: unknown eviction policy: %s", kind))
+	}
+}
+
+type policyMetrics struct {
+	hit, miss, evict *metrics.Counter
+}
+
+func newPolicyMetrics(kind EvictionPolicyKind, metricsLabel, cacheName string) policyMetrics {
+	if kind == "" {
+		kind = LRU
+	}
+	counter := func(result string) *metrics.Counter {
+		return metrics.GetOrCreateCounter(fmt.Sprintf(`cache_policy_total{result="%s",policy="%s",cache="%s",name="%s"}`, result, kind, cacheName, metricsLabel))
+	}
+	return policyMetrics{hit: counter("hit"), miss: counter("miss"), evict: counter("evict")}
+}
+
+// twoQueue - 2Q (Johnson, Shasha). New elements go to FIFO `in`, elements evicted from `in` are remembered
+// in `out` (keys only). Element accessed again while it's in `in` or remembered in `out` - goes to LRU `am`.
+// So one-off scans can't push out elements of `am`.
+type twoQueue struct {
+	in, am   List
+	out      map[string]*list.Element
+	outOrder *list.List
+	inMax    int // in bytes
+	m        policyMetrics
+}
+
+func newTwoQueue(maxSize int, m policyMetrics) *twoQueue {
+	q := &twoQueue{out: map[string]*list.Element{}, outOrder: list.New(), inMax: maxSize / 4, m: m}
+	q.in.Init()
+	q.am.Init()
+	return q
+}
+
+func (q *twoQueue) Init() {
+	q.in.Init()
+	q.am.Init()
+	q.out = map[string]*list.Element{}
+	q.outOrder.Init()
+}
+
+func (q *twoQueue) Add(e *Element) {
+	if ghost, ok := q.out[string(e.K)]; ok {
+		q.outOrder.Remove(ghost)
+		delete(q.out, string(e.K))
+		q.am.PushFront(e)
+		return
+	}
+	q.in.PushFront(e)
+}
+
+func (q *twoQueue) Restore(e *Element) { q.in.PushFront(e) }
+
+func (q *twoQueue) Touch(e *Element) {
+	switch e.list {
+	case &q.am:
+		q.m.hit.Inc()
+		q.am.MoveToFront(e)
+	case &q.in:
+		q.m.hit.Inc()
+		q.in.Remove(e)
+		q.am.PushFront(e)
+	}
+}
+
+func (q *twoQueue) Miss() { q.m.miss.Inc() }
+
+func (q *twoQueue) Replace(old, new *Element) {
+	if l := old.list; l == &q.in || l == &q.am {
+		l.InsertBefore(new, old)
+		l.Remove(old)
+		return
+	}
+	q.Add(new)
+}
+
+func (q *twoQueue) Remove(e *Element) {
+	q.in.Remove(e)
+	q.am.Remove(e)
+}
+
+func (q *twoQueue) Evict() *Element {
+	if q.in.Size() > q.inMax || q.am.Len() == 0 {
+		if e := q.in.Back(); e != nil {
+			q.in.Remove(e)
+			q.remember(e.K)
+			q.m.evict.Inc()
+			return e
+		}
+	}
+	e := q.am.Back()
+	if e == nil {
+		return nil
+	}
+	q.am.Remove(e)
+	q.m.evict.Inc()
+	return e
+}
+
+// remember - keeps up to len/2 recently evicted keys
+func (q *twoQueue) remember(k []byte) {
+	if _, ok := q.out[string(k)]; ok {
+		return
+	}
+	q.out[string(k)] = q.outOrder.PushFront(string(k))
+	for limit := (q.Len() + 1) / 2; q.outOrder.Len() > limit; {
+		delete(q.out, q.outOrder.Remove(q.outOrder.Back()).(string))
+	}
+}
+
+func (q *twoQueue) Len() int  { return q.in.Len() + q.am.Len() }
+func (q *twoQueue) Size() int { return q.in.Size() + q.am.Size() }
+
+// tinyLFU - W-TinyLFU (Einziger, Friedman, Manes). New elements go to small LRU `window`.
+// Element leaving `window` competes with victim of main SLRU (`probation`+`protected`):
+// the one with lower estimated frequency of access is evicted.
+type tinyLFU struct {
+	window, probation, protected List
+	windowMax, protectedMax      int // in bytes
+	sketch                       *cmSketch
+	m                            policyMetrics
+}
+
+func newTinyLFU(maxSize int, m policyMetrics) *tinyLFU {
+	windowMax := maxSize / 100
+	p := &tinyLFU{windowMax: windowMax, protectedMax: (maxSize - windowMax) * 8 / 10, m: m}
+	p.window.Init()
+	p.probation.Init()
+	p.protected.Init()
+	width := maxSize / 64 // approximate amount of elements
+	if width < 1<<10 {
+		width = 1 << 10
+	} else if width > 1<<20 {
+		width = 1 << 20
+	}
+	p.sketch = newCMSketch(width)
+	return p
+}
+
+// Init - frequencies are not forgotten, they are still valid after re-init of cache
+func (p *tinyLFU) Init() {
+	p.window.Init()
+	p.probation.Init()
+	p.protected.Init()
+}
+
+func (p *tinyLFU) Add(e *Element) {
+	p.sketch.Add(e.K)
+	p.window.PushFront(e)
+}
+
+// Restore - frequencies survive Init, re-tracked element must not increase them
+func (p *tinyLFU) Restore(e *Element) { p.window.PushFront(e) }
+
+func (p *tinyLFU) Touch(e *Element) {
+	switch e.list {
+	case &p.window, &p.protected:
+		e.list.MoveToFront(e)
+	case &p.probation:
+		p.probation.Remove(e)
+		p.protected.PushFront(e)
+		for p.protected.Size() > p.protectedMax {
+			demoted := p.protected.Back()
+			p.protected.Remove(demoted)
+			p.probation.PushFront(demoted)
+		}
+	default:
+		return
+	}
+	p.m.hit.Inc()
+	p.sketch.Add(e.K)
+}
+
+func (p *tinyLFU) Miss() { p.m.miss.Inc() }
+
+func (p *tinyLFU) Replace(old, new *Element) {
+	if l := old.list; l == &p.window || l == &p.probation || l == &p.protected {
+		l.InsertBefore(new, old)
+		l.Remove(old)
+		return
+	}
+	p.Add(new)
+}
+
+func (p *tinyLFU) Remove(e *Element) {
+	if l := e.list; l == &p.window || l == &p.probation || l == &p.protected {
+		l.Remove(e)
+	}
+}
+
+func (p *tinyLFU) Evict() *Element {
+	if p.probation.Len()+p.protected.Len() == 0 { // cache filled first time: nothing to compete with
+		for p.window.Size() > p.windowMax {
+			e := p.window.Back()
+			p.window.Remove(e)
+			p.probation.PushFront(e)
+		}
+	}
+	victim := p.probation.Back()
+	if victim == nil {
+		victim = p.protected.Back()
+	}
+	candidate := p.window.Back()
+	if candidate == nil && victim == nil {
+		return nil
+	}
+	if victim == nil || (candidate != nil && p.sketch.Estimate(candidate.K) <= p.sketch.Estimate(victim.K)) {
+		p.window.Remove(candidate)
+		p.m.evict.Inc()
+		return candidate
+	}
+	if candidate != nil && p.window.Size() > p.windowMax { // admitted
+		p.window.Remove(candidate)
+		p.probation.PushFront(candidate)
+	}
+	victim.list.Remove(victim)
+	p.m.evict.Inc()
+	return victim
+}
+
+func (p *tinyLFU) Len() int { return p.window.Len() + p.probation.Len() + p.protected.Len() }
+func (p *tinyLFU) Size() int {
+	return p.window.Size() + p.probation.Size() + p.protected.Size()
+}
+
+// cmSketch - count-min sketch with 4-bit saturating counters and doorkeeper: first occurrence of key only sets
+// bits in doorkeeper, so one-off keys don't pollute counters. All counters are halved and doorkeeper is cleared
+// after 10*width additions, so old popularity fades out.
+type cmSketch struct {
+	rows       [4][]uint8
+	doorkeeper []uint64
+	mask       uint64
+	seed       maphash.Seed
+	additions  int
+	resetAt    int
+}
+
+func newCMSketch(width int) *cmSketch {
+	w := 1
+	for w < width {
+		w <<= 1
+	}
+	s := &cmSketch{mask: uint64(w - 1), seed: maphash.MakeSeed(), resetAt: 10 * w, doorkeeper: make([]uint64, w/8)}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, w)
+	}
+	return s
+}
+
+// index - re-mixes hash for each row, so keys colliding in one row unlikely collide in others
+func (s *cmSketch) index(h uint64, row int) uint64 {
+	h += uint64(row) * 0x9e3779b97f4a7c15
+	h = (h ^ (h >> 30)) * 0xbf58476d1ce4e5b9
+	h = (h ^ (h >> 27)) * 0x94d049bb133111eb
+	return (h ^ (h >> 31)) & s.mask
+}
+
+// doorkeeperBits - 2 bits in doorkeeper of 8*width bits
+func (s *cmSketch) doorkeeperBits(h uint64) (uint64, uint64) {
+	bitsMask := uint64(len(s.doorkeeper))*64 - 1
+	return (h >> 7) & bitsMask, (h >> 37) & bitsMask
+}
+
+func (s *cmSketch) Add(k []byte) {
+	h := maphash.Bytes(s.seed, k)
+	s.additions++
+	if s.additions >= s.resetAt {
+		s.reset()
+	}
+	b1, b2 := s.doorkeeperBits(h)
+	if s.doorkeeper[b1/64]&(1<<(b1%64)) == 0 || s.doorkeeper[b2/64]&(1<<(b2%64)) == 0 {
+		s.doorkeeper[b1/64] |= 1 << (b1 % 64)
+		s.doorkeeper[b2/64] |= 1 << (b2 % 64)
+		return
+	}
+	for i := range s.rows {
+		if idx := s.index(h, i); s.rows[i][idx] < 15 {
+			s.rows[i][idx]++
+		}
+	}
+}
+
+func (s *cmSketch) Estimate(k []byte) uint8 {
+	h := maphash.Bytes(s.seed, k)
+	estimate := uint8(15)
+	for i := range s.rows {
+		if v := s.rows[i][s.index(h, i)]; v < estimate {
+			estimate = v
+		}
+	}
+	if b1, b2 := s.doorkeeperBits(h); s.doorkeeper[b1/64]&(1<<(b1%64)) != 0 && s.doorkeeper[b2/64]&(1<<(b2%64)) != 0 {
+		estimate++
+	}
+	return estimate
+}
+
+func (s *cmSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	for i := range s.doorkeeper {
+		s.doorkeeper[i] = 0
+	}
+	s.additions /= 2
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2021 Erigon contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package kvcache
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvictionPolicies(t *testing.T) {
+	const maxSize, elementSize = 1000, 10 // 100 elements
+	for _, kind := range []EvictionPolicyKind{LRU, TwoQueue, TinyLFU} {
+		kind := kind
+		t.Run(string(kind), func(t *testing.T) {
+			require := require.New(t)
+			p := newEvictionPolicy(kind, maxSize, "test", "state")
+			cached := map[uint32]*Element{}
+			get := func(key uint32) {
+				if e, ok := cached[key]; ok {
+					p.Touch(e)
+					return
+				}
+				p.Miss()
+				e := &Element{K: binary.BigEndian.AppendUint32(nil, key), V: make([]byte, elementSize-4)}
+				cached[key] = e
+				p.Add(e)
+				for p.Size() > maxSize {
+					victim := p.Evict()
+					require.NotNil(victim)
+					delete(cached, binary.BigEndian.Uint32(victim.K))
+				}
+			}
+
+			for round := 0; round < 8; round++ { // hot set
+				for key := uint32(0); key < 50; key++ {
+					get(key)
+				}
+			}
+			for key := uint32(1000); key < 3000; key++ { // one-off scan
+				get(key)
+			}
+			require.Equal(len(cached), p.Len())
+			require.LessOrEqual(p.Size(), maxSize)
+			hot := 0
+			for key := uint32(0); key < 50; key++ {
+				if _, ok := cached[key]; ok {
+					hot++
+				}
+			}
+			switch kind { // 2Q and TinyLFU are scan-resistant
+			case LRU:
+				require.Zero(hot)
+			case TwoQueue:
+				require.Equal(50, hot)
+			case TinyLFU:
+				require.GreaterOrEqual(hot, 45) // frequency estimation is probabilistic
+			}
+
+			// replaced element stays in cache instead of old one
+			var old *Element
+			for _, old = range cached {
+				break
+			}
+			replacement := &Element{K: old.K, V: []byte{1}}
+			p.Replace(old, replacement)
+			require.Equal(len(cached), p.Len())
+			p.Remove(replacement)
+			require.Equal(len(cached)-1, p.Len())
+
+			// Add is also called for state changes of new blocks: only read path counts misses
+			m := newPolicyMetrics(kind, "test", "state")
+			misses := m.miss.Get()
+			p.Add(&Element{K: []byte{0xff}})
+			require.Equal(misses, m.miss.Get())
+			p.Miss()
+			require.Equal(misses+1, m.miss.Get())
+
+			p.Init()
+			require.Zero(p.Len())
+			require.Nil(p.Evict())
+
+			// switch of views re-tracks elements of cache: it's not an access
+			restored := &Element{K: []byte{0xfe}}
+			p.Restore(restored)
+			require.Equal(1, p.Len())
+			if lfu, ok := p.(*tinyLFU); ok {
+				estimate := lfu.sketch.Estimate(restored.K)
+				for i := 0; i < 10; i++ {
+					p.Init()
+					p.Restore(restored)
+				}
+				require.Equal(estimate, lfu.sketch.Estimate(restored.K))
+			}
+		})
+	}
+}
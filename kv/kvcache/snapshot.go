@@ -0,0 +1,263 @@
+/*
+Copyright 2021 Erigon contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package kvcache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	btree2 "github.com/tidwall/btree"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// Snapshot file format (all integers are big-endian):
+//
+//	magic(8) | stateVersionID(8) | blockNum(8) | blockHash(32)
+//	stateCount(8) | stateCount * entry
+//	codeCount(8)  | codeCount * entry
+//	crc32(4) - of everything above
+//
+// entry: uvarint(len(k)) | k | uvarint(len(v)+1) | v    (0 instead of len(v)+1 means v==nil - marker of absence in db)
+var snapshotMagic = [8]byte{'k', 'v', 'c', 'a', 'c', 'h', 'e', 1}
+
+var ErrSnapshotCorrupted = errors.New("kvcache: snapshot file corrupted")
+
+type snapshot struct {
+	stateVersionID uint64
+	blockNum       uint64
+	blockHash      common.Hash
+	cache          *btree2.BTreeG[*Element]
+	codeCache      *btree2.BTreeG[*Element]
+}
+
+// SaveSnapshot - writes latest canonical view (state and code caches) to file, to warm-up cache after restart (see LoadSnapshot).
+// Call it on shutdown. Does nothing if cache doesn't know hash of block of latest view (no blocks or unwind).
+// File is replaced atomically.
+func (c *Coherent) SaveSnapshot(path string) error {
+	c.lock.Lock()
+	r, stateVersionID, blockNum, blockHash := c.latestStateView, c.latestStateVersionID, c.latestBlockNum, c.latestBlockHash
+	var cache, codeCache *btree2.BTreeG[*Element]
+	if r != nil {
+		cache, codeCache = r.cache.Copy(), r.codeCache.Copy()
+	}
+	c.lock.Unlock()
+	if r == nil || blockHash == (common.Hash{}) {
+		return nil
+	}
+
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+	defer f.Close()
+
+	crc := crc32.NewIEEE()
+	w := bufio.NewWriterSize(io.MultiWriter(f, crc), 1024*1024)
+	var num [8]byte
+	writeUint64 := func(v uint64) error {
+		binary.BigEndian.PutUint64(num[:], v)
+		_, err := w.Write(num[:])
+		return err
+	}
+	var lenBuf [binary.MaxVarintLen64]byte
+	writeTree := func(tree *btree2.BTreeG[*Element]) error {
+		if err := writeUint64(uint64(tree.Len())); err != nil {
+			return err
+		}
+		var err error
+		tree.Scan(func(e *Element) bool {
+			if _, err = w.Write(lenBuf[:binary.PutUvarint(lenBuf[:], uint64(len(e.K)))]); err != nil {
+				return false
+			}
+			if _, err = w.Write(e.K); err != nil {
+				return false
+			}
+			vLen := uint64(0)
+			if e.V != nil {
+				vLen = uint64(len(e.V)) + 1
+			}
+			if _, err = w.Write(lenBuf[:binary.PutUvarint(lenBuf[:], vLen)]); err != nil {
+				return false
+			}
+			_, err = w.Write(e.V)
+			return err == nil
+		})
+		return err
+	}
+
+	if _, err = w.Write(snapshotMagic[:]); err != nil {
+		return err
+	}
+	if err = writeUint64(stateVersionID); err != nil {
+		return err
+	}
+	if err = writeUint64(blockNum); err != nil {
+		return err
+	}
+	if _, err = w.Write(blockHash[:]); err != nil {
+		return err
+	}
+	if err = writeTree(cache); err != nil {
+		return err
+	}
+	if err = writeTree(codeCache); err != nil {
+		return err
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	if _, err = f.Write(sum[:]); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// LoadSnapshot - reads file created by SaveSnapshot. Call it on start, before first View.
+// Loaded data is not used immediately: first View accepts it only if kv.Tx of that View has same stateVersionID
+// and same canonical block hash, otherwise it's dropped. Missing file is not an error.
+func (c *Coherent) LoadSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	s, err := decodeSnapshot(data)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, path)
+	}
+	c.snapshot.Store(s)
+	return nil
+}
+
+func decodeSnapshot(data []byte) (*snapshot, error) {
+	headerLen := len(snapshotMagic) + 8 + 8 + 32
+	if len(data) < headerLen+4 || !bytes.Equal(data[:len(snapshotMagic)], snapshotMagic[:]) {
+		return nil, ErrSnapshotCorrupted
+	}
+	data, sum := data[:len(data)-4], binary.BigEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(data) != sum {
+		return nil, ErrSnapshotCorrupted
+	}
+	s := &snapshot{
+		stateVersionID: binary.BigEndian.Uint64(data[8:]),
+		blockNum:       binary.BigEndian.Uint64(data[16:]),
+		cache:          btree2.NewBTreeG[*Element](Less),
+		codeCache:      btree2.NewBTreeG[*Element](Less),
+	}
+	copy(s.blockHash[:], data[24:headerLen])
+	data = data[headerLen:]
+
+	readTree := func(tree *btree2.BTreeG[*Element]) error {
+		if len(data) < 8 {
+			return ErrSnapshotCorrupted
+		}
+		count := binary.BigEndian.Uint64(data)
+		data = data[8:]
+		for i := uint64(0); i < count; i++ {
+			kLen, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < kLen {
+				return ErrSnapshotCorrupted
+			}
+			k := common.Copy(data[n : n+int(kLen)])
+			data = data[n+int(kLen):]
+			vLen, n := binary.Uvarint(data)
+			if n <= 0 || (vLen > 0 && uint64(len(data)-n) < vLen-1) {
+				return ErrSnapshotCorrupted
+			}
+			data = data[n:]
+			var v []byte
+			if vLen > 0 {
+				v = common.Copy(data[:vLen-1])
+				data = data[vLen-1:]
+			}
+			tree.Set(&Element{K: k, V: v})
+		}
+		return nil
+	}
+	if err := readTree(s.cache); err != nil {
+		return nil, err
+	}
+	if err := readTree(s.codeCache); err != nil {
+		return nil, err
+	}
+	if len(data) != 0 {
+		return nil, ErrSnapshotCorrupted
+	}
+	return s, nil
+}
+
+// applySnapshot - called by View: snapshot loaded by LoadSnapshot is accepted (becomes latest canonical view)
+// only if it matches the first View. In any case it's not used after first View.
+func (c *Coherent) applySnapshot(tx kv.Tx, stateVersionID uint64) error {
+	s := c.snapshot.Swap(nil)
+	if s == nil || s.stateVersionID != stateVersionID {
+		return nil
+	}
+	canonicalHash, err := tx.GetOne(kv.HeaderCanonical, hexutility.EncodeTs(s.blockNum))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(canonicalHash, s.blockHash[:]) {
+		return nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.latestStateView != nil { // OnNewBlock was faster, cache has own view
+		return nil
+	}
+	if _, ok := c.roots[stateVersionID]; ok {
+		return nil
+	}
+	c.roots[stateVersionID] = &CoherentRoot{ready: make(chan struct{}), cache: s.cache, codeCache: s.codeCache}
+	r := c.advanceRoot(stateVersionID) // fills evict lists by snapshot's elements
+	for c.stateEvict.Size() > int(c.cfg.CacheSize.Bytes()) {
+		c.removeOldest(r)
+	}
+	for c.codeEvict.Size() > int(c.cfg.CodeCacheSize.Bytes()) {
+		c.removeOldestCode(r)
+	}
+	c.keys.Set(uint64(r.cache.Len()))
+	c.codeKeys.Set(uint64(r.codeCache.Len()))
+	c.evict.Set(uint64(c.stateEvict.Len()))
+	c.codeEvictLen.Set(uint64(c.codeEvict.Len()))
+	c.latestBlockNum, c.latestBlockHash = s.blockNum, s.blockHash
+	if r.readyChanClosed.CompareAndSwap(false, true) {
+		close(r.ready)
+	}
+	return nil
+}
@@ -0,0 +1,466 @@
+/*
+   Copyright 2023 Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package kvtest - behavioral spec of kv.Tx/kv.RwTx. Every kv.RwDB implementation must pass it,
+// then app code can switch between implementations without surprises.
+//
+// Usage:
+//
+//	kvtest.Suite{OpenRw: func(tb testing.TB, fill func(tx kv.RwTx)) kv.RwTx { ... }}.Run(t)
+package kvtest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/iter"
+	"github.com/ledgerwatch/erigon-lib/kv/order"
+	"github.com/stretchr/testify/require"
+)
+
+// Tables used by spec. Implementation must use kv.ChaindataTablesCfg for them.
+const (
+	PlainTable   = kv.HeaderNumber     // no flags
+	DupSortTable = kv.AccountChangeSet // DupSort
+	AutoDupTable = kv.PlainState       // DupSort with AutoDupSortKeysConversion
+)
+
+// Suite - describes how to open transactions of implementation under test.
+// `fill` writes fixtures and must be applied before returned transaction created - such way data of underlying
+// storage (not in-memory overlay or not-committed changes) is tested.
+type Suite struct {
+	// Open - opens read-only transaction. Can be nil if OpenRw provided.
+	Open func(tb testing.TB, fill func(tx kv.RwTx)) kv.Tx
+	// OpenRw - opens read-write transaction. nil for read-only implementations: write-spec will be skipped.
+	OpenRw func(tb testing.TB, fill func(tx kv.RwTx)) kv.RwTx
+}
+
+func (s Suite) open(tb testing.TB) kv.Tx {
+	tb.Helper()
+	if s.Open != nil {
+		return s.Open(tb, Fill)
+	}
+	return s.OpenRw(tb, Fill)
+}
+
+// Run - runs whole spec
+func (s Suite) Run(t *testing.T) {
+	t.Helper()
+	if s.Open == nil && s.OpenRw == nil {
+		t.Fatal("kvtest: Open or OpenRw must be provided")
+	}
+	t.Run("Seek", func(t *testing.T) { testSeek(t, s.open(t)) })
+	t.Run("DupSort", func(t *testing.T) { testDupSort(t, s.open(t)) })
+	t.Run("AutoDupSortKeysConversion", func(t *testing.T) { testAutoDupSort(t, s.open(t)) })
+	t.Run("Range", func(t *testing.T) { testRange(t, s.open(t)) })
+	t.Run("Stream", func(t *testing.T) { testStream(t, s.open(t)) })
+	t.Run("Reverse", func(t *testing.T) { testReverse(t, s.open(t)) })
+	t.Run("Sequence", func(t *testing.T) { testSequence(t, s.open(t)) })
+	t.Run("Size", func(t *testing.T) { testSize(t, s.open(t)) })
+
+	if s.OpenRw == nil {
+		return
+	}
+	t.Run("Put", func(t *testing.T) { testPut(t, s.OpenRw(t, Fill)) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, s.OpenRw(t, Fill)) })
+	t.Run("ClearBucket", func(t *testing.T) { testClearBucket(t, s.OpenRw(t, Fill)) })
+}
+
+var (
+	acc1 = bytes.Repeat([]byte{1}, 20)
+	acc2 = bytes.Repeat([]byte{2}, 20)
+	inc1 = []byte{0, 0, 0, 0, 0, 0, 0, 1}
+
+	storage1 = append(append(cp(acc1), inc1...), bytes.Repeat([]byte{1}, 32)...)
+	storage2 = append(append(cp(acc1), inc1...), bytes.Repeat([]byte{2}, 32)...)
+	storage3 = append(append(cp(acc1), inc1...), bytes.Repeat([]byte{3}, 32)...)
+)
+
+func cp(b []byte) []byte { return append([]byte{}, b...) }
+
+// Fill - writes fixtures used by spec:
+//
+//	PlainTable:   1->11, 3->33, 5->55, 7->77
+//	DupSortTable: 1->[1,3,5], 3->[1], 5->[2,4]
+//	AutoDupTable: acc1->a, acc1+inc+loc1->b, acc1+inc+loc2->c, acc2->d
+//	Sequence:     PlainTable->7
+func Fill(tx kv.RwTx) {
+	for _, i := range []byte{1, 3, 5, 7} {
+		if err := tx.Put(PlainTable, []byte{i}, []byte{i, i}); err != nil {
+			panic(err)
+		}
+	}
+	dups := map[byte][]byte{1: {1, 3, 5}, 3: {1}, 5: {2, 4}}
+	for _, k := range []byte{1, 3, 5} {
+		for _, v := range dups[k] {
+			if err := tx.Put(DupSortTable, []byte{k}, []byte{v}); err != nil {
+				panic(err)
+			}
+		}
+	}
+	for _, pair := range [][2][]byte{{acc1, {0xa}}, {storage1, {0xb}}, {storage2, {0xc}}, {acc2, {0xd}}} {
+		if err := tx.Put(AutoDupTable, pair[0], pair[1]); err != nil {
+			panic(err)
+		}
+	}
+	if _, err := tx.IncrementSequence(PlainTable, 7); err != nil {
+		panic(err)
+	}
+}
+
+func requireKV(tb testing.TB, expectK, expectV []byte) func(k, v []byte, err error) {
+	tb.Helper()
+	return func(k, v []byte, err error) {
+		tb.Helper()
+		require.NoError(tb, err)
+		require.Equal(tb, expectK, k)
+		require.Equal(tb, expectV, v)
+	}
+}
+
+func requireV(tb testing.TB, expectV []byte) func(v []byte, err error) {
+	tb.Helper()
+	return func(v []byte, err error) {
+		tb.Helper()
+		require.NoError(tb, err)
+		require.Equal(tb, expectV, v)
+	}
+}
+
+func requirePairs(tb testing.TB, it iter.KV, err error, expect ...[]byte) {
+	tb.Helper()
+	require.NoError(tb, err)
+	keys, values, err := iter.ToKVArray(it)
+	require.NoError(tb, err)
+	var res [][]byte
+	for i := range keys {
+		res = append(res, keys[i], values[i])
+	}
+	require.Equal(tb, expect, res)
+}
+
+func testSeek(t *testing.T, tx kv.Tx) {
+	c, err := tx.Cursor(PlainTable)
+	require.NoError(t, err)
+	defer c.Close()
+
+	requireKV(t, []byte{1}, []byte{1, 1})(c.First())
+	requireKV(t, []byte{3}, []byte{3, 3})(c.Next())
+	requireKV(t, []byte{5}, []byte{5, 5})(c.Next())
+	requireKV(t, []byte{7}, []byte{7, 7})(c.Next())
+	requireKV(t, nil, nil)(c.Next())
+
+	requireKV(t, []byte{3}, []byte{3, 3})(c.Seek([]byte{2}))
+	requireKV(t, []byte{5}, []byte{5, 5})(c.Next())
+	requireKV(t, []byte{5}, []byte{5, 5})(c.Current())
+	requireKV(t, []byte{3}, []byte{3, 3})(c.Seek([]byte{3}))
+	requireKV(t, []byte{1}, []byte{1, 1})(c.Seek(nil))
+	requireKV(t, nil, nil)(c.Seek([]byte{8}))
+
+	requireKV(t, []byte{3}, []byte{3, 3})(c.SeekExact([]byte{3}))
+	requireKV(t, []byte{3}, []byte{3, 3})(c.Current())
+	requireKV(t, []byte{5}, []byte{5, 5})(c.Next())
+	requireKV(t, nil, nil)(c.SeekExact([]byte{4}))
+
+	requireV(t, []byte{5, 5})(tx.GetOne(PlainTable, []byte{5}))
+	requireV(t, nil)(tx.GetOne(PlainTable, []byte{6}))
+	has, err := tx.Has(PlainTable, []byte{5})
+	require.NoError(t, err)
+	require.True(t, has)
+	has, err = tx.Has(PlainTable, []byte{6})
+	require.NoError(t, err)
+	require.False(t, has)
+
+	var keys []byte
+	require.NoError(t, tx.ForEach(PlainTable, []byte{2}, func(k, v []byte) error {
+		keys = append(keys, k...)
+		return nil
+	}))
+	require.Equal(t, []byte{3, 5, 7}, keys)
+	keys = keys[:0]
+	require.NoError(t, tx.ForAmount(PlainTable, []byte{2}, 2, func(k, v []byte) error {
+		keys = append(keys, k...)
+		return nil
+	}))
+	require.Equal(t, []byte{3, 5}, keys)
+}
+
+func testDupSort(t *testing.T, tx kv.Tx) {
+	c, err := tx.CursorDupSort(DupSortTable)
+	require.NoError(t, err)
+	defer c.Close()
+
+	requireKV(t, []byte{1}, []byte{1})(c.First())
+	requireKV(t, []byte{1}, []byte{3})(c.Next())
+	requireKV(t, []byte{1}, []byte{5})(c.Next())
+	requireKV(t, []byte{3}, []byte{1})(c.Next())
+	requireKV(t, []byte{5}, []byte{2})(c.Next())
+	requireKV(t, []byte{5}, []byte{4})(c.Next())
+	requireKV(t, nil, nil)(c.Next())
+
+	requireKV(t, []byte{1}, []byte{1})(c.SeekExact([]byte{1}))
+	requireKV(t, []byte{1}, []byte{3})(c.NextDup())
+	requireKV(t, []byte{1}, []byte{5})(c.NextDup())
+	requireKV(t, nil, nil)(c.NextDup())
+
+	requireKV(t, []byte{1}, []byte{1})(c.SeekExact([]byte{1}))
+	requireKV(t, []byte{3}, []byte{1})(c.NextNoDup())
+	requireKV(t, []byte{5}, []byte{2})(c.NextNoDup())
+	requireKV(t, nil, nil)(c.NextNoDup())
+
+	requireKV(t, []byte{3}, []byte{1})(c.Seek([]byte{2}))
+	requireKV(t, nil, nil)(c.SeekExact([]byte{2}))
+
+	requireV(t, []byte{3})(c.SeekBothRange([]byte{1}, []byte{2}))
+	requireKV(t, []byte{1}, []byte{5})(c.NextDup())
+	requireV(t, []byte{3})(c.SeekBothRange([]byte{1}, []byte{3}))
+	requireV(t, nil)(c.SeekBothRange([]byte{1}, []byte{6}))
+	requireV(t, nil)(c.SeekBothRange([]byte{2}, []byte{1}))
+
+	requireKV(t, []byte{5}, []byte{4})(c.SeekBothExact([]byte{5}, []byte{4}))
+	requireKV(t, nil, nil)(c.SeekBothExact([]byte{5}, []byte{3}))
+
+	requireKV(t, []byte{1}, []byte{3})(c.SeekBothExact([]byte{1}, []byte{3}))
+	requireV(t, []byte{5})(c.LastDup())
+	requireV(t, []byte{1})(c.FirstDup())
+	requireKV(t, []byte{1}, []byte{3})(c.NextDup())
+
+	requireKV(t, []byte{5}, []byte{2})(c.SeekExact([]byte{5}))
+	cnt, err := c.CountDuplicates()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), cnt)
+	requireKV(t, []byte{5}, []byte{2})(c.Current())
+
+	requireV(t, []byte{1})(tx.GetOne(DupSortTable, []byte{1}))
+}
+
+func testAutoDupSort(t *testing.T, tx kv.Tx) {
+	c, err := tx.Cursor(AutoDupTable)
+	require.NoError(t, err)
+	defer c.Close()
+
+	requireKV(t, acc1, []byte{0xa})(c.First())
+	requireKV(t, storage1, []byte{0xb})(c.Next())
+	requireKV(t, storage2, []byte{0xc})(c.Next())
+	requireKV(t, acc2, []byte{0xd})(c.Next())
+	requireKV(t, nil, nil)(c.Next())
+
+	requireKV(t, storage2, []byte{0xc})(c.Seek(storage2))
+	requireKV(t, acc2, []byte{0xd})(c.Next())
+	requireKV(t, storage1, []byte{0xb})(c.SeekExact(storage1))
+	requireKV(t, nil, nil)(c.SeekExact(storage3))
+
+	requireV(t, []byte{0xa})(tx.GetOne(AutoDupTable, acc1))
+	requireV(t, []byte{0xc})(tx.GetOne(AutoDupTable, storage2))
+	requireV(t, nil)(tx.GetOne(AutoDupTable, storage3))
+}
+
+func testRange(t *testing.T, tx kv.Tx) {
+	it, err := tx.Range(PlainTable, nil, nil)
+	requirePairs(t, it, err, []byte{1}, []byte{1, 1}, []byte{3}, []byte{3, 3}, []byte{5}, []byte{5, 5}, []byte{7}, []byte{7, 7})
+	it, err = tx.Range(PlainTable, []byte{2}, []byte{7})
+	requirePairs(t, it, err, []byte{3}, []byte{3, 3}, []byte{5}, []byte{5, 5})
+	it, err = tx.RangeAscend(PlainTable, nil, nil, 2)
+	requirePairs(t, it, err, []byte{1}, []byte{1, 1}, []byte{3}, []byte{3, 3})
+	it, err = tx.RangeAscend(PlainTable, []byte{8}, nil, -1)
+	requirePairs(t, it, err)
+	it, err = tx.Prefix(PlainTable, []byte{5})
+	requirePairs(t, it, err, []byte{5}, []byte{5, 5})
+
+	it, err = tx.Range(DupSortTable, []byte{3}, nil)
+	requirePairs(t, it, err, []byte{3}, []byte{1}, []byte{5}, []byte{2}, []byte{5}, []byte{4})
+
+	it, err = tx.RangeDupSort(DupSortTable, []byte{1}, nil, nil, order.Asc, -1)
+	requirePairs(t, it, err, []byte{1}, []byte{1}, []byte{1}, []byte{3}, []byte{1}, []byte{5})
+	it, err = tx.RangeDupSort(DupSortTable, []byte{1}, []byte{2}, []byte{5}, order.Asc, -1)
+	requirePairs(t, it, err, []byte{1}, []byte{3})
+	it, err = tx.RangeDupSort(DupSortTable, []byte{1}, nil, nil, order.Asc, 1)
+	requirePairs(t, it, err, []byte{1}, []byte{1})
+	it, err = tx.RangeDupSort(DupSortTable, []byte{2}, nil, nil, order.Asc, -1)
+	requirePairs(t, it, err)
+}
+
+func testStream(t *testing.T, tx kv.Tx) {
+	it, err := tx.Stream(PlainTable, nil, nil)
+	requirePairs(t, it, err, []byte{1}, []byte{1, 1}, []byte{3}, []byte{3, 3}, []byte{5}, []byte{5, 5}, []byte{7}, []byte{7, 7})
+	it, err = tx.StreamAscend(PlainTable, []byte{2}, nil, 2)
+	requirePairs(t, it, err, []byte{3}, []byte{3, 3}, []byte{5}, []byte{5, 5})
+	it, err = tx.StreamDescend(PlainTable, []byte{6}, []byte{1}, -1)
+	requirePairs(t, it, err, []byte{5}, []byte{5, 5}, []byte{3}, []byte{3, 3})
+	it, err = tx.StreamDescend(DupSortTable, nil, nil, 3)
+	requirePairs(t, it, err, []byte{5}, []byte{4}, []byte{5}, []byte{2}, []byte{3}, []byte{1})
+
+	// consumer can stop stream in the middle
+	it, err = tx.Stream(DupSortTable, nil, nil)
+	require.NoError(t, err)
+	require.True(t, it.HasNext())
+	requireKV(t, []byte{1}, []byte{1})(it.Next())
+	if casted, ok := it.(kv.Closer); ok {
+		casted.Close()
+	}
+}
+
+func testReverse(t *testing.T, tx kv.Tx) {
+	c, err := tx.Cursor(PlainTable)
+	require.NoError(t, err)
+	defer c.Close()
+	requireKV(t, []byte{7}, []byte{7, 7})(c.Last())
+	requireKV(t, []byte{5}, []byte{5, 5})(c.Prev())
+	requireKV(t, []byte{3}, []byte{3, 3})(c.Prev())
+	requireKV(t, []byte{1}, []byte{1, 1})(c.Prev())
+	requireKV(t, nil, nil)(c.Prev())
+	requireKV(t, []byte{5}, []byte{5, 5})(c.Seek([]byte{4}))
+	requireKV(t, []byte{3}, []byte{3, 3})(c.Prev())
+	requireKV(t, []byte{5}, []byte{5, 5})(c.Next())
+
+	dc, err := tx.CursorDupSort(DupSortTable)
+	require.NoError(t, err)
+	defer dc.Close()
+	requireKV(t, []byte{5}, []byte{4})(dc.Last())
+	requireKV(t, []byte{5}, []byte{2})(dc.Prev())
+	requireKV(t, []byte{3}, []byte{1})(dc.Prev())
+	requireKV(t, []byte{1}, []byte{5})(dc.Prev())
+	requireKV(t, []byte{5}, []byte{2})(dc.SeekExact([]byte{5}))
+	requireKV(t, []byte{3}, []byte{1})(dc.PrevNoDup())
+	requireKV(t, []byte{1}, []byte{5})(dc.PrevNoDup())
+	requireKV(t, nil, nil)(dc.PrevNoDup())
+	requireKV(t, []byte{1}, []byte{5})(dc.SeekBothExact([]byte{1}, []byte{5}))
+	requireKV(t, []byte{1}, []byte{3})(dc.PrevDup())
+	requireKV(t, []byte{1}, []byte{1})(dc.PrevDup())
+	requireKV(t, nil, nil)(dc.PrevDup())
+
+	ac, err := tx.Cursor(AutoDupTable)
+	require.NoError(t, err)
+	defer ac.Close()
+	requireKV(t, acc2, []byte{0xd})(ac.Last())
+	requireKV(t, storage2, []byte{0xc})(ac.Prev())
+	requireKV(t, storage1, []byte{0xb})(ac.Prev())
+	requireKV(t, acc1, []byte{0xa})(ac.Prev())
+
+	it, err := tx.RangeDescend(PlainTable, nil, nil, -1)
+	requirePairs(t, it, err, []byte{7}, []byte{7, 7}, []byte{5}, []byte{5, 5}, []byte{3}, []byte{3, 3}, []byte{1}, []byte{1, 1})
+	it, err = tx.RangeDescend(PlainTable, []byte{6}, []byte{1}, -1)
+	requirePairs(t, it, err, []byte{5}, []byte{5, 5}, []byte{3}, []byte{3, 3})
+	it, err = tx.RangeDescend(PlainTable, []byte{5}, nil, 2)
+	requirePairs(t, it, err, []byte{5}, []byte{5, 5}, []byte{3}, []byte{3, 3})
+
+	it, err = tx.RangeDupSort(DupSortTable, []byte{1}, nil, nil, order.Desc, -1)
+	requirePairs(t, it, err, []byte{1}, []byte{5}, []byte{1}, []byte{3}, []byte{1}, []byte{1})
+	it, err = tx.RangeDupSort(DupSortTable, []byte{1}, []byte{4}, []byte{1}, order.Desc, -1)
+	requirePairs(t, it, err, []byte{1}, []byte{3})
+	it, err = tx.RangeDupSort(DupSortTable, []byte{1}, nil, nil, order.Desc, 2)
+	requirePairs(t, it, err, []byte{1}, []byte{5}, []byte{1}, []byte{3})
+}
+
+func testSequence(t *testing.T, tx kv.Tx) {
+	seq, err := tx.ReadSequence(PlainTable)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), seq)
+	seq, err = tx.ReadSequence(DupSortTable)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), seq)
+}
+
+func testSize(t *testing.T, tx kv.Tx) {
+	dbSize, err := tx.DBSize()
+	require.NoError(t, err)
+	require.Greater(t, dbSize, uint64(0))
+	tableSize, err := tx.BucketSize(AutoDupTable)
+	require.NoError(t, err)
+	require.Greater(t, tableSize, uint64(0))
+	require.GreaterOrEqual(t, dbSize, tableSize)
+}
+
+func testPut(t *testing.T, tx kv.RwTx) {
+	require.NoError(t, tx.Put(PlainTable, []byte{4}, []byte{4, 4}))
+	require.NoError(t, tx.Put(PlainTable, []byte{3}, []byte{9}))
+	requireV(t, []byte{9})(tx.GetOne(PlainTable, []byte{3}))
+	it, err := tx.Range(PlainTable, []byte{2}, []byte{6})
+	requirePairs(t, it, err, []byte{3}, []byte{9}, []byte{4}, []byte{4, 4}, []byte{5}, []byte{5, 5})
+
+	require.NoError(t, tx.Put(DupSortTable, []byte{1}, []byte{2}))
+	require.NoError(t, tx.Put(DupSortTable, []byte{2}, []byte{1}))
+	it, err = tx.Range(DupSortTable, nil, []byte{3})
+	requirePairs(t, it, err, []byte{1}, []byte{1}, []byte{1}, []byte{2}, []byte{1}, []byte{3}, []byte{1}, []byte{5}, []byte{2}, []byte{1})
+	c, err := tx.CursorDupSort(DupSortTable)
+	require.NoError(t, err)
+	defer c.Close()
+	requireV(t, []byte{2})(c.SeekBothRange([]byte{1}, []byte{2}))
+	requireKV(t, []byte{1}, []byte{3})(c.NextDup())
+	cnt, err := c.CountDuplicates()
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), cnt)
+
+	require.NoError(t, tx.Put(AutoDupTable, storage3, []byte{0xe}))
+	require.NoError(t, tx.Put(AutoDupTable, storage1, []byte{0xf}))
+	it, err = tx.Range(AutoDupTable, nil, nil)
+	requirePairs(t, it, err, acc1, []byte{0xa}, storage1, []byte{0xf}, storage2, []byte{0xc}, storage3, []byte{0xe}, acc2, []byte{0xd})
+}
+
+func testDelete(t *testing.T, tx kv.RwTx) {
+	require.NoError(t, tx.Delete(PlainTable, []byte{3}))
+	require.NoError(t, tx.Delete(PlainTable, []byte{4})) // not existing key
+	requireV(t, nil)(tx.GetOne(PlainTable, []byte{3}))
+	has, err := tx.Has(PlainTable, []byte{3})
+	require.NoError(t, err)
+	require.False(t, has)
+	it, err := tx.Range(PlainTable, nil, nil)
+	requirePairs(t, it, err, []byte{1}, []byte{1, 1}, []byte{5}, []byte{5, 5}, []byte{7}, []byte{7, 7})
+	c, err := tx.Cursor(PlainTable)
+	require.NoError(t, err)
+	defer c.Close()
+	requireKV(t, []byte{5}, []byte{5, 5})(c.Seek([]byte{2}))
+	requireKV(t, nil, nil)(c.SeekExact([]byte{3}))
+
+	require.NoError(t, tx.Put(PlainTable, []byte{3}, []byte{9})) // re-create after delete
+	requireV(t, []byte{9})(tx.GetOne(PlainTable, []byte{3}))
+
+	require.NoError(t, tx.Delete(DupSortTable, []byte{1})) // deletes all values of key
+	it, err = tx.Range(DupSortTable, nil, nil)
+	requirePairs(t, it, err, []byte{3}, []byte{1}, []byte{5}, []byte{2}, []byte{5}, []byte{4})
+	it, err = tx.RangeDupSort(DupSortTable, []byte{1}, nil, nil, order.Asc, -1)
+	requirePairs(t, it, err)
+
+	require.NoError(t, tx.Delete(AutoDupTable, storage1))
+	requireV(t, nil)(tx.GetOne(AutoDupTable, storage1))
+	it, err = tx.Range(AutoDupTable, nil, nil)
+	requirePairs(t, it, err, acc1, []byte{0xa}, storage2, []byte{0xc}, acc2, []byte{0xd})
+}
+
+func testClearBucket(t *testing.T, tx kv.RwTx) {
+	require.NoError(t, tx.ClearBucket(PlainTable))
+	requireV(t, nil)(tx.GetOne(PlainTable, []byte{1}))
+	it, err := tx.Range(PlainTable, nil, nil)
+	requirePairs(t, it, err)
+	c, err := tx.Cursor(PlainTable)
+	require.NoError(t, err)
+	defer c.Close()
+	requireKV(t, nil, nil)(c.First())
+
+	require.NoError(t, tx.Put(PlainTable, []byte{2}, []byte{2, 2}))
+	requireKV(t, []byte{2}, []byte{2, 2})(c.First())
+	requireKV(t, nil, nil)(c.Next())
+	it, err = tx.Range(PlainTable, nil, nil)
+	requirePairs(t, it, err, []byte{2}, []byte{2, 2})
+
+	require.NoError(t, tx.ClearBucket(DupSortTable))
+	it, err = tx.RangeDupSort(DupSortTable, []byte{1}, nil, nil, order.Asc, -1)
+	requirePairs(t, it, err)
+
+	// other tables are not affected
+	requireV(t, []byte{0xa})(tx.GetOne(AutoDupTable, acc1))
+}
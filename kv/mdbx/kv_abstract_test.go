@@ -18,6 +18,7 @@ package mdbx_test
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"net"
 	"runtime"
@@ -26,8 +27,11 @@ import (
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/iter"
+	"github.com/ledgerwatch/erigon-lib/kv/kvtest"
 	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon-lib/kv/order"
 	"github.com/ledgerwatch/erigon-lib/kv/remotedb"
 	"github.com/ledgerwatch/erigon-lib/kv/remotedbserver"
 	"github.com/ledgerwatch/log/v3"
@@ -332,19 +336,303 @@ func TestRemoteKvRange(t *testing.T) {
 	require.NoError(err)
 }
 
+func TestKvSpec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fix me on win please")
+	}
+	logger := log.New()
+	ctx := context.Background()
+	filled := func(tb testing.TB, db kv.RwDB, fill func(tx kv.RwTx)) {
+		tb.Helper()
+		require.NoError(tb, db.Update(ctx, func(tx kv.RwTx) error {
+			fill(tx)
+			return nil
+		}))
+	}
+
+	t.Run("mdbx", func(t *testing.T) {
+		kvtest.Suite{OpenRw: func(tb testing.TB, fill func(tx kv.RwTx)) kv.RwTx {
+			db := memdb.NewTestDB(tb)
+			filled(tb, db, fill)
+			return memdb.BeginRw(tb, db)
+		}}.Run(t)
+	})
+	t.Run("mdbx_temporary", func(t *testing.T) {
+		kvtest.Suite{OpenRw: func(tb testing.TB, fill func(tx kv.RwTx)) kv.RwTx {
+			db, err := mdbx.NewTemporaryMdbx(tb.TempDir())
+			require.NoError(tb, err)
+			tb.Cleanup(db.Close)
+			filled(tb, db, fill)
+			return memdb.BeginRw(tb, db)
+		}}.Run(t)
+	})
+	t.Run("remotedb", func(t *testing.T) {
+		kvtest.Suite{Open: func(tb testing.TB, fill func(tx kv.RwTx)) kv.Tx {
+			db := memdb.NewTestDB(tb)
+			filled(tb, db, fill)
+			return memdb.BeginRo(tb, newRemoteDB(t, logger, db))
+		}}.Run(t)
+	})
+	t.Run("remotedb_rw", func(t *testing.T) {
+		kvtest.Suite{OpenRw: func(tb testing.TB, fill func(tx kv.RwTx)) kv.RwTx {
+			db := memdb.NewTestDB(tb)
+			filled(tb, db, fill)
+			return memdb.BeginRw(tb, newRemoteDB(t, logger, db, kvtest.PlainTable, kvtest.DupSortTable, kvtest.AutoDupTable))
+		}}.Run(t)
+	})
+}
+
+func TestRemoteKvCommit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fix me on win please")
+	}
+	require, ctx, logger := require.New(t), context.Background(), log.New()
+	writeDB := memdb.NewTestDB(t)
+	require.NoError(writeDB.Update(ctx, func(tx kv.RwTx) error {
+		require.NoError(tx.Put(kv.HeaderNumber, []byte{1}, []byte{1}))
+		require.NoError(tx.Put(kv.HeaderNumber, []byte{2}, []byte{2}))
+		return nil
+	}))
+	db := newRemoteDB(t, logger, writeDB, kv.HeaderNumber, kv.AccountChangeSet)
+
+	require.NoError(db.Update(ctx, func(tx kv.RwTx) error {
+		require.NoError(tx.Put(kv.HeaderNumber, []byte{3}, []byte{3}))
+		require.NoError(tx.Delete(kv.HeaderNumber, []byte{1}))
+		require.NoError(tx.Put(kv.AccountChangeSet, []byte{1}, []byte{1}))
+		require.NoError(tx.Put(kv.AccountChangeSet, []byte{1}, []byte{2}))
+		// read own writes
+		v, err := tx.GetOne(kv.HeaderNumber, []byte{3})
+		require.NoError(err)
+		require.Equal([]byte{3}, v)
+		has, err := tx.Has(kv.HeaderNumber, []byte{1})
+		require.NoError(err)
+		require.False(has)
+		return nil
+	}))
+	require.NoError(writeDB.View(ctx, func(tx kv.Tx) error {
+		it, err := tx.Range(kv.HeaderNumber, nil, nil)
+		require.NoError(err)
+		keys, _, err := iter.ToKVArray(it)
+		require.NoError(err)
+		require.Equal([][]byte{{2}, {3}}, keys)
+		it, err = tx.RangeDupSort(kv.AccountChangeSet, []byte{1}, nil, nil, order.Asc, -1)
+		require.NoError(err)
+		_, vals, err := iter.ToKVArray(it)
+		require.NoError(err)
+		require.Equal([][]byte{{1}, {2}}, vals)
+		return nil
+	}))
+
+	// table is not in allow-list: nothing written
+	err := db.Update(ctx, func(tx kv.RwTx) error {
+		require.NoError(tx.Put(kv.HeaderNumber, []byte{4}, []byte{4}))
+		return tx.Put(kv.Code, []byte{1}, []byte{1})
+	})
+	require.ErrorContains(err, "not writable")
+	require.NoError(writeDB.View(ctx, func(tx kv.Tx) error {
+		has, err := tx.Has(kv.HeaderNumber, []byte{4})
+		require.NoError(err)
+		require.False(has)
+		return nil
+	}))
+
+	// db modified after remote tx started
+	tx, err := db.BeginRw(ctx)
+	require.NoError(err)
+	defer tx.Rollback()
+	require.NoError(tx.Put(kv.HeaderNumber, []byte{5}, []byte{5}))
+	require.NoError(writeDB.Update(ctx, func(tx kv.RwTx) error {
+		return tx.Put(kv.HeaderNumber, []byte{6}, []byte{6})
+	}))
+	require.ErrorIs(tx.Commit(), remotedb.ErrConflict)
+	require.NoError(writeDB.View(ctx, func(tx kv.Tx) error {
+		has, err := tx.Has(kv.HeaderNumber, []byte{5})
+		require.NoError(err)
+		require.False(has)
+		return nil
+	}))
+}
+
+// temporalDB - exposes plain tables as Domains/Histories/InvertedIndices: enough to test transport of temporal methods
+type temporalDB struct {
+	kv.RwDB
+	historyNexts int // amount of pairs read from HistoryRange iterators: server must seek, not skip, to next page
+}
+type temporalTx struct {
+	kv.Tx
+	historyNexts *int
+}
+
+func (db *temporalDB) BeginRo(ctx context.Context) (kv.Tx, error) {
+	tx, err := db.RwDB.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &temporalTx{Tx: tx, historyNexts: &db.historyNexts}, nil
+}
+
+// seekableKV - iterator of plain table which supports iter.Seekable (as iterators of History do)
+type seekableKV struct {
+	iter.KV
+	tx    kv.Tx
+	table string
+	nexts *int
+	err   error
+}
+
+func (it *seekableKV) HasNext() bool { return it.err != nil || it.KV.HasNext() }
+func (it *seekableKV) Next() ([]byte, []byte, error) {
+	if it.err != nil {
+		return nil, nil, it.err
+	}
+	*it.nexts++
+	return it.KV.Next()
+}
+func (it *seekableKV) Seek(k []byte) { it.KV, it.err = it.tx.RangeAscend(it.table, k, nil, -1) }
+func (tx *temporalTx) DomainGet(name kv.Domain, k, k2 []byte) (v []byte, ok bool, err error) {
+	v, err = tx.GetOne(string(name), k)
+	return v, v != nil, err
+}
+func (tx *temporalTx) DomainGetAsOf(name kv.Domain, k, k2 []byte, ts uint64) (v []byte, ok bool, err error) {
+	return tx.DomainGet(name, k, k2)
+}
+func (tx *temporalTx) HistoryGet(name kv.History, k []byte, ts uint64) (v []byte, ok bool, err error) {
+	return tx.DomainGet(kv.Domain(name), k, nil)
+}
+func (tx *temporalTx) IndexRange(name kv.InvertedIdx, k []byte, fromTs, toTs int, asc order.By, limit int) (iter.U64, error) {
+	var res []uint64
+	for i := fromTs; i < toTs && limit != 0; i, limit = i+1, limit-1 {
+		res = append(res, uint64(i))
+	}
+	if asc {
+		return iter.Array(res), nil
+	}
+	return iter.ReverseArray(res), nil
+}
+func (tx *temporalTx) HistoryRange(name kv.History, fromTs, toTs int, asc order.By, limit int) (iter.KV, error) {
+	it, err := tx.DomainRange(kv.Domain(name), nil, nil, 0, asc, limit)
+	if err != nil || !asc {
+		return it, err
+	}
+	return &seekableKV{KV: it, tx: tx.Tx, table: string(name), nexts: tx.historyNexts}, nil
+}
+func (tx *temporalTx) DomainRange(name kv.Domain, fromKey, toKey []byte, ts uint64, asc order.By, limit int) (iter.KV, error) {
+	if asc {
+		return tx.RangeAscend(string(name), fromKey, toKey, limit)
+	}
+	return tx.RangeDescend(string(name), fromKey, toKey, limit)
+}
+
+func TestRemoteKvTemporal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fix me on win please")
+	}
+	require, ctx, logger := require.New(t), context.Background(), log.New()
+	writeDB := memdb.NewTestDB(t)
+	// more than 1 page of results
+	n := remotedbserver.PageSizeLimit + 10
+	var keys [][]byte
+	require.NoError(writeDB.Update(ctx, func(tx kv.RwTx) error {
+		for i := 0; i < n; i++ {
+			k := make([]byte, 4)
+			binary.BigEndian.PutUint32(k, uint32(i))
+			keys = append(keys, k)
+			require.NoError(tx.Put(kv.HeaderNumber, k, k))
+		}
+		return nil
+	}))
+	tdb := &temporalDB{RwDB: writeDB}
+	db := newRemoteDB(t, logger, tdb)
+
+	reversed := make([][]byte, 0, n)
+	for i := len(keys) - 1; i >= 0; i-- {
+		reversed = append(reversed, keys[i])
+	}
+
+	require.NoError(db.View(ctx, func(tx kv.Tx) error {
+		ttx := tx.(kv.TemporalTx)
+
+		v, ok, err := ttx.DomainGet(kv.Domain(kv.HeaderNumber), keys[1], nil)
+		require.NoError(err)
+		require.True(ok)
+		require.Equal(keys[1], v)
+
+		it, err := ttx.DomainRange(kv.Domain(kv.HeaderNumber), nil, nil, 0, order.Asc, -1)
+		require.NoError(err)
+		k, vals, err := iter.ToKVArray(it)
+		require.NoError(err)
+		require.Equal(keys, k)
+		require.Equal(keys, vals)
+
+		it, err = ttx.DomainRange(kv.Domain(kv.HeaderNumber), keys[5], nil, 0, order.Asc, remotedbserver.PageSizeLimit+1)
+		require.NoError(err)
+		k, _, err = iter.ToKVArray(it)
+		require.NoError(err)
+		require.Equal(keys[5:5+remotedbserver.PageSizeLimit+1], k)
+
+		it, err = ttx.DomainRange(kv.Domain(kv.HeaderNumber), nil, nil, 0, order.Desc, -1)
+		require.NoError(err)
+		k, _, err = iter.ToKVArray(it)
+		require.NoError(err)
+		require.Equal(reversed, k)
+
+		it, err = ttx.DomainRange(kv.Domain(kv.HeaderNumber), nil, nil, 0, order.Asc, 0) // 0 - no limit
+		require.NoError(err)
+		cnt, err := iter.CountKV(it)
+		require.NoError(err)
+		require.Equal(n, cnt)
+
+		it, err = ttx.HistoryRange(kv.History(kv.HeaderNumber), 0, n, order.Asc, -1)
+		require.NoError(err)
+		k, _, err = iter.ToKVArray(it)
+		require.NoError(err)
+		require.Equal(keys, k)
+		require.Less(tdb.historyNexts, n+n/2) // next page doesn't re-read previous one
+
+		it, err = ttx.HistoryRange(kv.History(kv.HeaderNumber), 0, n, order.Desc, remotedbserver.PageSizeLimit+1)
+		require.NoError(err)
+		k, _, err = iter.ToKVArray(it)
+		require.NoError(err)
+		require.Equal(reversed[:remotedbserver.PageSizeLimit+1], k)
+
+		ts, err := ttx.IndexRange(kv.InvertedIdx(kv.HeaderNumber), keys[0], 0, n, order.Asc, -1)
+		require.NoError(err)
+		cnt, err = iter.Count[uint64](ts)
+		require.NoError(err)
+		require.Equal(n, cnt)
+		return nil
+	}))
+}
+
 func setupDatabases(t *testing.T, logger log.Logger, f mdbx.TableCfgFunc) (writeDBs []kv.RwDB, readDBs []kv.RwDB) {
 	t.Helper()
-	ctx := context.Background()
 	writeDBs = []kv.RwDB{
 		mdbx.NewMDBX(logger).InMem("").WithTableCfg(f).MustOpen(),
 		mdbx.NewMDBX(logger).InMem("").WithTableCfg(f).MustOpen(), // for remote db
 	}
+	readDBs = []kv.RwDB{
+		writeDBs[0],
+		writeDBs[1],
+		newRemoteDB(t, logger, writeDBs[1]),
+	}
+
+	t.Cleanup(func() {
+		for _, db := range writeDBs {
+			db.Close()
+		}
+	})
+	return writeDBs, readDBs
+}
 
+// newRemoteDB - serves `db` by KvServer over in-memory grpc connection and returns remotedb client of it
+func newRemoteDB(t *testing.T, logger log.Logger, db kv.RwDB, writableTables ...string) kv.RwDB {
+	t.Helper()
+	ctx := context.Background()
 	conn := bufconn.Listen(1024 * 1024)
 
 	grpcServer := grpc.NewServer()
 	f2 := func() {
-		remote.RegisterKVServer(grpcServer, remotedbserver.NewKvServer(ctx, writeDBs[1], nil, nil, logger))
+		remote.RegisterKVServer(grpcServer, remotedbserver.NewKvServer(ctx, db, nil, nil, logger).WithWritableTables(writableTables...))
 		if err := grpcServer.Serve(conn); err != nil {
 			logger.Error("private RPC server fail", "err", err)
 		}
@@ -355,29 +643,15 @@ func setupDatabases(t *testing.T, logger log.Logger, f mdbx.TableCfgFunc) (write
 	assert.NoError(t, err)
 	rdb, err := remotedb.NewRemote(v, logger, remote.NewKVClient(cc)).Open()
 	assert.NoError(t, err)
-	readDBs = []kv.RwDB{
-		writeDBs[0],
-		writeDBs[1],
-		rdb,
-	}
 
 	t.Cleanup(func() {
+		rdb.Close()
 		grpcServer.Stop()
-
 		if err := conn.Close(); err != nil {
 			panic(err)
 		}
-
-		for _, db := range readDBs {
-			db.Close()
-		}
-
-		for _, db := range writeDBs {
-			db.Close()
-		}
-
 	})
-	return writeDBs, readDBs
+	return rdb
 }
 
 func testMultiCursor(t *testing.T, db kv.RwDB, bucket1, bucket2 string) {
@@ -1421,7 +1421,7 @@ func (c *MdbxCursor) SeekExact(key []byte) ([]byte, []byte, error) {
 		if !bytes.Equal(key[to:], v[:from-to]) {
 			return nil, nil, nil
 		}
-		return key[:to], v[from-to:], nil
+		return key, v[from-to:], nil
 	}
 
 	k, v, err := c.set(key)
@@ -1689,6 +1689,17 @@ func (tx *MdbxTx) RangeDescend(table string, fromPrefix, toPrefix []byte, limit
 	return tx.rangeOrderLimit(table, fromPrefix, toPrefix, order.Desc, limit)
 }
 
+// Stream* - local db has no network round-trips, so it's same as Range*
+func (tx *MdbxTx) Stream(table string, fromPrefix, toPrefix []byte) (iter.KV, error) {
+	return tx.Range(table, fromPrefix, toPrefix)
+}
+func (tx *MdbxTx) StreamAscend(table string, fromPrefix, toPrefix []byte, limit int) (iter.KV, error) {
+	return tx.RangeAscend(table, fromPrefix, toPrefix, limit)
+}
+func (tx *MdbxTx) StreamDescend(table string, fromPrefix, toPrefix []byte, limit int) (iter.KV, error) {
+	return tx.RangeDescend(table, fromPrefix, toPrefix, limit)
+}
+
 type cursor2iter struct {
 	c                                  kv.Cursor
 	fromPrefix, toPrefix, nextK, nextV []byte
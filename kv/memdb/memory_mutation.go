@@ -91,8 +91,9 @@ func (m *MemoryMutation) isEntryDeleted(table string, key []byte) bool {
 	return ok
 }
 
+// DBSize - size of underlying db, in-memory changes are not counted
 func (m *MemoryMutation) DBSize() (uint64, error) {
-	panic("not implemented")
+	return m.db.DBSize()
 }
 
 func initSequences(db kv.Tx, memTx kv.RwTx) error {
@@ -168,7 +169,11 @@ func (m *MemoryMutation) GetOne(table string, key []byte) ([]byte, error) {
 }
 
 func (m *MemoryMutation) Last(table string) ([]byte, []byte, error) {
-	panic("not implemented. (MemoryMutation.Last)")
+	c, err := m.statelessCursor(table)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.Last()
 }
 
 // Has return whether a key is present in a certain table.
@@ -221,30 +226,33 @@ func (m *MemoryMutation) ForEach(bucket string, fromPrefix []byte, walker func(k
 func (m *MemoryMutation) Prefix(table string, prefix []byte) (iter.KV, error) {
 	nextPrefix, ok := kv.NextSubtree(prefix)
 	if !ok {
-		return m.Stream(table, prefix, nil)
+		return m.Range(table, prefix, nil)
 	}
-	return m.Stream(table, prefix, nextPrefix)
+	return m.Range(table, prefix, nextPrefix)
 }
 func (m *MemoryMutation) Stream(table string, fromPrefix, toPrefix []byte) (iter.KV, error) {
-	panic("please implement me")
+	return m.Range(table, fromPrefix, toPrefix)
 }
 func (m *MemoryMutation) StreamAscend(table string, fromPrefix, toPrefix []byte, limit int) (iter.KV, error) {
-	panic("please implement me")
+	return m.RangeAscend(table, fromPrefix, toPrefix, limit)
 }
 func (m *MemoryMutation) StreamDescend(table string, fromPrefix, toPrefix []byte, limit int) (iter.KV, error) {
-	panic("please implement me")
+	return m.RangeDescend(table, fromPrefix, toPrefix, limit)
 }
 func (m *MemoryMutation) Range(table string, fromPrefix, toPrefix []byte) (iter.KV, error) {
-	panic("please implement me")
+	return m.RangeAscend(table, fromPrefix, toPrefix, -1)
 }
 func (m *MemoryMutation) RangeAscend(table string, fromPrefix, toPrefix []byte, limit int) (iter.KV, error) {
-	panic("please implement me")
+	s := &cursor2iter{fromPrefix: fromPrefix, toPrefix: toPrefix, orderAscend: order.Asc, limit: int64(limit)}
+	return s.init(table, m)
 }
 func (m *MemoryMutation) RangeDescend(table string, fromPrefix, toPrefix []byte, limit int) (iter.KV, error) {
-	panic("please implement me")
+	s := &cursor2iter{fromPrefix: fromPrefix, toPrefix: toPrefix, orderAscend: order.Desc, limit: int64(limit)}
+	return s.init(table, m)
 }
 func (m *MemoryMutation) RangeDupSort(table string, key []byte, fromPrefix, toPrefix []byte, asc order.By, limit int) (iter.KV, error) {
-	panic("please implement me")
+	s := &cursorDup2iter{key: key, fromPrefix: fromPrefix, toPrefix: toPrefix, orderAscend: bool(asc), limit: int64(limit)}
+	return s.init(table, m)
 }
 
 func (m *MemoryMutation) ForPrefix(bucket string, prefix []byte, walker func(k, v []byte) error) error {
@@ -291,8 +299,9 @@ func (m *MemoryMutation) Close() {
 	m.Rollback()
 }
 
+// BucketSize - size of table in underlying db, in-memory changes are not counted
 func (m *MemoryMutation) BucketSize(bucket string) (uint64, error) {
-	return m.memTx.BucketSize(bucket)
+	return m.db.BucketSize(bucket)
 }
 
 func (m *MemoryMutation) DropBucket(bucket string) error {
@@ -283,36 +283,20 @@ func (m *memoryMutationCursor) Seek(seek []byte) ([]byte, []byte, error) {
 	return m.resolveCursorPriority(memKey, memValue, dbKey, dbValue, Normal)
 }
 
-// Seek move pointer to a key at a certain position.
+// SeekExact move pointer to a key at a certain position.
 func (m *memoryMutationCursor) SeekExact(seek []byte) ([]byte, []byte, error) {
-	memKey, memValue, err := m.memCursor.SeekExact(seek)
-	if err != nil || m.isTableCleared() {
-		return memKey, memValue, err
-	}
-
-	if memKey != nil {
-		m.currentMemEntry.key = memKey
-		m.currentMemEntry.value = memValue
-		m.currentDbEntry.key, m.currentDbEntry.value, err = m.cursor.Seek(seek)
-		m.isPrevFromDb = false
-		m.currentPair = cursorEntry{memKey, memValue}
-		return memKey, memValue, err
+	if m.isTableCleared() {
+		return m.memCursor.SeekExact(seek)
 	}
-
-	dbKey, dbValue, err := m.cursor.SeekExact(seek)
+	// Seek does merge values of db and memory, then for DupSort tables it returns smallest value of the key
+	k, v, err := m.Seek(seek)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	if dbKey != nil && !m.mutation.isEntryDeleted(m.table, seek) {
-		m.currentDbEntry.key = dbKey
-		m.currentDbEntry.value = dbValue
-		m.currentMemEntry.key, m.currentMemEntry.value, err = m.memCursor.Seek(seek)
-		m.isPrevFromDb = true
-		m.currentPair = cursorEntry{dbKey, dbValue}
-		return dbKey, dbValue, err
+	if !bytes.Equal(k, seek) {
+		return nil, nil, nil
 	}
-	return nil, nil, nil
+	return k, v, nil
 }
 
 func (m *memoryMutationCursor) Put(k, v []byte) error {
@@ -392,67 +376,180 @@ func (m *memoryMutationCursor) Last() ([]byte, []byte, error) {
 		return memKey, memValue, err
 	}
 
-	dbKey, dbValue, err := m.cursor.Last()
+	dbKey, dbValue, err := m.skipDeletedBackward(m.cursor.Last())
 	if err != nil {
 		return nil, nil, err
 	}
+	return m.resolveBackward(memKey, memValue, dbKey, dbValue)
+}
+
+// Prev - moves cursor to the previous entry: last value of previous key or previous value of same key (for DupSort tables)
+func (m *memoryMutationCursor) Prev() ([]byte, []byte, error) {
+	if m.isTableCleared() {
+		return m.memCursor.Prev()
+	}
+	if m.currentPair.key == nil {
+		return m.Last()
+	}
+	k, v := common.Copy(m.currentPair.key), common.Copy(m.currentPair.value)
+	dupSort := isTablePurelyDupsort(m.table)
 
-	dbKey, dbValue, err = m.skipIntersection(memKey, memValue, dbKey, dbValue, Normal)
+	memKey, memValue, err := prevBefore(m.memCursor, k, v, dupSort)
+	if err != nil {
+		return nil, nil, err
+	}
+	dbKey, dbValue, err := m.skipDeletedBackward(prevBefore(m.cursor, k, v, dupSort))
 	if err != nil {
 		return nil, nil, err
 	}
+	if memKey == nil && dbKey == nil {
+		return nil, nil, m.stay(k, v)
+	}
+	return m.resolveBackward(memKey, memValue, dbKey, dbValue)
+}
 
-	m.currentDbEntry = cursorEntry{dbKey, dbValue}
-	m.currentMemEntry = cursorEntry{memKey, memValue}
+// PrevDup - moves cursor to the previous value of current key
+func (m *memoryMutationCursor) PrevDup() ([]byte, []byte, error) {
+	if m.isTableCleared() {
+		return m.memCursor.PrevDup()
+	}
+	if m.currentPair.key == nil || !isTablePurelyDupsort(m.table) {
+		return nil, nil, nil
+	}
+	k, v := common.Copy(m.currentPair.key), common.Copy(m.currentPair.value)
 
-	// Basic checks
-	if dbKey != nil && m.isEntryDeleted(dbKey, dbValue, Normal) {
-		m.currentDbEntry = cursorEntry{}
-		m.isPrevFromDb = false
-		return memKey, memValue, nil
+	var memKey, dbKey []byte
+	memValue, err := prevDupBefore(m.memCursor, k, v)
+	if err != nil {
+		return nil, nil, err
+	}
+	if memValue != nil {
+		memKey = k
+	}
+	var dbValue []byte
+	if !m.mutation.isEntryDeleted(m.table, k) {
+		if dbValue, err = prevDupBefore(m.cursor, k, v); err != nil {
+			return nil, nil, err
+		}
+		if dbValue != nil {
+			dbKey = k
+		}
+	}
+	if memKey == nil && dbKey == nil {
+		return nil, nil, m.stay(k, v)
 	}
+	return m.resolveBackward(memKey, memValue, dbKey, dbValue)
+}
 
-	if dbValue == nil {
-		m.isPrevFromDb = false
-		return memKey, memValue, nil
+// PrevNoDup - moves cursor to the last value of previous key
+func (m *memoryMutationCursor) PrevNoDup() ([]byte, []byte, error) {
+	if m.isTableCleared() {
+		return m.memCursor.PrevNoDup()
 	}
+	if m.currentPair.key == nil {
+		return m.Last()
+	}
+	k, v := common.Copy(m.currentPair.key), common.Copy(m.currentPair.value)
 
-	if memValue == nil {
-		m.isPrevFromDb = true
-		return dbKey, dbValue, nil
+	memKey, memValue, err := prevBefore(m.memCursor, k, nil, false)
+	if err != nil {
+		return nil, nil, err
 	}
-	// Check which one is last and return it
-	keyCompare := bytes.Compare(memKey, dbKey)
-	if keyCompare == 0 {
-		if bytes.Compare(memValue, dbValue) > 0 {
-			m.currentDbEntry = cursorEntry{}
-			m.isPrevFromDb = false
-			return memKey, memValue, nil
-		}
-		m.currentMemEntry = cursorEntry{}
-		m.isPrevFromDb = true
-		return dbKey, dbValue, nil
+	dbKey, dbValue, err := m.skipDeletedBackward(prevBefore(m.cursor, k, nil, false))
+	if err != nil {
+		return nil, nil, err
 	}
+	if memKey == nil && dbKey == nil {
+		return nil, nil, m.stay(k, v)
+	}
+	return m.resolveBackward(memKey, memValue, dbKey, dbValue)
+}
 
-	if keyCompare > 0 {
-		m.currentDbEntry = cursorEntry{}
-		m.isPrevFromDb = false
-		return memKey, memValue, nil
+// skipDeletedBackward - moves db cursor backward until it stops on not deleted entry
+func (m *memoryMutationCursor) skipDeletedBackward(k, v []byte, err error) ([]byte, []byte, error) {
+	for err == nil && k != nil && m.isEntryDeleted(k, v, Normal) {
+		k, v, err = m.cursor.Prev()
 	}
+	return k, v, err
+}
 
-	m.currentMemEntry = cursorEntry{}
-	m.isPrevFromDb = true
-	return dbKey, dbValue, nil
+// resolveBackward - picks the biggest entry of db and memory candidates (memory shadows db on equal entries),
+// then positions cursor on it - such way next forward and backward moves continue from it.
+func (m *memoryMutationCursor) resolveBackward(memKey, memValue, dbKey, dbValue []byte) ([]byte, []byte, error) {
+	var k, v []byte
+	switch {
+	case memKey == nil && dbKey == nil:
+		return nil, nil, nil
+	case memKey == nil:
+		k, v = dbKey, dbValue
+	case dbKey == nil:
+		k, v = memKey, memValue
+	default:
+		cmp := bytes.Compare(dbKey, memKey)
+		if cmp == 0 && isTablePurelyDupsort(m.table) {
+			cmp = bytes.Compare(dbValue, memValue)
+		}
+		if cmp > 0 {
+			k, v = dbKey, dbValue
+		} else {
+			k, v = memKey, memValue
+		}
+	}
+	k, v = common.Copy(k), common.Copy(v)
+	if err := m.stay(k, v); err != nil {
+		return nil, nil, err
+	}
+	return k, v, nil
 }
 
-func (m *memoryMutationCursor) Prev() ([]byte, []byte, error) {
-	panic("Prev is not implemented!")
+// stay - positions cursor exactly on given entry. Entry must exist.
+func (m *memoryMutationCursor) stay(k, v []byte) (err error) {
+	if isTablePurelyDupsort(m.table) {
+		_, err = m.SeekBothRange(k, v)
+		return err
+	}
+	_, _, err = m.Seek(k)
+	return err
 }
-func (m *memoryMutationCursor) PrevDup() ([]byte, []byte, error) {
-	panic("Prev is not implemented!")
+
+// prevBefore - moves cursor `c` to the biggest entry which is smaller than (k, v)
+func prevBefore(c kv.CursorDupSort, k, v []byte, dupSort bool) ([]byte, []byte, error) {
+	var next []byte
+	var err error
+	if dupSort {
+		if next, err = c.SeekBothRange(k, v); err != nil {
+			return nil, nil, err
+		}
+	}
+	if next == nil {
+		if next, _, err = c.Seek(k); err != nil {
+			return nil, nil, err
+		}
+		if dupSort && bytes.Equal(next, k) { // all values of `k` are smaller than `v`
+			last, err := c.LastDup()
+			return k, last, err
+		}
+	}
+	if next == nil {
+		return c.Last()
+	}
+	return c.Prev()
 }
-func (m *memoryMutationCursor) PrevNoDup() ([]byte, []byte, error) {
-	panic("Prev is not implemented!")
+
+// prevDupBefore - moves cursor `c` to the biggest value of key `k` which is smaller than `v`
+func prevDupBefore(c kv.CursorDupSort, k, v []byte) ([]byte, error) {
+	next, err := c.SeekBothRange(k, v)
+	if err != nil {
+		return nil, err
+	}
+	if next != nil {
+		_, prev, err := c.PrevDup()
+		return prev, err
+	}
+	if k, _, err = c.SeekExact(k); err != nil || k == nil {
+		return nil, err
+	}
+	return c.LastDup()
 }
 
 func (m *memoryMutationCursor) Close() {
@@ -468,8 +565,18 @@ func (m *memoryMutationCursor) Count() (uint64, error) {
 	panic("Not implemented")
 }
 
+// FirstDup - moves cursor to the first value of current key
 func (m *memoryMutationCursor) FirstDup() ([]byte, error) {
-	panic("Not implemented")
+	if m.isTableCleared() {
+		return m.memCursor.FirstDup()
+	}
+	if m.currentPair.key == nil {
+		return nil, nil
+	}
+	if !isTablePurelyDupsort(m.table) { // key has only 1 value
+		return common.Copy(m.currentPair.value), nil
+	}
+	return m.SeekBothRange(common.Copy(m.currentPair.key), nil)
 }
 
 func (m *memoryMutationCursor) NextNoDup() ([]byte, []byte, error) {
@@ -493,14 +600,76 @@ func (m *memoryMutationCursor) NextNoDup() ([]byte, []byte, error) {
 	return m.resolveCursorPriority(memK, memV, m.currentDbEntry.key, m.currentDbEntry.value, NoDup)
 }
 
+// LastDup - moves cursor to the last value of current key
 func (m *memoryMutationCursor) LastDup() ([]byte, error) {
-	panic("Not implemented")
+	if m.isTableCleared() {
+		return m.memCursor.LastDup()
+	}
+	if m.currentPair.key == nil {
+		return nil, nil
+	}
+	if !isTablePurelyDupsort(m.table) { // key has only 1 value
+		return common.Copy(m.currentPair.value), nil
+	}
+	k := common.Copy(m.currentPair.key)
+
+	var memKey, memValue, dbKey, dbValue []byte
+	memKey, _, err := m.memCursor.SeekExact(k)
+	if err != nil {
+		return nil, err
+	}
+	if memKey != nil {
+		if memValue, err = m.memCursor.LastDup(); err != nil {
+			return nil, err
+		}
+	}
+	if !m.mutation.isEntryDeleted(m.table, k) {
+		if dbKey, _, err = m.cursor.SeekExact(k); err != nil {
+			return nil, err
+		}
+		if dbKey != nil {
+			if dbValue, err = m.cursor.LastDup(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	_, v, err := m.resolveBackward(memKey, memValue, dbKey, dbValue)
+	return v, err
 }
 
+// CountDuplicates - amount of values of current key. Doesn't change cursor position.
 func (m *memoryMutationCursor) CountDuplicates() (uint64, error) {
-	panic("Not implemented")
+	if m.isTableCleared() {
+		return m.memCursor.CountDuplicates()
+	}
+	key, value := common.Copy(m.currentPair.key), common.Copy(m.currentPair.value)
+	if key == nil {
+		return 0, nil
+	}
+	var cnt uint64
+	v, err := m.FirstDup()
+	for ; v != nil; _, v, err = m.NextDup() {
+		if err != nil {
+			return 0, err
+		}
+		cnt++
+	}
+	if err != nil {
+		return 0, err
+	}
+	if _, err = m.SeekBothRange(key, value); err != nil {
+		return 0, err
+	}
+	return cnt, nil
 }
 
 func (m *memoryMutationCursor) SeekBothExact(key, value []byte) ([]byte, []byte, error) {
-	panic("SeekBothExact Not implemented")
+	v, err := m.SeekBothRange(key, value)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(v, value) {
+		return nil, nil, nil
+	}
+	return key, v, nil
 }
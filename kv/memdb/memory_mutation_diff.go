@@ -1,6 +1,13 @@
 package memdb
 
-import "github.com/ledgerwatch/erigon-lib/kv"
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
 
 type entry struct {
 	k []byte
@@ -56,3 +63,177 @@ func (m *MemoryDiff) Flush(tx kv.RwTx) error {
 	}
 	return nil
 }
+
+// Tables - sorted names of all tables touched by diff: cleared, with deleted entries or with put entries
+func (m *MemoryDiff) Tables() []string {
+	seen := map[string]struct{}{}
+	for _, name := range m.clearedTableNames {
+		seen[name] = struct{}{}
+	}
+	for name := range m.deletedEntries {
+		seen[name] = struct{}{}
+	}
+	for t := range m.diff {
+		seen[t.name] = struct{}{}
+	}
+	res := make([]string, 0, len(seen))
+	for name := range seen {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// MemoryDiffVersion - version of binary format produced by MemoryDiff.MarshalBinary
+const MemoryDiffVersion uint8 = 1
+
+var ErrMemoryDiffCorrupted = errors.New("memdb: corrupted diff")
+
+// MarshalBinary - serializes diff into compact versioned format, which can be shipped to another node (or persisted)
+// and replayed there by UnmarshalBinary+Flush. Output is deterministic: tables and deleted keys are sorted.
+//
+// Format (all numbers are uvarint, all strings/bytes are length-prefixed):
+//
+//	version
+//	clearedTablesAmount, [tableName]...
+//	deletedTablesAmount, [tableName, keysAmount, [key]...]...
+//	putTablesAmount, [tableName, dupsort(0|1), entriesAmount, [k, v]...]...
+func (m *MemoryDiff) MarshalBinary() ([]byte, error) {
+	buf := []byte{MemoryDiffVersion}
+
+	cleared := append([]string{}, m.clearedTableNames...)
+	sort.Strings(cleared)
+	buf = binary.AppendUvarint(buf, uint64(len(cleared)))
+	for _, name := range cleared {
+		buf = appendBytes(buf, []byte(name))
+	}
+
+	deletedTables := make([]string, 0, len(m.deletedEntries))
+	for name := range m.deletedEntries {
+		deletedTables = append(deletedTables, name)
+	}
+	sort.Strings(deletedTables)
+	buf = binary.AppendUvarint(buf, uint64(len(deletedTables)))
+	for _, name := range deletedTables {
+		keys := append([]string{}, m.deletedEntries[name]...)
+		sort.Strings(keys)
+		buf = appendBytes(buf, []byte(name))
+		buf = binary.AppendUvarint(buf, uint64(len(keys)))
+		for _, key := range keys {
+			buf = appendBytes(buf, []byte(key))
+		}
+	}
+
+	tables := make([]table, 0, len(m.diff))
+	for t := range m.diff {
+		tables = append(tables, t)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].name < tables[j].name })
+	buf = binary.AppendUvarint(buf, uint64(len(tables)))
+	for _, t := range tables {
+		buf = appendBytes(buf, []byte(t.name))
+		if t.dupsort {
+			buf = append(buf, 1)
+		} else {
+			buf = append(buf, 0)
+		}
+		entries := m.diff[t]
+		buf = binary.AppendUvarint(buf, uint64(len(entries)))
+		for _, e := range entries {
+			buf = appendBytes(buf, e.k)
+			buf = appendBytes(buf, e.v)
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary - restores diff serialized by MarshalBinary. Then use .Flush to apply it.
+func (m *MemoryDiff) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("%w: empty input", ErrMemoryDiffCorrupted)
+	}
+	if data[0] != MemoryDiffVersion {
+		return fmt.Errorf("memdb: unsupported diff version %d, expected %d", data[0], MemoryDiffVersion)
+	}
+	r := diffReader{data: data[1:]}
+
+	diff := MemoryDiff{
+		diff:           make(map[table][]entry),
+		deletedEntries: make(map[string][]string),
+	}
+	for i, n := uint64(0), r.uvarint(); i < n && r.err == nil; i++ {
+		diff.clearedTableNames = append(diff.clearedTableNames, string(r.bytes()))
+	}
+	for i, n := uint64(0), r.uvarint(); i < n && r.err == nil; i++ {
+		name := string(r.bytes())
+		for j, keysAmount := uint64(0), r.uvarint(); j < keysAmount && r.err == nil; j++ {
+			diff.deletedEntries[name] = append(diff.deletedEntries[name], string(r.bytes()))
+		}
+	}
+	for i, n := uint64(0), r.uvarint(); i < n && r.err == nil; i++ {
+		t := table{name: string(r.bytes()), dupsort: r.byte() == 1}
+		for j, entriesAmount := uint64(0), r.uvarint(); j < entriesAmount && r.err == nil; j++ {
+			diff.diff[t] = append(diff.diff[t], entry{k: r.bytes(), v: r.bytes()})
+		}
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.data) > 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrMemoryDiffCorrupted, len(r.data))
+	}
+	*m = diff
+	return nil
+}
+
+func appendBytes(buf, b []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+// diffReader - remembers first error, all next reads are no-op
+type diffReader struct {
+	data []byte
+	err  error
+}
+
+func (r *diffReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = fmt.Errorf("%w: bad length", ErrMemoryDiffCorrupted)
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *diffReader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data) == 0 {
+		r.err = fmt.Errorf("%w: unexpected end of input", ErrMemoryDiffCorrupted)
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+func (r *diffReader) bytes() []byte {
+	l := r.uvarint()
+	if r.err != nil {
+		return nil
+	}
+	if uint64(len(r.data)) < l {
+		r.err = fmt.Errorf("%w: unexpected end of input", ErrMemoryDiffCorrupted)
+		return nil
+	}
+	b := make([]byte, l)
+	copy(b, r.data[:l])
+	r.data = r.data[l:]
+	return b
+}
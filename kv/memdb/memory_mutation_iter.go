@@ -0,0 +1,210 @@
+/*
+   Copyright 2023 Erigon contributors
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+       http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package memdb
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/order"
+)
+
+// cursor2iter - same as mdbx's one, but on top of memoryMutationCursor - to see merged view of db and memory
+type cursor2iter struct {
+	c                                  kv.Cursor
+	fromPrefix, toPrefix, nextK, nextV []byte
+	err                                error
+	orderAscend                        order.By
+	limit                              int64
+}
+
+func (s *cursor2iter) init(table string, tx kv.Tx) (*cursor2iter, error) {
+	if s.orderAscend && s.fromPrefix != nil && s.toPrefix != nil && bytes.Compare(s.fromPrefix, s.toPrefix) >= 0 {
+		return s, fmt.Errorf("tx.Dual: %x must be lexicographicaly before %x", s.fromPrefix, s.toPrefix)
+	}
+	if !s.orderAscend && s.fromPrefix != nil && s.toPrefix != nil && bytes.Compare(s.fromPrefix, s.toPrefix) <= 0 {
+		return s, fmt.Errorf("tx.Dual: %x must be lexicographicaly before %x", s.toPrefix, s.fromPrefix)
+	}
+	c, err := tx.Cursor(table)
+	if err != nil {
+		return s, err
+	}
+	s.c = c
+
+	if s.fromPrefix == nil { // no initial position
+		if s.orderAscend {
+			s.nextK, s.nextV, s.err = s.c.First()
+		} else {
+			s.nextK, s.nextV, s.err = s.c.Last()
+		}
+		return s, s.err
+	}
+
+	if s.orderAscend {
+		s.nextK, s.nextV, s.err = s.c.Seek(s.fromPrefix)
+		return s, s.err
+	}
+	// seek exactly to given key or previous one
+	s.nextK, s.nextV, s.err = s.c.SeekExact(s.fromPrefix)
+	if s.err != nil {
+		return s, s.err
+	}
+	if s.nextK != nil { // go to last value of this key
+		if casted, ok := s.c.(kv.CursorDupSort); ok {
+			s.nextV, s.err = casted.LastDup()
+		}
+	} else { // key not found, go to prev one
+		if s.nextK, _, s.err = s.c.Seek(s.fromPrefix); s.err != nil {
+			return s, s.err
+		}
+		if s.nextK == nil {
+			s.nextK, s.nextV, s.err = s.c.Last()
+		} else {
+			s.nextK, s.nextV, s.err = s.c.Prev()
+		}
+	}
+	return s, s.err
+}
+
+func (s *cursor2iter) Close() {
+	if s.c != nil {
+		s.c.Close()
+	}
+}
+func (s *cursor2iter) HasNext() bool {
+	if s.err != nil { // always true, then .Next() call will return this error
+		return true
+	}
+	if s.limit == 0 { // limit reached
+		return false
+	}
+	if s.nextK == nil { // EndOfTable
+		return false
+	}
+	if s.toPrefix == nil { // s.nextK == nil check is above
+		return true
+	}
+
+	//Asc:  [from, to) AND from > to
+	//Desc: [from, to) AND from < to
+	cmp := bytes.Compare(s.nextK, s.toPrefix)
+	return (bool(s.orderAscend) && cmp < 0) || (!bool(s.orderAscend) && cmp > 0)
+}
+func (s *cursor2iter) Next() (k, v []byte, err error) {
+	s.limit--
+	k, v, err = s.nextK, s.nextV, s.err
+	if s.orderAscend {
+		s.nextK, s.nextV, s.err = s.c.Next()
+	} else {
+		s.nextK, s.nextV, s.err = s.c.Prev()
+	}
+	return k, v, err
+}
+
+// cursorDup2iter - same as mdbx's one, but on top of memoryMutationCursor - to see merged view of db and memory
+type cursorDup2iter struct {
+	c                           kv.CursorDupSort
+	key                         []byte
+	fromPrefix, toPrefix, nextV []byte
+	err                         error
+	orderAscend                 bool
+	limit                       int64
+}
+
+func (s *cursorDup2iter) init(table string, tx kv.Tx) (*cursorDup2iter, error) {
+	if s.orderAscend && s.fromPrefix != nil && s.toPrefix != nil && bytes.Compare(s.fromPrefix, s.toPrefix) >= 0 {
+		return s, fmt.Errorf("tx.Dual: %x must be lexicographicaly before %x", s.fromPrefix, s.toPrefix)
+	}
+	if !s.orderAscend && s.fromPrefix != nil && s.toPrefix != nil && bytes.Compare(s.fromPrefix, s.toPrefix) <= 0 {
+		return s, fmt.Errorf("tx.Dual: %x must be lexicographicaly before %x", s.toPrefix, s.fromPrefix)
+	}
+	c, err := tx.CursorDupSort(table)
+	if err != nil {
+		return s, err
+	}
+	s.c = c
+	k, _, err := c.SeekExact(s.key)
+	if err != nil {
+		return s, err
+	}
+	if k == nil {
+		return s, nil
+	}
+
+	if s.fromPrefix == nil { // no initial position
+		if s.orderAscend {
+			s.nextV, s.err = s.c.FirstDup()
+		} else {
+			s.nextV, s.err = s.c.LastDup()
+		}
+		return s, s.err
+	}
+
+	if s.orderAscend {
+		s.nextV, s.err = s.c.SeekBothRange(s.key, s.fromPrefix)
+		return s, s.err
+	}
+	// seek exactly to given key or previous one
+	_, s.nextV, s.err = s.c.SeekBothExact(s.key, s.fromPrefix)
+	if s.nextV == nil { // no such key
+		if s.nextV, s.err = s.c.SeekBothRange(s.key, s.fromPrefix); s.err != nil {
+			return s, s.err
+		}
+		if s.nextV == nil {
+			if _, _, s.err = s.c.SeekExact(s.key); s.err != nil {
+				return s, s.err
+			}
+			s.nextV, s.err = s.c.LastDup()
+		} else {
+			_, s.nextV, s.err = s.c.PrevDup()
+		}
+	}
+	return s, s.err
+}
+
+func (s *cursorDup2iter) Close() {
+	if s.c != nil {
+		s.c.Close()
+	}
+}
+func (s *cursorDup2iter) HasNext() bool {
+	if s.err != nil { // always true, then .Next() call will return this error
+		return true
+	}
+	if s.limit == 0 { // limit reached
+		return false
+	}
+	if s.nextV == nil { // EndOfTable
+		return false
+	}
+	if s.toPrefix == nil { // s.nextK == nil check is above
+		return true
+	}
+
+	//Asc:  [from, to) AND from > to
+	//Desc: [from, to) AND from < to
+	cmp := bytes.Compare(s.nextV, s.toPrefix)
+	return (s.orderAscend && cmp < 0) || (!s.orderAscend && cmp > 0)
+}
+func (s *cursorDup2iter) Next() (k, v []byte, err error) {
+	s.limit--
+	v, err = s.nextV, s.err
+	if s.orderAscend {
+		_, s.nextV, s.err = s.c.NextDup()
+	} else {
+		_, s.nextV, s.err = s.c.PrevDup()
+	}
+	return s.key, v, err
+}
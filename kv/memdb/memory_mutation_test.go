@@ -19,7 +19,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/iter"
+	"github.com/ledgerwatch/erigon-lib/kv/kvtest"
 )
 
 func initializeDbNonDupSort(rwTx kv.RwTx) {
@@ -664,3 +667,192 @@ func TestAutoConversionSeekBothRange(t *testing.T) {
 	require.NoError(t, err)
 	assert.Nil(t, v)
 }
+
+func TestKvSpec(t *testing.T) {
+	kvtest.Suite{
+		OpenRw: func(tb testing.TB, fill func(tx kv.RwTx)) kv.RwTx {
+			_, rwTx := NewTestTx(tb)
+			fill(rwTx)
+			batch := NewMemoryBatch(rwTx, tb.TempDir())
+			tb.Cleanup(batch.Rollback)
+			return batch
+		},
+	}.Run(t)
+}
+
+func forwardAndBackward(t *testing.T, c kv.Cursor) (forward, backward []string) {
+	t.Helper()
+	for k, v, err := c.First(); k != nil; k, v, err = c.Next() {
+		require.NoError(t, err)
+		forward = append(forward, string(k)+":"+string(v))
+	}
+	for k, v, err := c.Last(); k != nil; k, v, err = c.Prev() {
+		require.NoError(t, err)
+		backward = append([]string{string(k) + ":" + string(v)}, backward...)
+	}
+	return forward, backward
+}
+
+func TestPrev(t *testing.T) {
+	_, rwTx := NewTestTx(t)
+	initializeDbNonDupSort(rwTx)
+
+	batch := NewMemoryBatch(rwTx, "")
+	require.NoError(t, batch.Put(kv.HashedAccounts, []byte("BAAA"), []byte("value4")))
+	require.NoError(t, batch.Put(kv.HashedAccounts, []byte("CAAA"), []byte("value5")))
+	require.NoError(t, batch.Put(kv.HashedAccounts, []byte("DDAA"), []byte("value6")))
+	require.NoError(t, batch.Delete(kv.HashedAccounts, []byte("CBAA")))
+	require.NoError(t, batch.Delete(kv.HashedAccounts, []byte("CCAA")))
+
+	cursor, err := batch.Cursor(kv.HashedAccounts)
+	require.NoError(t, err)
+	defer cursor.Close()
+	forward, backward := forwardAndBackward(t, cursor)
+	require.Equal(t, []string{"AAAA:value", "BAAA:value4", "CAAA:value5", "DDAA:value6"}, forward)
+	require.Equal(t, forward, backward)
+
+	// change direction in the middle
+	k, _, err := cursor.Seek([]byte("C"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("CAAA"), k)
+	k, v, err := cursor.Prev()
+	require.NoError(t, err)
+	require.Equal(t, []byte("BAAA"), k)
+	require.Equal(t, []byte("value4"), v)
+	k, _, err = cursor.Next()
+	require.NoError(t, err)
+	require.Equal(t, []byte("CAAA"), k)
+	k, _, err = cursor.Next()
+	require.NoError(t, err)
+	require.Equal(t, []byte("DDAA"), k)
+
+	// deleted last entry of db
+	require.NoError(t, batch.Delete(kv.HashedAccounts, []byte("DDAA")))
+	k, v, err = batch.Last(kv.HashedAccounts)
+	require.NoError(t, err)
+	require.Equal(t, []byte("CAAA"), k)
+	require.Equal(t, []byte("value5"), v)
+}
+
+func TestPrevDupSort(t *testing.T) {
+	_, rwTx := NewTestTx(t)
+	initializeDbDupSort(rwTx)
+
+	batch := NewMemoryBatch(rwTx, "")
+	require.NoError(t, batch.Put(kv.AccountChangeSet, []byte("key1"), []byte("value1.2")))
+	require.NoError(t, batch.Put(kv.AccountChangeSet, []byte("key2"), []byte("value2.1")))
+	require.NoError(t, batch.Put(kv.AccountChangeSet, []byte("key3"), []byte("value3.4")))
+
+	cursor, err := batch.CursorDupSort(kv.AccountChangeSet)
+	require.NoError(t, err)
+	defer cursor.Close()
+	forward, backward := forwardAndBackward(t, cursor)
+	require.Equal(t, []string{"key1:value1.1", "key1:value1.2", "key1:value1.3", "key2:value2.1", "key3:value3.1", "key3:value3.3", "key3:value3.4"}, forward)
+	require.Equal(t, forward, backward)
+
+	_, v, err := cursor.SeekBothExact([]byte("key1"), []byte("value1.3"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1.3"), v)
+	for _, expect := range []string{"value1.2", "value1.1", ""} {
+		_, v, err = cursor.PrevDup()
+		require.NoError(t, err)
+		require.Equal(t, expect, string(v))
+	}
+
+	_, _, err = cursor.SeekExact([]byte("key3"))
+	require.NoError(t, err)
+	v, err = cursor.LastDup()
+	require.NoError(t, err)
+	require.Equal(t, []byte("value3.4"), v)
+	for _, expect := range []string{"key2:value2.1", "key1:value1.3", ":"} {
+		k, v, err := cursor.PrevNoDup()
+		require.NoError(t, err)
+		require.Equal(t, expect, string(k)+":"+string(v))
+	}
+
+	// deleted key hides all values of db
+	require.NoError(t, batch.Delete(kv.AccountChangeSet, []byte("key3")))
+	require.NoError(t, batch.Put(kv.AccountChangeSet, []byte("key3"), []byte("value3.2")))
+	forward, backward = forwardAndBackward(t, cursor)
+	require.Equal(t, []string{"key1:value1.1", "key1:value1.2", "key1:value1.3", "key2:value2.1", "key3:value3.2"}, forward)
+	require.Equal(t, forward, backward)
+}
+
+func TestPrevAfterClearBucket(t *testing.T) {
+	_, rwTx := NewTestTx(t)
+	initializeDbNonDupSort(rwTx)
+
+	batch := NewMemoryBatch(rwTx, "")
+	require.NoError(t, batch.ClearBucket(kv.HashedAccounts))
+	require.NoError(t, batch.Put(kv.HashedAccounts, []byte("BAAA"), []byte("value4")))
+	require.NoError(t, batch.Put(kv.HashedAccounts, []byte("CAAA"), []byte("value5")))
+
+	cursor, err := batch.Cursor(kv.HashedAccounts)
+	require.NoError(t, err)
+	defer cursor.Close()
+	forward, backward := forwardAndBackward(t, cursor)
+	require.Equal(t, []string{"BAAA:value4", "CAAA:value5"}, forward)
+	require.Equal(t, forward, backward)
+}
+
+func TestDiffMarshalBinary(t *testing.T) {
+	fill := func(tx kv.RwTx) {
+		initializeDbNonDupSort(tx)
+		initializeDbDupSort(tx)
+		require.NoError(t, tx.Put(kv.Code, []byte("code1"), []byte("value")))
+	}
+	_, rwTx := NewTestTx(t)
+	fill(rwTx)
+
+	batch := NewMemoryBatch(rwTx, "")
+	defer batch.Rollback()
+	require.NoError(t, batch.Put(kv.HashedAccounts, []byte("BAAA"), []byte("value4")))
+	require.NoError(t, batch.Put(kv.HashedAccounts, []byte("CAAA"), []byte("value5")))
+	require.NoError(t, batch.Delete(kv.HashedAccounts, []byte("CBAA")))
+	require.NoError(t, batch.Put(kv.AccountChangeSet, []byte("key1"), []byte("value1.2")))
+	require.NoError(t, batch.Delete(kv.AccountChangeSet, []byte("key3")))
+	require.NoError(t, batch.ClearBucket(kv.Code))
+	require.NoError(t, batch.Put(kv.Code, []byte("code2"), []byte("value")))
+
+	diff, err := batch.Diff()
+	require.NoError(t, err)
+	encoded, err := diff.MarshalBinary()
+	require.NoError(t, err)
+	encodedAgain, err := diff.MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, encoded, encodedAgain)
+
+	// replay on another node
+	_, otherTx := NewTestTx(t)
+	fill(otherTx)
+	var decoded MemoryDiff
+	require.NoError(t, decoded.UnmarshalBinary(encoded))
+	require.NoError(t, decoded.Flush(otherTx))
+	require.NoError(t, batch.Flush(rwTx))
+
+	for _, table := range []string{kv.HashedAccounts, kv.AccountChangeSet, kv.Code} {
+		expect, err := rwTx.Range(table, nil, nil)
+		require.NoError(t, err)
+		expectKeys, expectValues, err := iter.ToKVArray(expect)
+		require.NoError(t, err)
+		got, err := otherTx.Range(table, nil, nil)
+		require.NoError(t, err)
+		gotKeys, gotValues, err := iter.ToKVArray(got)
+		require.NoError(t, err)
+		require.Equal(t, expectKeys, gotKeys, table)
+		require.Equal(t, expectValues, gotValues, table)
+	}
+	v, err := otherTx.GetOne(kv.Code, []byte("code1"))
+	require.NoError(t, err)
+	require.Nil(t, v)
+	v, err = otherTx.GetOne(kv.HashedAccounts, []byte("CAAA"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value5"), v)
+
+	require.Error(t, decoded.UnmarshalBinary(nil))
+	require.ErrorIs(t, decoded.UnmarshalBinary(encoded[:len(encoded)-1]), ErrMemoryDiffCorrupted)
+	require.ErrorIs(t, decoded.UnmarshalBinary(append(common.Copy(encoded), 0)), ErrMemoryDiffCorrupted)
+	unknownVersion := common.Copy(encoded)
+	unknownVersion[0] = MemoryDiffVersion + 1
+	require.Error(t, decoded.UnmarshalBinary(unknownVersion))
+}
@@ -20,8 +20,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"runtime"
+	"sync"
 
 	"github.com/ledgerwatch/erigon-lib/kv/iter"
 	"github.com/ledgerwatch/erigon-lib/kv/order"
@@ -54,6 +57,9 @@ type DB struct {
 	buckets      kv.TableCfg
 	roTxsLimiter *semaphore.Weighted
 	opts         remoteOpts
+
+	memDBLock sync.Mutex
+	memDB     kv.RwDB // buffer for writes of remote RwTx, opened by first BeginRw
 }
 
 type tx struct {
@@ -146,7 +152,14 @@ func (db *DB) EnsureVersionCompatibility() bool {
 	return true
 }
 
-func (db *DB) Close() {}
+func (db *DB) Close() {
+	db.memDBLock.Lock()
+	defer db.memDBLock.Unlock()
+	if db.memDB != nil {
+		db.memDB.Close()
+		db.memDB = nil
+	}
+}
 
 func (db *DB) BeginRo(ctx context.Context) (txn kv.Tx, err error) {
 	select {
@@ -186,12 +199,6 @@ func (db *DB) BeginTemporalRo(ctx context.Context) (kv.TemporalTx, error) {
 	}
 	return t.(kv.TemporalTx), nil
 }
-func (db *DB) BeginRw(ctx context.Context) (kv.RwTx, error) {
-	return nil, fmt.Errorf("remote db provider doesn't support .BeginRw method")
-}
-func (db *DB) BeginRwNosync(ctx context.Context) (kv.RwTx, error) {
-	return nil, fmt.Errorf("remote db provider doesn't support .BeginRw method")
-}
 func (db *DB) BeginTemporalRw(ctx context.Context) (kv.RwTx, error) {
 	return nil, fmt.Errorf("remote db provider doesn't support .BeginTemporalRw method")
 }
@@ -216,20 +223,17 @@ func (db *DB) ViewTemporal(ctx context.Context, f func(tx kv.TemporalTx) error)
 	return f(tx)
 }
 
-func (db *DB) Update(ctx context.Context, f func(tx kv.RwTx) error) (err error) {
-	return fmt.Errorf("remote db provider doesn't support .Update method")
-}
-func (db *DB) UpdateNosync(ctx context.Context, f func(tx kv.RwTx) error) (err error) {
-	return fmt.Errorf("remote db provider doesn't support .UpdateNosync method")
-}
-
 func (tx *tx) ViewID() uint64  { return tx.viewID }
 func (tx *tx) CollectMetrics() {}
 func (tx *tx) IncrementSequence(bucket string, amount uint64) (uint64, error) {
-	panic("not implemented yet")
+	return 0, fmt.Errorf("remote db provider doesn't support .IncrementSequence method")
 }
 func (tx *tx) ReadSequence(bucket string) (uint64, error) {
-	panic("not implemented yet")
+	reply, err := tx.db.remoteKV.Sequence(tx.ctx, &remote.SequenceReq{TxId: tx.id, Table: bucket})
+	if err != nil {
+		return 0, err
+	}
+	return reply.Value, nil
 }
 func (tx *tx) Append(bucket string, k, v []byte) error    { panic("no write methods") }
 func (tx *tx) AppendDup(bucket string, k, v []byte) error { panic("no write methods") }
@@ -246,7 +250,13 @@ func (tx *tx) Rollback() {
 		c.Close()
 	}
 }
-func (tx *tx) DBSize() (uint64, error) { panic("not implemented") }
+func (tx *tx) DBSize() (uint64, error) {
+	reply, err := tx.db.remoteKV.Size(tx.ctx, &remote.SizeReq{TxId: tx.id})
+	if err != nil {
+		return 0, err
+	}
+	return reply.Size, nil
+}
 
 func (tx *tx) statelessCursor(bucket string) (kv.Cursor, error) {
 	if tx.statelessCursors == nil {
@@ -264,7 +274,13 @@ func (tx *tx) statelessCursor(bucket string) (kv.Cursor, error) {
 	return c, nil
 }
 
-func (tx *tx) BucketSize(name string) (uint64, error) { panic("not implemented") }
+func (tx *tx) BucketSize(name string) (uint64, error) {
+	reply, err := tx.db.remoteKV.Size(tx.ctx, &remote.SizeReq{TxId: tx.id, Table: name})
+	if err != nil {
+		return 0, err
+	}
+	return reply.Size, nil
+}
 
 func (tx *tx) ForEach(bucket string, fromPrefix []byte, walker func(k, v []byte) error) error {
 	it, err := tx.Range(bucket, fromPrefix, nil)
@@ -634,7 +650,17 @@ func (c *remoteCursorDupSort) DeleteExact(k1, k2 []byte) error    { panic("not s
 func (c *remoteCursorDupSort) AppendDup(k []byte, v []byte) error { panic("not supported") }
 func (c *remoteCursorDupSort) PutNoDupData(k, v []byte) error     { panic("not supported") }
 func (c *remoteCursorDupSort) DeleteCurrentDuplicates() error     { panic("not supported") }
-func (c *remoteCursorDupSort) CountDuplicates() (uint64, error)   { panic("not supported") }
+
+func (c *remoteCursorDupSort) CountDuplicates() (uint64, error) {
+	if err := c.stream.Send(&remote.Cursor{Cursor: c.id, Op: remote.Op_COUNT_DUPLICATES}); err != nil {
+		return 0, err
+	}
+	pair, err := c.stream.Recv()
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(pair.V), nil
+}
 
 func (c *remoteCursorDupSort) FirstDup() ([]byte, error)          { return c.firstDup() }
 func (c *remoteCursorDupSort) NextDup() ([]byte, []byte, error)   { return c.nextDup() }
@@ -662,7 +688,7 @@ func (tx *tx) DomainGet(name kv.Domain, k, k2 []byte) (v []byte, ok bool, err er
 
 func (tx *tx) DomainRange(name kv.Domain, fromKey, toKey []byte, ts uint64, asc order.By, limit int) (it iter.KV, err error) {
 	return iter.PaginateKV(func(pageToken string) (keys, vals [][]byte, nextPageToken string, err error) {
-		reply, err := tx.db.remoteKV.DomainRange(tx.ctx, &remote.DomainRangeReq{TxId: tx.id, Table: string(name), FromKey: fromKey, ToKey: toKey, Ts: ts, OrderAscend: bool(asc), Limit: int64(limit)})
+		reply, err := tx.db.remoteKV.DomainRange(tx.ctx, &remote.DomainRangeReq{TxId: tx.id, Table: string(name), FromKey: fromKey, ToKey: toKey, Ts: ts, OrderAscend: bool(asc), Limit: int64(limit), PageToken: pageToken})
 		if err != nil {
 			return nil, nil, "", err
 		}
@@ -678,7 +704,7 @@ func (tx *tx) HistoryGet(name kv.History, k []byte, ts uint64) (v []byte, ok boo
 }
 func (tx *tx) HistoryRange(name kv.History, fromTs, toTs int, asc order.By, limit int) (it iter.KV, err error) {
 	return iter.PaginateKV(func(pageToken string) (keys, vals [][]byte, nextPageToken string, err error) {
-		reply, err := tx.db.remoteKV.HistoryRange(tx.ctx, &remote.HistoryRangeReq{TxId: tx.id, Table: string(name), FromTs: int64(fromTs), ToTs: int64(toTs), OrderAscend: bool(asc), Limit: int64(limit)})
+		reply, err := tx.db.remoteKV.HistoryRange(tx.ctx, &remote.HistoryRangeReq{TxId: tx.id, Table: string(name), FromTs: int64(fromTs), ToTs: int64(toTs), OrderAscend: bool(asc), Limit: int64(limit), PageToken: pageToken})
 		if err != nil {
 			return nil, nil, "", err
 		}
@@ -688,7 +714,7 @@ func (tx *tx) HistoryRange(name kv.History, fromTs, toTs int, asc order.By, limi
 
 func (tx *tx) IndexRange(name kv.InvertedIdx, k []byte, fromTs, toTs int, asc order.By, limit int) (timestamps iter.U64, err error) {
 	return iter.PaginateU64(func(pageToken string) (arr []uint64, nextPageToken string, err error) {
-		req := &remote.IndexRangeReq{TxId: tx.id, Table: string(name), K: k, FromTs: int64(fromTs), ToTs: int64(toTs), OrderAscend: bool(asc), Limit: int64(limit)}
+		req := &remote.IndexRangeReq{TxId: tx.id, Table: string(name), K: k, FromTs: int64(fromTs), ToTs: int64(toTs), OrderAscend: bool(asc), Limit: int64(limit), PageToken: pageToken}
 		reply, err := tx.db.remoteKV.IndexRange(tx.ctx, req)
 		if err != nil {
 			return nil, "", err
@@ -724,6 +750,81 @@ func (tx *tx) RangeAscend(table string, fromPrefix, toPrefix []byte, limit int)
 func (tx *tx) RangeDescend(table string, fromPrefix, toPrefix []byte, limit int) (iter.KV, error) {
 	return tx.rangeOrderLimit(table, fromPrefix, toPrefix, order.Desc, limit)
 }
+
+func (tx *tx) streamOrderLimit(table string, fromPrefix, toPrefix []byte, asc order.By, limit int) (iter.KV, error) {
+	ctx, cancel := context.WithCancel(tx.ctx)
+	req := &remote.RangeReq{TxId: tx.id, Table: table, FromPrefix: fromPrefix, ToPrefix: toPrefix, OrderAscend: bool(asc), Limit: int64(limit)}
+	stream, err := tx.db.remoteKV.Stream(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	s := &streamKV{stream: stream, cancel: cancel}
+	tx.streams = append(tx.streams, s)
+	return s, nil
+}
+func (tx *tx) Stream(table string, fromPrefix, toPrefix []byte) (iter.KV, error) {
+	return tx.streamOrderLimit(table, fromPrefix, toPrefix, order.Asc, -1)
+}
+func (tx *tx) StreamAscend(table string, fromPrefix, toPrefix []byte, limit int) (iter.KV, error) {
+	return tx.streamOrderLimit(table, fromPrefix, toPrefix, order.Asc, limit)
+}
+func (tx *tx) StreamDescend(table string, fromPrefix, toPrefix []byte, limit int) (iter.KV, error) {
+	return tx.streamOrderLimit(table, fromPrefix, toPrefix, order.Desc, limit)
+}
+
+// streamKV - iterator over batches pushed by server-side stream. Close() cancels the stream.
+type streamKV struct {
+	stream       remote.KV_StreamClient
+	cancel       context.CancelFunc
+	keys, values [][]byte
+	i            int
+	err          error
+	eof          bool
+}
+
+func (it *streamKV) HasNext() bool {
+	if it.err != nil || it.i < len(it.keys) {
+		return true
+	}
+	for !it.eof && it.i >= len(it.keys) {
+		reply, err := it.stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				it.Close()
+				return false
+			}
+			it.err = err
+			return true
+		}
+		it.keys, it.values, it.i = reply.Keys, reply.Values, 0
+	}
+	return it.i < len(it.keys)
+}
+func (it *streamKV) Next() ([]byte, []byte, error) {
+	if it.err != nil {
+		return nil, nil, it.err
+	}
+	k, v := it.keys[it.i], it.values[it.i]
+	it.i++
+	return k, v, nil
+}
+func (it *streamKV) Close() {
+	it.eof = true
+	it.cancel()
+}
+
 func (tx *tx) RangeDupSort(table string, key []byte, fromPrefix, toPrefix []byte, asc order.By, limit int) (iter.KV, error) {
-	panic("not implemented yet")
+	return iter.PaginateKV(func(pageToken string) (keys [][]byte, values [][]byte, nextPageToken string, err error) {
+		req := &remote.RangeDupSortReq{TxId: tx.id, Table: table, Key: key, FromPrefix: fromPrefix, ToPrefix: toPrefix, OrderAscend: bool(asc), Limit: int64(limit), PageToken: pageToken}
+		reply, err := tx.db.remoteKV.RangeDupSort(tx.ctx, req)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		keys = make([][]byte, len(reply.Values))
+		for i := range keys {
+			keys[i] = key
+		}
+		return keys, reply.Values, reply.NextPageToken, nil
+	}), nil
 }
@@ -0,0 +1,144 @@
+/*
+   Copyright 2021 Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package remotedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+)
+
+// ErrConflict - DB was modified by someone else after remote RwTx has started. Caller can retry whole transaction.
+var ErrConflict = errors.New("remotedb: write conflict")
+
+var _ kv.RwTx = (*rwTx)(nil)
+
+// rwTx - buffers writes in memory on top of remote read-only tx (reads see own writes).
+// Commit sends all writes to server in one request, server applies them in one RwTx.
+// Server must allow writes to touched tables - see `remotedbserver.KvServer.WithWritableTables`
+type rwTx struct {
+	*memdb.MemoryMutation
+	memTx  kv.RwTx // owned by MemoryMutation, but env is shared by DB - so rollback only tx
+	tx     *tx
+	closed bool
+}
+
+func (db *DB) BeginRw(ctx context.Context) (kv.RwTx, error) {
+	memDB, err := db.openMemDB()
+	if err != nil {
+		return nil, err
+	}
+	roTx, err := db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// in-memory env has 1 writer: remote RwTx-es of this DB are serialized - same as local ones
+	memTx, err := memDB.BeginRw(ctx)
+	if err != nil {
+		roTx.Rollback()
+		return nil, err
+	}
+	return &rwTx{MemoryMutation: memdb.NewMemoryBatchWithCustomDB(roTx, memDB, memTx, ""), memTx: memTx, tx: roTx.(*tx)}, nil
+}
+
+// openMemDB - one in-memory env per DB: it's always empty between txs, because memTx never commits
+func (db *DB) openMemDB() (kv.RwDB, error) {
+	db.memDBLock.Lock()
+	defer db.memDBLock.Unlock()
+	if db.memDB != nil {
+		return db.memDB, nil
+	}
+	memDB, err := mdbx.NewMDBX(db.log).InMem("").Open()
+	if err != nil {
+		return nil, fmt.Errorf("remotedb: open in-memory buffer: %w", err)
+	}
+	db.memDB = memDB
+	return memDB, nil
+}
+
+// BeginRwNosync - server decides about durability of remote writes, so it's same as BeginRw
+func (db *DB) BeginRwNosync(ctx context.Context) (kv.RwTx, error) {
+	return db.BeginRw(ctx)
+}
+
+func (db *DB) Update(ctx context.Context, f func(tx kv.RwTx) error) (err error) {
+	tx, err := db.BeginRw(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = f(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+func (db *DB) UpdateNosync(ctx context.Context, f func(tx kv.RwTx) error) (err error) {
+	return db.Update(ctx, f)
+}
+
+func (tx *rwTx) ViewID() uint64                             { return tx.tx.ViewID() }
+func (tx *rwTx) DBSize() (uint64, error)                    { return tx.tx.DBSize() }
+func (tx *rwTx) BucketSize(name string) (uint64, error)     { return tx.tx.BucketSize(name) }
+func (tx *rwTx) ReadSequence(bucket string) (uint64, error) { return tx.tx.ReadSequence(bucket) }
+func (tx *rwTx) IncrementSequence(bucket string, amount uint64) (uint64, error) {
+	return 0, fmt.Errorf("remote db provider doesn't support .IncrementSequence method")
+}
+func (tx *rwTx) DropBucket(bucket string) error {
+	return fmt.Errorf("remote db provider doesn't support .DropBucket method")
+}
+
+func (tx *rwTx) Commit() error {
+	if tx.closed {
+		return fmt.Errorf("remotedb: tx already closed")
+	}
+	defer tx.Rollback()
+	diff, err := tx.MemoryMutation.Diff()
+	if err != nil {
+		return err
+	}
+	if len(diff.Tables()) == 0 {
+		return nil
+	}
+	data, err := diff.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.tx.db.remoteKV.Commit(tx.tx.ctx, &remote.CommitReq{ViewId: tx.tx.ViewID(), Diff: data}); err != nil {
+		if status.Code(err) == codes.Aborted {
+			return fmt.Errorf("%w: %s", ErrConflict, status.Convert(err).Message())
+		}
+		return err
+	}
+	return nil
+}
+
+func (tx *rwTx) Rollback() {
+	if tx.closed {
+		return
+	}
+	tx.closed = true
+	tx.memTx.Rollback() // not MemoryMutation.Rollback: it closes env
+	tx.tx.Rollback()
+}
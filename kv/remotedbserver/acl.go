@@ -0,0 +1,152 @@
+/*
+   Copyright 2021 Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package remotedbserver
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// AuthorizationHeader - gRPC metadata key. Value format: "Bearer <token>"
+const AuthorizationHeader = "authorization"
+
+// ACL - identities of clients and their permissions. Client identity is resolved:
+//   - from token in `authorization` metadata (see Tokens), if it's present
+//   - from CommonName of verified client certificate (mTLS)
+//
+// All rejected requests (unknown identity, not allowed table, exceeded limit, foreign tx) get `PermissionDenied` code.
+type ACL struct {
+	Tokens  map[string]string            // token -> identity
+	Clients map[string]ClientPermissions // identity -> permissions
+}
+
+type ClientPermissions struct {
+	Tables   []string // allowed tables, domains, histories and inverted indices. nil means all
+	MaxTxs   int      // limit of simultaneously opened txs. 0 means unlimited
+	MaxRange int      // limit of items in one Range/Stream/IndexRange/... request and of items read by one cursor of Tx stream. 0 means unlimited
+}
+
+func (p ClientPermissions) tableAllowed(table string) bool {
+	if p.Tables == nil {
+		return true
+	}
+	for _, t := range p.Tables {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
+
+// WithACL - enables authentication and per-client permissions. Call it before serving requests.
+func (s *KvServer) WithACL(acl *ACL) *KvServer {
+	s.acl = acl
+	return s
+}
+
+// identify - returns identity and permissions of client. Returns empty identity if ACLs are disabled.
+func (s *KvServer) identify(ctx context.Context) (string, ClientPermissions, error) {
+	if s.acl == nil {
+		return "", ClientPermissions{}, nil
+	}
+	var identity string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if vals := md.Get(AuthorizationHeader); len(vals) > 0 {
+			token := strings.TrimPrefix(vals[0], "Bearer ")
+			if identity, ok = s.acl.Tokens[token]; !ok {
+				return "", ClientPermissions{}, status.Error(codes.PermissionDenied, "kvserver: unknown token")
+			}
+		}
+	}
+	if identity == "" {
+		if p, ok := peer.FromContext(ctx); ok {
+			if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.VerifiedChains) > 0 && len(tlsInfo.State.VerifiedChains[0]) > 0 {
+				identity = tlsInfo.State.VerifiedChains[0][0].Subject.CommonName
+			}
+		}
+	}
+	perms, ok := s.acl.Clients[identity]
+	if identity == "" || !ok {
+		return "", ClientPermissions{}, status.Errorf(codes.PermissionDenied, "kvserver: unknown client %q", identity)
+	}
+	return identity, perms, nil
+}
+
+// authorize - checks that client can read/write `table` (if not empty) inside tx `txID` (if not 0)
+func (s *KvServer) authorize(ctx context.Context, txID uint64, table string) (ClientPermissions, error) {
+	if s.acl == nil {
+		return ClientPermissions{}, nil
+	}
+	identity, perms, err := s.identify(ctx)
+	if err != nil {
+		return perms, err
+	}
+	if txID != 0 {
+		s.txsMapLock.RLock()
+		tx, ok := s.txs[txID]
+		s.txsMapLock.RUnlock()
+		if ok && tx.client != identity {
+			return perms, status.Errorf(codes.PermissionDenied, "kvserver: txn %d belongs to another client", txID)
+		}
+	}
+	if table != "" && !perms.tableAllowed(table) {
+		return perms, status.Errorf(codes.PermissionDenied, "kvserver: client %q has no access to %s", identity, table)
+	}
+	return perms, nil
+}
+
+// authorizeRange - same as `authorize`, but also checks amount of requested items: returns limit to use.
+// limit<=0 means unlimited - it's reduced to MaxRange of client
+func (s *KvServer) authorizeRange(ctx context.Context, txID uint64, table string, limit int) (int, error) {
+	perms, err := s.authorize(ctx, txID, table)
+	if err != nil {
+		return limit, err
+	}
+	if perms.MaxRange <= 0 {
+		return limit, nil
+	}
+	if limit <= 0 {
+		return perms.MaxRange, nil
+	}
+	if limit > perms.MaxRange {
+		return limit, status.Errorf(codes.PermissionDenied, "kvserver: limit %d exceeds allowed %d", limit, perms.MaxRange)
+	}
+	return limit, nil
+}
+
+// cursorBudget - items read by one cursor of Tx stream
+type cursorBudget struct {
+	maxRange, read int
+}
+
+// readCursor - counts items read by cursor of Tx stream: they are limited by MaxRange of client (as items of one Range request)
+func (c *cursorBudget) readCursor() error {
+	if c.maxRange <= 0 {
+		return nil
+	}
+	if c.read >= c.maxRange {
+		return status.Errorf(codes.PermissionDenied, "kvserver: cursor read more than allowed %d items", c.maxRange)
+	}
+	c.read++
+	return nil
+}
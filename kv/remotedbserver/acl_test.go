@@ -0,0 +1,160 @@
+/*
+   Copyright 2021 Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package remotedbserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"runtime"
+	"testing"
+
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+)
+
+type tokenCreds string
+
+func (t tokenCreds) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{AuthorizationHeader: "Bearer " + string(t)}, nil
+}
+func (t tokenCreds) RequireTransportSecurity() bool { return false }
+
+func TestKvServer_ACL(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fix me on win please")
+	}
+	ctx, db := context.Background(), memdb.NewTestDB(t)
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		for i := byte(1); i <= 20; i++ {
+			if err := tx.Put(kv.HeaderNumber, []byte{i}, []byte{i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	s := NewKvServer(ctx, db, nil, nil, log.New()).WithACL(&ACL{
+		Tokens: map[string]string{"t1": "txpool", "t2": "indexer"},
+		Clients: map[string]ClientPermissions{
+			"txpool":  {Tables: []string{kv.HeaderNumber}, MaxTxs: 1, MaxRange: 10},
+			"indexer": {},
+		},
+	})
+	conn, grpcServer := bufconn.Listen(1024*1024), grpc.NewServer()
+	remote.RegisterKVServer(grpcServer, s)
+	go grpcServer.Serve(conn) //nolint
+	t.Cleanup(grpcServer.Stop)
+	newClient := func(opts ...grpc.DialOption) remote.KVClient {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithContextDialer(func(ctx context.Context, url string) (net.Conn, error) { return conn.Dial() }))
+		cc, err := grpc.Dial("", opts...)
+		require.NoError(t, err)
+		t.Cleanup(func() { cc.Close() })
+		return remote.NewKVClient(cc)
+	}
+	var lastStream remote.KV_TxClient
+	beginTx := func(client remote.KVClient) (uint64, error) {
+		stream, err := client.Tx(ctx)
+		require.NoError(t, err)
+		t.Cleanup(func() { stream.CloseSend() }) //nolint
+		msg, err := stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		lastStream = stream
+		return msg.TxId, nil
+	}
+	requireDenied := func(err error) {
+		t.Helper()
+		require.Equal(t, codes.PermissionDenied, status.Code(err), err)
+	}
+
+	anonymous, txpool, indexer := newClient(), newClient(grpc.WithPerRPCCredentials(tokenCreds("t1"))), newClient(grpc.WithPerRPCCredentials(tokenCreds("t2")))
+	_, err := beginTx(anonymous)
+	requireDenied(err)
+	_, err = beginTx(newClient(grpc.WithPerRPCCredentials(tokenCreds("wrong"))))
+	requireDenied(err)
+
+	txpoolTx, err := beginTx(txpool)
+	require.NoError(t, err)
+	txpoolStream := lastStream
+	_, err = beginTx(txpool) // MaxTxs
+	requireDenied(err)
+
+	reply, err := txpool.Range(ctx, &remote.RangeReq{TxId: txpoolTx, Table: kv.HeaderNumber, OrderAscend: true, Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{1}, {2}}, reply.Keys)
+	reply, err = txpool.Range(ctx, &remote.RangeReq{TxId: txpoolTx, Table: kv.HeaderNumber, OrderAscend: true, Limit: -1}) // reduced to MaxRange
+	require.NoError(t, err)
+	require.Equal(t, 10, len(reply.Keys))
+	_, err = txpool.Range(ctx, &remote.RangeReq{TxId: txpoolTx, Table: kv.HeaderNumber, OrderAscend: true, Limit: 11}) // MaxRange
+	requireDenied(err)
+	_, err = txpool.Range(ctx, &remote.RangeReq{TxId: txpoolTx, Table: kv.Code, OrderAscend: true, Limit: 10})
+	requireDenied(err)
+	_, err = txpool.Sequence(ctx, &remote.SequenceReq{TxId: txpoolTx, Table: kv.Code})
+	requireDenied(err)
+
+	// no limits for indexer, but it can't use tx of another client
+	indexerTx, err := beginTx(indexer)
+	require.NoError(t, err)
+	_, err = indexer.Range(ctx, &remote.RangeReq{TxId: indexerTx, Table: kv.Code, OrderAscend: true, Limit: -1})
+	require.NoError(t, err)
+	_, err = indexer.Range(ctx, &remote.RangeReq{TxId: txpoolTx, Table: kv.HeaderNumber, OrderAscend: true, Limit: 1})
+	requireDenied(err)
+
+	// cursor of Tx stream has same MaxRange budget
+	require.NoError(t, txpoolStream.Send(&remote.Cursor{Op: remote.Op_OPEN, BucketName: kv.HeaderNumber}))
+	msg, err := txpoolStream.Recv()
+	require.NoError(t, err)
+	cursorOp := func(op remote.Op) error {
+		require.NoError(t, txpoolStream.Send(&remote.Cursor{Op: op, Cursor: msg.CursorId}))
+		_, err := txpoolStream.Recv()
+		return err
+	}
+	require.NoError(t, cursorOp(remote.Op_FIRST))
+	for i := 1; i < 10; i++ {
+		require.NoError(t, cursorOp(remote.Op_NEXT))
+	}
+	requireDenied(cursorOp(remote.Op_NEXT))
+}
+
+func TestKvServer_ACL_mTLS(t *testing.T) {
+	s := NewKvServer(context.Background(), memdb.NewTestDB(t), nil, nil, log.New()).WithACL(&ACL{
+		Clients: map[string]ClientPermissions{"indexer": {}},
+	})
+	withCert := func(cn string) context.Context {
+		state := tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: cn}}}}}
+		return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+	}
+	identity, _, err := s.identify(withCert("indexer"))
+	require.NoError(t, err)
+	require.Equal(t, "indexer", identity)
+	_, _, err = s.identify(withCert("unknown"))
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -0,0 +1,80 @@
+/*
+   Copyright 2021 Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package remotedbserver
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"google.golang.org/grpc/peer"
+)
+
+var (
+	remoteTxsOpen     = metrics.GetOrCreateCounter(`kv_remote_txs_open`)
+	remoteTxsReaped   = metrics.GetOrCreateCounter(`kv_remote_txs_reaped`)
+	remoteTxsRejected = metrics.GetOrCreateCounter(`kv_remote_txs_rejected`)
+)
+
+// TxLimits - protection against clients which open too many txs or don't close them.
+// Every read tx pins MDBX snapshot: DB can't reuse pages freed after it and start grow.
+// Zero value of any field means: no limit.
+type TxLimits struct {
+	// IdleTimeout - rollback tx if client didn't use it (by `Tx` stream or by other methods) for this duration
+	IdleTimeout time.Duration
+	// MaxLifetime - rollback tx after this duration since it was opened. Periodic `renew` doesn't extend it.
+	MaxLifetime time.Duration
+	// MaxTxsPerPeer - limit of simultaneously opened txs per client's host
+	MaxTxsPerPeer int
+}
+
+// WithTxLimits - call it before serving requests
+func (s *KvServer) WithTxLimits(limits TxLimits) *KvServer {
+	s.limits = limits
+	return s
+}
+
+func (s *KvServer) touch(id uint64) {
+	s.txsMapLock.RLock()
+	defer s.txsMapLock.RUnlock()
+	if tx, ok := s.txs[id]; ok {
+		tx.touch()
+	}
+}
+
+func (s *KvServer) idle(id uint64) time.Duration {
+	s.txsMapLock.RLock()
+	defer s.txsMapLock.RUnlock()
+	if tx, ok := s.txs[id]; ok {
+		return tx.idle()
+	}
+	return 0
+}
+
+// peerFromContext - returns host of client (without port: every connection of same client has own port)
+func peerFromContext(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,114 @@
+/*
+   Copyright 2021 Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package remotedbserver
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+)
+
+func TestKvServer_TxLimits(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fix me on win please")
+	}
+	ctx, db := context.Background(), memdb.NewTestDB(t)
+	newClient := func(t *testing.T, limits TxLimits) (*KvServer, remote.KVClient) {
+		s := NewKvServer(ctx, db, nil, nil, log.New()).WithTxLimits(limits)
+		conn, grpcServer := bufconn.Listen(1024*1024), grpc.NewServer()
+		remote.RegisterKVServer(grpcServer, s)
+		go grpcServer.Serve(conn) //nolint
+		t.Cleanup(grpcServer.Stop)
+		cc, err := grpc.Dial("", grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithContextDialer(func(ctx context.Context, url string) (net.Conn, error) { return conn.Dial() }))
+		require.NoError(t, err)
+		t.Cleanup(func() { cc.Close() })
+		return s, remote.NewKVClient(cc)
+	}
+	beginTx := func(t *testing.T, client remote.KVClient) (remote.KV_TxClient, uint64, error) {
+		stream, err := client.Tx(ctx)
+		require.NoError(t, err)
+		t.Cleanup(func() { stream.CloseSend() }) //nolint
+		msg, err := stream.Recv()
+		if err != nil {
+			return nil, 0, err
+		}
+		return stream, msg.TxId, nil
+	}
+	openTxs := func(s *KvServer) int {
+		s.txsMapLock.RLock()
+		defer s.txsMapLock.RUnlock()
+		return len(s.txs)
+	}
+
+	t.Run("idle", func(t *testing.T) {
+		s, client := newClient(t, TxLimits{IdleTimeout: 200 * time.Millisecond})
+		stream, id, err := beginTx(t, client)
+		require.NoError(t, err)
+
+		// usage of tx by other methods prevents reaping
+		for i := 0; i < 10; i++ {
+			_, err = client.Range(ctx, &remote.RangeReq{TxId: id, Table: kv.HeaderNumber, OrderAscend: true, Limit: -1})
+			require.NoError(t, err)
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		_, err = stream.Recv()
+		require.Equal(t, codes.DeadlineExceeded, status.Code(err), err)
+		require.Contains(t, err.Error(), "idle")
+		require.Eventually(t, func() bool { return openTxs(s) == 0 }, time.Second, 10*time.Millisecond)
+		_, err = client.Range(ctx, &remote.RangeReq{TxId: id, Table: kv.HeaderNumber, OrderAscend: true, Limit: -1})
+		require.Error(t, err)
+	})
+	t.Run("lifetime", func(t *testing.T) {
+		s, client := newClient(t, TxLimits{MaxLifetime: 200 * time.Millisecond})
+		stream, _, err := beginTx(t, client)
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.Equal(t, codes.DeadlineExceeded, status.Code(err), err)
+		require.Contains(t, err.Error(), "lived")
+		require.Eventually(t, func() bool { return openTxs(s) == 0 }, time.Second, 10*time.Millisecond)
+	})
+	t.Run("per peer", func(t *testing.T) {
+		openBefore := remoteTxsOpen.Get()
+		s, client := newClient(t, TxLimits{MaxTxsPerPeer: 1})
+		stream, _, err := beginTx(t, client)
+		require.NoError(t, err)
+		require.Equal(t, openBefore+1, remoteTxsOpen.Get())
+		_, _, err = beginTx(t, client)
+		require.Equal(t, codes.ResourceExhausted, status.Code(err), err)
+
+		require.NoError(t, stream.CloseSend())
+		require.Eventually(t, func() bool { return openTxs(s) == 0 }, time.Second, 10*time.Millisecond)
+		require.Equal(t, openBefore, remoteTxsOpen.Get())
+		_, _, err = beginTx(t, client)
+		require.NoError(t, err)
+	})
+}
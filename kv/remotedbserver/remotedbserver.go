@@ -17,17 +17,23 @@
 package remotedbserver
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"reflect"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/VictoriaMetrics/metrics"
 	"github.com/ledgerwatch/log/v3"
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
 
@@ -38,6 +44,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/types"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/iter"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
 	"github.com/ledgerwatch/erigon-lib/kv/order"
 )
 
@@ -64,7 +71,12 @@ const MaxTxTTL = 60 * time.Second
 // 6.0.0 - Blocks now have system-txs - in the begin/end of block
 // 6.1.0 - Add methods Range, IndexRange, HistoryGet, HistoryRange
 // 6.2.0 - Add HistoryFiles to reply of Snapshots() method
-var KvServiceAPIVersion = &types.VersionReply{Major: 6, Minor: 2, Patch: 0}
+// 6.3.0 - Add methods RangeDupSort, Sequence, Size and cursor op COUNT_DUPLICATES
+// 6.4.0 - Add server-streaming method Stream
+// 6.5.0 - Implement DomainRange, HistoryRange. IndexRange now respects page_size and clients must send page_token
+// 6.6.0 - Add method Commit (opt-in remote RwTx)
+// 6.7.0 - Add StateChangeRequest.supported_actions handshake
+var KvServiceAPIVersion = &types.VersionReply{Major: 6, Minor: 7, Patch: 0}
 
 type KvServer struct {
 	remote.UnimplementedKVServer // must be embedded to have forward compatible implementations.
@@ -80,6 +92,10 @@ type KvServer struct {
 	txsMapLock *sync.RWMutex
 	txs        map[uint64]*threadSafeTx
 
+	writableTables map[string]struct{} // allow-list for remote RwTx, empty means read-only server
+	acl            *ACL                // nil means: any client has access to all tables
+	limits         TxLimits
+
 	trace     bool
 	rangeStep int // make sure `s.with` has limited time
 	logger    log.Logger
@@ -88,6 +104,14 @@ type KvServer struct {
 type threadSafeTx struct {
 	kv.Tx
 	sync.Mutex
+	client       string       // identity of client which opened tx, empty if ACLs are disabled
+	peer         string       // host of client which opened tx
+	lastActivity atomic.Int64 // unix nanoseconds of last client's request which used this tx
+}
+
+func (tx *threadSafeTx) touch() { tx.lastActivity.Store(time.Now().UnixNano()) }
+func (tx *threadSafeTx) idle() time.Duration {
+	return time.Since(time.Unix(0, tx.lastActivity.Load()))
 }
 
 type Snapsthots interface {
@@ -105,6 +129,16 @@ func NewKvServer(ctx context.Context, db kv.RoDB, snapshots Snapsthots, historyS
 	}
 }
 
+// WithWritableTables - enables remote RwTx (see `Commit` method) for given tables. Call it before serving requests.
+// Server DB must implement kv.RwDB
+func (s *KvServer) WithWritableTables(tables ...string) *KvServer {
+	s.writableTables = make(map[string]struct{}, len(tables))
+	for _, table := range tables {
+		s.writableTables[table] = struct{}{}
+	}
+	return s
+}
+
 // Version returns the service-side interface version number
 func (s *KvServer) Version(context.Context, *emptypb.Empty) (*types.VersionReply, error) {
 	dbSchemaVersion := &kv.DBSchemaVersion
@@ -127,14 +161,39 @@ func (s *KvServer) begin(ctx context.Context) (id uint64, err error) {
 	if s.trace {
 		s.logger.Info(fmt.Sprintf("[kv_server] begin %d %s\n", id, dbg.Stack()))
 	}
+	client, perms, err := s.identify(ctx)
+	if err != nil {
+		return 0, err
+	}
+	peerHost := peerFromContext(ctx)
 	s.txsMapLock.Lock()
 	defer s.txsMapLock.Unlock()
+	if perms.MaxTxs > 0 || s.limits.MaxTxsPerPeer > 0 {
+		var clientTxs, peerTxs int
+		for _, tx := range s.txs {
+			if tx.client == client {
+				clientTxs++
+			}
+			if tx.peer == peerHost {
+				peerTxs++
+			}
+		}
+		if perms.MaxTxs > 0 && clientTxs >= perms.MaxTxs {
+			return 0, status.Errorf(codes.PermissionDenied, "kvserver: client %q reached limit of opened txs %d", client, perms.MaxTxs)
+		}
+		if s.limits.MaxTxsPerPeer > 0 && peerTxs >= s.limits.MaxTxsPerPeer {
+			remoteTxsRejected.Inc()
+			return 0, status.Errorf(codes.ResourceExhausted, "kvserver: peer %s reached limit of opened txs %d", peerHost, s.limits.MaxTxsPerPeer)
+		}
+	}
 	tx, errBegin := s.kv.BeginRo(ctx)
 	if errBegin != nil {
 		return 0, errBegin
 	}
 	id = s.txIdGen.Add(1)
-	s.txs[id] = &threadSafeTx{Tx: tx}
+	s.txs[id] = &threadSafeTx{Tx: tx, client: client, peer: peerHost}
+	s.txs[id].touch()
+	remoteTxsOpen.Inc()
 	return id, nil
 }
 
@@ -145,17 +204,23 @@ func (s *KvServer) renew(ctx context.Context, id uint64) (err error) {
 	}
 	s.txsMapLock.Lock()
 	defer s.txsMapLock.Unlock()
+	renewed := &threadSafeTx{}
 	tx, ok := s.txs[id]
 	if ok {
 		tx.Lock()
 		defer tx.Unlock()
 		tx.Rollback()
+		renewed.client, renewed.peer = tx.client, tx.peer
+	} else {
+		remoteTxsOpen.Inc()
 	}
 	newTx, errBegin := s.kv.BeginRo(ctx)
 	if errBegin != nil {
 		return fmt.Errorf("kvserver: %w", err)
 	}
-	s.txs[id] = &threadSafeTx{Tx: newTx}
+	renewed.Tx = newTx
+	renewed.touch()
+	s.txs[id] = renewed
 	return nil
 }
 
@@ -171,6 +236,7 @@ func (s *KvServer) rollback(id uint64) {
 		defer tx.Unlock()
 		tx.Rollback()
 		delete(s.txs, id)
+		remoteTxsOpen.Dec()
 	}
 }
 
@@ -194,6 +260,7 @@ func (s *KvServer) with(id uint64, f func(kv.Tx) error) error {
 		s.logger.Info(fmt.Sprintf("[kv_server] with %d try lock %s\n", id, dbg.Stack()[:2]))
 	}
 	tx.Lock()
+	tx.touch()
 	if s.trace {
 		s.logger.Info(fmt.Sprintf("[kv_server] with %d can lock %s\n", id, dbg.Stack()[:2]))
 	}
@@ -229,23 +296,66 @@ func (s *KvServer) Tx(stream remote.KV_TxServer) error {
 		bucket string
 		c      kv.Cursor
 		k, v   []byte //fields to save current position of cursor - used when Tx reopen
+		cursorBudget
 	}
 	cursors := map[uint32]*CursorInfo{}
 
 	txTicker := time.NewTicker(MaxTxTTL)
 	defer txTicker.Stop()
 
+	// Recv is blocking - read in background to be able rollback tx of client which doesn't send any requests
+	requests, recvErrs, done := make(chan *remote.Cursor), make(chan error, 1), make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			in, err := stream.Recv()
+			if err != nil {
+				recvErrs <- err
+				return
+			}
+			select {
+			case requests <- in:
+			case <-done:
+				return
+			}
+		}
+	}()
+	var idleTimeout, lifetimeTimeout <-chan time.Time
+	if s.limits.IdleTimeout > 0 {
+		idleTimer := time.NewTimer(s.limits.IdleTimeout)
+		defer idleTimer.Stop()
+		idleTimeout = idleTimer.C
+	}
+	if s.limits.MaxLifetime > 0 {
+		lifetimeTimer := time.NewTimer(s.limits.MaxLifetime)
+		defer lifetimeTimer.Stop()
+		lifetimeTimeout = lifetimeTimer.C
+	}
+
 	// send all items to client, if k==nil - still send it to client and break loop
 	for {
-		in, recvErr := stream.Recv()
-		if recvErr != nil {
+		var in *remote.Cursor
+		select {
+		case in = <-requests:
+			s.touch(id)
+		case recvErr := <-recvErrs:
 			if errors.Is(recvErr, io.EOF) { // termination
 				return nil
 			}
 			return fmt.Errorf("server-side error: %w", recvErr)
+		case <-idleTimeout:
+			// tx may be used by other methods (Range, Stream, ...) - they don't send requests to this stream
+			if idle := s.idle(id); idle < s.limits.IdleTimeout {
+				idleTimeout = time.After(s.limits.IdleTimeout - idle)
+				continue
+			}
+			remoteTxsReaped.Inc()
+			return status.Errorf(codes.DeadlineExceeded, "kvserver: txn %d rolled back: idle more than %s", id, s.limits.IdleTimeout)
+		case <-lifetimeTimeout:
+			remoteTxsReaped.Inc()
+			return status.Errorf(codes.DeadlineExceeded, "kvserver: txn %d rolled back: lived more than %s", id, s.limits.MaxLifetime)
 		}
 
-		//TODO: protect against client - which doesn't send any requests
 		select {
 		default:
 		case <-txTicker.C:
@@ -298,12 +408,20 @@ func (s *KvServer) Tx(stream remote.KV_TxServer) error {
 			if !ok {
 				return fmt.Errorf("server-side error: unknown Cursor=%d, Op=%s", in.Cursor, in.Op)
 			}
+			if in.Op != remote.Op_CLOSE {
+				if err := cInfo.readCursor(); err != nil {
+					return err
+				}
+			}
 			c = cInfo.c
 		}
 		switch in.Op {
 		case remote.Op_OPEN:
+			perms, err := s.authorize(stream.Context(), id, in.BucketName)
+			if err != nil {
+				return err
+			}
 			CursorID++
-			var err error
 			if err := s.with(id, func(tx kv.Tx) error {
 				c, err = tx.Cursor(in.BucketName)
 				if err != nil {
@@ -314,16 +432,20 @@ func (s *KvServer) Tx(stream remote.KV_TxServer) error {
 				return fmt.Errorf("kvserver: %w", err)
 			}
 			cursors[CursorID] = &CursorInfo{
-				bucket: in.BucketName,
-				c:      c,
+				bucket:       in.BucketName,
+				c:            c,
+				cursorBudget: cursorBudget{maxRange: perms.MaxRange},
 			}
 			if err := stream.Send(&remote.Pair{CursorId: CursorID}); err != nil {
 				return fmt.Errorf("kvserver: %w", err)
 			}
 			continue
 		case remote.Op_OPEN_DUP_SORT:
+			perms, err := s.authorize(stream.Context(), id, in.BucketName)
+			if err != nil {
+				return err
+			}
 			CursorID++
-			var err error
 			if err := s.with(id, func(tx kv.Tx) error {
 				c, err = tx.CursorDupSort(in.BucketName)
 				if err != nil {
@@ -334,8 +456,9 @@ func (s *KvServer) Tx(stream remote.KV_TxServer) error {
 				return fmt.Errorf("kvserver: %w", err)
 			}
 			cursors[CursorID] = &CursorInfo{
-				bucket: in.BucketName,
-				c:      c,
+				bucket:       in.BucketName,
+				c:            c,
+				cursorBudget: cursorBudget{maxRange: perms.MaxRange},
 			}
 			if err := stream.Send(&remote.Pair{CursorId: CursorID}); err != nil {
 				return fmt.Errorf("server-side error: %w", err)
@@ -387,16 +510,10 @@ func handleOp(c kv.Cursor, stream remote.KV_TxServer, in *remote.Cursor) error {
 		k, v, err = c.(kv.CursorDupSort).NextNoDup()
 	case remote.Op_PREV:
 		k, v, err = c.Prev()
-	//case remote.Op_PREV_DUP:
-	//	k, v, err = c.(ethdb.CursorDupSort).Prev()
-	//	if err != nil {
-	//		return err
-	//	}
-	//case remote.Op_PREV_NO_DUP:
-	//	k, v, err = c.Prev()
-	//	if err != nil {
-	//		return err
-	//	}
+	case remote.Op_PREV_DUP:
+		k, v, err = c.(kv.CursorDupSort).PrevDup()
+	case remote.Op_PREV_NO_DUP:
+		k, v, err = c.(kv.CursorDupSort).PrevNoDup()
 	case remote.Op_SEEK_EXACT:
 		k, v, err = c.SeekExact(in.K)
 	case remote.Op_SEEK_BOTH_EXACT:
@@ -407,6 +524,12 @@ func handleOp(c kv.Cursor, stream remote.KV_TxServer, in *remote.Cursor) error {
 			return err
 		}
 		v = hexutility.EncodeTs(cnt)
+	case remote.Op_COUNT_DUPLICATES:
+		cnt, err := c.(kv.CursorDupSort).CountDuplicates()
+		if err != nil {
+			return err
+		}
+		v = hexutility.EncodeTs(cnt)
 	default:
 		return fmt.Errorf("unknown operation: %s", in.Op)
 	}
@@ -430,12 +553,57 @@ func bytesCopy(b []byte) []byte {
 	return copiedBytes
 }
 
+// legacyActions - actions known by consumers which don't send StateChangeRequest.SupportedActions
+var legacyActions = []remote.Action{remote.Action_STORAGE, remote.Action_UPSERT, remote.Action_CODE, remote.Action_UPSERT_CODE, remote.Action_REMOVE}
+
+var stateChangesUnsupportedActions = metrics.GetOrCreateCounter(`kv_state_changes_unsupported_actions`)
+
+// filterActions - removes changes with actions which are not in `supported` list.
+// Batch is shared by all subscribers: it's never modified, copy is returned if something was removed
+func filterActions(batch *remote.StateChangeBatch, supported []remote.Action) *remote.StateChangeBatch {
+	if !hasUnsupportedAction(batch, supported) {
+		return batch
+	}
+	batch = proto.Clone(batch).(*remote.StateChangeBatch)
+	for _, sc := range batch.ChangeBatch {
+		changes := sc.Changes[:0]
+		for _, change := range sc.Changes {
+			if !slices.Contains(supported, change.Action) {
+				stateChangesUnsupportedActions.Inc()
+				continue
+			}
+			changes = append(changes, change)
+		}
+		sc.Changes = changes
+	}
+	return batch
+}
+
+func hasUnsupportedAction(batch *remote.StateChangeBatch, supported []remote.Action) bool {
+	for _, sc := range batch.ChangeBatch {
+		for _, change := range sc.Changes {
+			if !slices.Contains(supported, change.Action) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (s *KvServer) StateChanges(req *remote.StateChangeRequest, server remote.KV_StateChangesServer) error {
+	if _, err := s.authorize(server.Context(), 0, ""); err != nil {
+		return err
+	}
+	// consumer which did handshake tolerates unknown actions, legacy one would crash on them: they are not sent to it
+	legacy := len(req.SupportedActions) == 0
 	ch, remove := s.stateChangeStreams.Sub()
 	defer remove()
 	for {
 		select {
 		case reply := <-ch:
+			if legacy {
+				reply = filterActions(reply, legacyActions)
+			}
 			if err := server.Send(reply); err != nil {
 				return err
 			}
@@ -452,6 +620,9 @@ func (s *KvServer) SendStateChanges(ctx context.Context, sc *remote.StateChangeB
 }
 
 func (s *KvServer) Snapshots(ctx context.Context, _ *remote.SnapshotsRequest) (*remote.SnapshotsReply, error) {
+	if _, err := s.authorize(ctx, 0, ""); err != nil {
+		return nil, err
+	}
 	if s.blockSnapshots == nil || reflect.ValueOf(s.blockSnapshots).IsNil() { // nolint
 		return &remote.SnapshotsReply{BlocksFiles: []string{}, HistoryFiles: []string{}}, nil
 	}
@@ -509,6 +680,9 @@ func (s *StateChangePubSub) remove(id uint) {
 
 // Temporal methods
 func (s *KvServer) DomainGet(ctx context.Context, req *remote.DomainGetReq) (reply *remote.DomainGetReply, err error) {
+	if _, err := s.authorize(ctx, req.TxId, req.Table); err != nil {
+		return nil, err
+	}
 	reply = &remote.DomainGetReply{}
 	if err := s.with(req.TxId, func(tx kv.Tx) error {
 		ttx, ok := tx.(kv.TemporalTx)
@@ -533,6 +707,9 @@ func (s *KvServer) DomainGet(ctx context.Context, req *remote.DomainGetReq) (rep
 	return reply, nil
 }
 func (s *KvServer) HistoryGet(ctx context.Context, req *remote.HistoryGetReq) (reply *remote.HistoryGetReply, err error) {
+	if _, err := s.authorize(ctx, req.TxId, req.Table); err != nil {
+		return nil, err
+	}
 	reply = &remote.HistoryGetReply{}
 	if err := s.with(req.TxId, func(tx kv.Tx) error {
 		ttx, ok := tx.(kv.TemporalTx)
@@ -562,6 +739,10 @@ func (s *KvServer) IndexRange(ctx context.Context, req *remote.IndexRangeReq) (*
 		}
 		from, limit = int(pagination.NextTimeStamp), int(pagination.Limit)
 	}
+	limit, err := s.authorizeRange(ctx, req.TxId, req.Table, limit)
+	if err != nil {
+		return nil, err
+	}
 	if req.PageSize <= 0 || req.PageSize > PageSizeLimit {
 		req.PageSize = PageSizeLimit
 	}
@@ -575,7 +756,7 @@ func (s *KvServer) IndexRange(ctx context.Context, req *remote.IndexRangeReq) (*
 		if err != nil {
 			return err
 		}
-		for it.HasNext() {
+		for len(reply.Timestamps) < int(req.PageSize) && it.HasNext() {
 			v, err := it.Next()
 			if err != nil {
 				return err
@@ -583,7 +764,7 @@ func (s *KvServer) IndexRange(ctx context.Context, req *remote.IndexRangeReq) (*
 			reply.Timestamps = append(reply.Timestamps, v)
 			limit--
 		}
-		if len(reply.Timestamps) == PageSizeLimit && it.HasNext() {
+		if it.HasNext() {
 			next, err := it.Next()
 			if err != nil {
 				return err
@@ -600,6 +781,141 @@ func (s *KvServer) IndexRange(ctx context.Context, req *remote.IndexRangeReq) (*
 	return reply, nil
 }
 
+func (s *KvServer) DomainRange(ctx context.Context, req *remote.DomainRangeReq) (*remote.Pairs, error) {
+	from, limit := req.FromKey, int(req.Limit)
+	if limit <= 0 {
+		limit = -1
+	}
+	if req.PageToken != "" {
+		var pagination remote.ParisPagination
+		if err := unmarshalPagination(req.PageToken, &pagination); err != nil {
+			return nil, err
+		}
+		from, limit = pagination.NextKey, int(pagination.Limit)
+	}
+	limit, err := s.authorizeRange(ctx, req.TxId, req.Table, limit)
+	if err != nil {
+		return nil, err
+	}
+	if req.PageSize <= 0 || req.PageSize > PageSizeLimit {
+		req.PageSize = PageSizeLimit
+	}
+	ts := req.Ts
+	if req.Latest {
+		ts = math.MaxUint64
+	}
+
+	reply := &remote.Pairs{}
+	if err := s.with(req.TxId, func(tx kv.Tx) error {
+		ttx, ok := tx.(kv.TemporalTx)
+		if !ok {
+			return fmt.Errorf("server DB doesn't implement kv.Temporal interface")
+		}
+		it, err := ttx.DomainRange(kv.Domain(req.Table), from, req.ToKey, ts, order.By(req.OrderAscend), limit)
+		if err != nil {
+			return err
+		}
+		if casted, ok := it.(kv.Closer); ok {
+			defer casted.Close()
+		}
+		for len(reply.Keys) < int(req.PageSize) && it.HasNext() {
+			k, v, err := it.Next()
+			if err != nil {
+				return err
+			}
+			reply.Keys = append(reply.Keys, bytesCopy(k))
+			reply.Values = append(reply.Values, bytesCopy(v))
+			limit--
+		}
+		if it.HasNext() {
+			nextK, _, err := it.Next()
+			if err != nil {
+				return err
+			}
+			reply.NextPageToken, err = marshalPagination(&remote.ParisPagination{NextKey: nextK, Limit: int64(limit)})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// HistoryRange - pairs are ordered by key, but kv.TemporalTx.HistoryRange has no `fromKey` parameter:
+// next page re-creates iterator and seeks to `NextKey` of pagination token (or skips keys before it - if iterator is not Seekable).
+func (s *KvServer) HistoryRange(ctx context.Context, req *remote.HistoryRangeReq) (*remote.Pairs, error) {
+	var nextKey []byte
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = -1
+	}
+	if req.PageToken != "" {
+		var pagination remote.ParisPagination
+		if err := unmarshalPagination(req.PageToken, &pagination); err != nil {
+			return nil, err
+		}
+		nextKey, limit = pagination.NextKey, int(pagination.Limit)
+	}
+	limit, err := s.authorizeRange(ctx, req.TxId, req.Table, limit)
+	if err != nil {
+		return nil, err
+	}
+	if req.PageSize <= 0 || req.PageSize > PageSizeLimit {
+		req.PageSize = PageSizeLimit
+	}
+
+	reply := &remote.Pairs{}
+	if err := s.with(req.TxId, func(tx kv.Tx) error {
+		ttx, ok := tx.(kv.TemporalTx)
+		if !ok {
+			return fmt.Errorf("server DB doesn't implement kv.Temporal interface")
+		}
+		it, err := ttx.HistoryRange(kv.History(req.Table), int(req.FromTs), int(req.ToTs), order.By(req.OrderAscend), -1)
+		if err != nil {
+			return err
+		}
+		if casted, ok := it.(kv.Closer); ok {
+			defer casted.Close()
+		}
+		if seekable, ok := it.(iter.SeekableKV); ok && nextKey != nil && req.OrderAscend {
+			seekable.Seek(nextKey)
+			nextKey = nil
+		}
+		for len(reply.Keys) < int(req.PageSize) && limit != 0 && it.HasNext() {
+			k, v, err := it.Next()
+			if err != nil {
+				return err
+			}
+			if nextKey != nil {
+				if cmp := bytes.Compare(k, nextKey); (req.OrderAscend && cmp < 0) || (!req.OrderAscend && cmp > 0) {
+					continue
+				}
+				nextKey = nil
+			}
+			reply.Keys = append(reply.Keys, bytesCopy(k))
+			reply.Values = append(reply.Values, bytesCopy(v))
+			limit--
+		}
+		if limit != 0 && it.HasNext() {
+			nextK, _, err := it.Next()
+			if err != nil {
+				return err
+			}
+			reply.NextPageToken, err = marshalPagination(&remote.ParisPagination{NextKey: nextK, Limit: int64(limit)})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
 func (s *KvServer) Range(ctx context.Context, req *remote.RangeReq) (*remote.Pairs, error) {
 	from, limit := req.FromPrefix, int(req.Limit)
 	if req.PageToken != "" {
@@ -609,12 +925,15 @@ func (s *KvServer) Range(ctx context.Context, req *remote.RangeReq) (*remote.Pai
 		}
 		from, limit = pagination.NextKey, int(pagination.Limit)
 	}
+	limit, err := s.authorizeRange(ctx, req.TxId, req.Table, limit)
+	if err != nil {
+		return nil, err
+	}
 	if req.PageSize <= 0 || req.PageSize > PageSizeLimit {
 		req.PageSize = PageSizeLimit
 	}
 
 	reply := &remote.Pairs{}
-	var err error
 	if err = s.with(req.TxId, func(tx kv.Tx) error {
 		var it iter.KV
 		if req.OrderAscend {
@@ -654,6 +973,262 @@ func (s *KvServer) Range(ctx context.Context, req *remote.RangeReq) (*remote.Pai
 	return reply, nil
 }
 
+// Stream - server-side streaming version of Range. Every batch is read in separated `s.with` call (to not block
+// other users of `tx` while client consumes data) and next batch continues from last sent key.
+// Amount of in-flight batches is limited by HTTP/2 flow control: `stream.Send` blocks until client reads.
+func (s *KvServer) Stream(req *remote.RangeReq, stream remote.KV_StreamServer) error {
+	ctx := stream.Context()
+	pageSize, limit := int(req.PageSize), int(req.Limit)
+	limit, err := s.authorizeRange(ctx, req.TxId, req.Table, limit)
+	if err != nil {
+		return err
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+	if pageSize <= 0 || pageSize > PageSizeLimit {
+		pageSize = PageSizeLimit
+	}
+
+	// every batch is read in own `s.with` call (tx can't be used by other requests while locked):
+	// next batch continues right after last sent pair - by seek, not by re-reading already sent pairs
+	var lastK, lastV []byte
+	for limit != 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		reply := &remote.Pairs{}
+		var eof bool
+		if err := s.with(req.TxId, func(tx kv.Tx) error {
+			c, err := tx.Cursor(req.Table)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			next := c.Prev
+			if req.OrderAscend {
+				next = c.Next
+			}
+
+			var k, v []byte
+			if lastK == nil {
+				k, v, err = streamSeek(c, req.FromPrefix, req.OrderAscend)
+			} else {
+				k, v, err = streamResume(c, lastK, lastV, req.OrderAscend)
+			}
+			for ; len(reply.Keys) < pageSize && limit != 0; k, v, err = next() {
+				if err != nil {
+					return err
+				}
+				if !streamInRange(k, req.ToPrefix, req.OrderAscend) {
+					eof = true
+					return nil
+				}
+				lastK, lastV = bytesCopy(k), bytesCopy(v)
+				reply.Keys = append(reply.Keys, lastK)
+				reply.Values = append(reply.Values, lastV)
+				limit--
+			}
+			if err != nil {
+				return err
+			}
+			eof = !streamInRange(k, req.ToPrefix, req.OrderAscend)
+			return nil
+		}); err != nil {
+			return err
+		}
+		if len(reply.Keys) > 0 {
+			if err := stream.Send(reply); err != nil {
+				return err
+			}
+		}
+		if eof {
+			return nil
+		}
+	}
+	return nil
+}
+
+// streamSeek - positions cursor to first pair of stream. Same semantic as tx.RangeAscend/tx.RangeDescend
+func streamSeek(c kv.Cursor, from []byte, asc bool) ([]byte, []byte, error) {
+	if from == nil {
+		if asc {
+			return c.First()
+		}
+		return c.Last()
+	}
+	k, v, err := c.Seek(from)
+	if err != nil || asc {
+		return k, v, err
+	}
+	// desc: exactly given key (last value of it) or previous one
+	if k == nil {
+		return c.Last()
+	}
+	if !bytes.Equal(k, from) {
+		return c.Prev()
+	}
+	if casted, ok := c.(kv.CursorDupSort); ok {
+		v, err = casted.LastDup()
+	}
+	return k, v, err
+}
+
+// streamResume - positions cursor right after last sent pair: by seek to (lastK, lastV), because
+// in DupSort tables lastK may have many values and re-reading them on every batch is O(n^2)
+func streamResume(c kv.Cursor, lastK, lastV []byte, asc bool) (k, v []byte, err error) {
+	if casted, ok := c.(kv.CursorDupSort); ok {
+		k, v, err = casted.SeekBothExact(lastK, lastV)
+	} else {
+		k, v, err = c.Seek(lastK)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if k == nil || !bytes.Equal(k, lastK) { // read-only tx: sent pair can't disappear
+		return nil, nil, fmt.Errorf("kvserver: stream can't find last sent key %x", lastK)
+	}
+	if asc {
+		return c.Next()
+	}
+	return c.Prev()
+}
+
+// streamInRange - Asc: [from, to), Desc: [from, to) and from > to
+func streamInRange(k, to []byte, asc bool) bool {
+	if k == nil {
+		return false
+	}
+	if to == nil {
+		return true
+	}
+	cmp := bytes.Compare(k, to)
+	return (asc && cmp < 0) || (!asc && cmp > 0)
+}
+
+func (s *KvServer) RangeDupSort(ctx context.Context, req *remote.RangeDupSortReq) (*remote.Pairs, error) {
+	from, limit := req.FromPrefix, int(req.Limit)
+	if req.PageToken != "" {
+		var pagination remote.ParisPagination
+		if err := unmarshalPagination(req.PageToken, &pagination); err != nil {
+			return nil, err
+		}
+		from, limit = pagination.NextKey, int(pagination.Limit)
+	}
+	limit, err := s.authorizeRange(ctx, req.TxId, req.Table, limit)
+	if err != nil {
+		return nil, err
+	}
+	if req.PageSize <= 0 || req.PageSize > PageSizeLimit {
+		req.PageSize = PageSizeLimit
+	}
+
+	reply := &remote.Pairs{}
+	if err := s.with(req.TxId, func(tx kv.Tx) error {
+		it, err := tx.RangeDupSort(req.Table, req.Key, from, req.ToPrefix, order.By(req.OrderAscend), limit)
+		if err != nil {
+			return err
+		}
+		for len(reply.Values) < int(req.PageSize) && it.HasNext() {
+			_, v, err := it.Next()
+			if err != nil {
+				return err
+			}
+			reply.Values = append(reply.Values, bytesCopy(v))
+			limit--
+		}
+		if len(reply.Values) == int(req.PageSize) && it.HasNext() {
+			_, nextV, err := it.Next()
+			if err != nil {
+				return err
+			}
+			reply.NextPageToken, err = marshalPagination(&remote.ParisPagination{NextKey: nextV, Limit: int64(limit)})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+func (s *KvServer) Sequence(ctx context.Context, req *remote.SequenceReq) (reply *remote.SequenceReply, err error) {
+	if _, err := s.authorize(ctx, req.TxId, req.Table); err != nil {
+		return nil, err
+	}
+	reply = &remote.SequenceReply{}
+	if err := s.with(req.TxId, func(tx kv.Tx) error {
+		reply.Value, err = tx.ReadSequence(req.Table)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+func (s *KvServer) Size(ctx context.Context, req *remote.SizeReq) (reply *remote.SizeReply, err error) {
+	if _, err := s.authorize(ctx, req.TxId, req.Table); err != nil {
+		return nil, err
+	}
+	reply = &remote.SizeReply{}
+	if err := s.with(req.TxId, func(tx kv.Tx) error {
+		if req.Table == "" {
+			reply.Size, err = tx.DBSize()
+			return err
+		}
+		reply.Size, err = tx.BucketSize(req.Table)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// Commit - applies client's writes in one server-side RwTx. Conflict detection is optimistic and coarse:
+// client's writes are rejected if any other RwTx was committed after client's read view.
+func (s *KvServer) Commit(ctx context.Context, req *remote.CommitReq) (*remote.CommitReply, error) {
+	if req.ViewId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "kvserver: view_id is required")
+	}
+	if len(s.writableTables) == 0 {
+		return nil, status.Error(codes.PermissionDenied, "kvserver: remote writes are disabled")
+	}
+	db, ok := s.kv.(kv.RwDB)
+	if !ok {
+		return nil, status.Error(codes.FailedPrecondition, "kvserver: server DB is read-only")
+	}
+	diff := &memdb.MemoryDiff{}
+	if err := diff.UnmarshalBinary(req.Diff); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	for _, table := range diff.Tables() {
+		if _, ok := s.writableTables[table]; !ok {
+			return nil, status.Errorf(codes.PermissionDenied, "kvserver: table %s is not writable", table)
+		}
+		if _, err := s.authorize(ctx, 0, table); err != nil {
+			return nil, err
+		}
+	}
+
+	reply := &remote.CommitReply{}
+	if err := db.Update(ctx, func(tx kv.RwTx) error {
+		// write lock is held: nobody can commit until we are done. ViewID of RwTx is next after last committed one
+		if tx.ViewID()-1 != req.ViewId {
+			return status.Errorf(codes.Aborted, "kvserver: db was modified after view %d", req.ViewId)
+		}
+		reply.ViewId = tx.ViewID()
+		return diff.Flush(tx)
+	}); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
 // see: https://cloud.google.com/apis/design/design_patterns
 func marshalPagination(m proto.Message) (string, error) {
 	pageToken, err := proto.Marshal(m)
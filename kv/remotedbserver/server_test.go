@@ -20,12 +20,17 @@ import (
 	"context"
 	"runtime"
 	"testing"
+	"time"
 
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestKvServer_renew(t *testing.T) {
@@ -95,3 +100,197 @@ func TestKvServer_renew(t *testing.T) {
 	}
 	require.NoError(g.Wait())
 }
+
+func TestKvServer_RangeDupSortPagination(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fix me on win please")
+	}
+
+	require, ctx, db := require.New(t), context.Background(), memdb.NewTestDB(t)
+	require.NoError(db.Update(ctx, func(tx kv.RwTx) error {
+		wc, err := tx.RwCursorDupSort(kv.PlainState)
+		require.NoError(err)
+		for i := byte(0); i < 5; i++ {
+			require.NoError(wc.Append([]byte{1}, []byte{i}))
+		}
+		return nil
+	}))
+
+	s := NewKvServer(ctx, db, nil, nil, log.New())
+	id, err := s.begin(ctx)
+	require.NoError(err)
+	defer s.rollback(id)
+
+	for _, asc := range []bool{true, false} {
+		var values [][]byte
+		req := &remote.RangeDupSortReq{TxId: id, Table: kv.PlainState, Key: []byte{1}, OrderAscend: asc, Limit: 4, PageSize: 2}
+		for {
+			reply, err := s.RangeDupSort(ctx, req)
+			require.NoError(err)
+			require.LessOrEqual(len(reply.Values), 2)
+			values = append(values, reply.Values...)
+			if reply.NextPageToken == "" {
+				break
+			}
+			req.PageToken = reply.NextPageToken
+		}
+		if asc {
+			require.Equal([][]byte{{0}, {1}, {2}, {3}}, values)
+		} else {
+			require.Equal([][]byte{{4}, {3}, {2}, {1}}, values)
+		}
+	}
+}
+
+type collectStreamServer struct {
+	grpc.ServerStream
+	ctx     context.Context
+	batches []*remote.Pairs
+}
+
+func (s *collectStreamServer) Context() context.Context { return s.ctx }
+func (s *collectStreamServer) Send(p *remote.Pairs) error {
+	s.batches = append(s.batches, p)
+	return nil
+}
+
+func TestKvServer_Stream(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fix me on win please")
+	}
+
+	require, ctx, db := require.New(t), context.Background(), memdb.NewTestDB(t)
+	require.NoError(db.Update(ctx, func(tx kv.RwTx) error {
+		wc, err := tx.RwCursorDupSort(kv.AccountChangeSet)
+		require.NoError(err)
+		for i := byte(0); i < 5; i++ {
+			require.NoError(wc.Append([]byte{1}, []byte{i}))
+		}
+		require.NoError(wc.Append([]byte{2}, []byte{0}))
+		return nil
+	}))
+
+	s := NewKvServer(ctx, db, nil, nil, log.New())
+	id, err := s.begin(ctx)
+	require.NoError(err)
+	defer s.rollback(id)
+
+	// batches boundaries are in the middle of duplicates of same key
+	for _, asc := range []bool{true, false} {
+		stream := &collectStreamServer{ctx: ctx}
+		require.NoError(s.Stream(&remote.RangeReq{TxId: id, Table: kv.AccountChangeSet, OrderAscend: asc, Limit: -1, PageSize: 2}, stream))
+		require.Equal(3, len(stream.batches))
+		var keys, values [][]byte
+		for _, b := range stream.batches {
+			keys, values = append(keys, b.Keys...), append(values, b.Values...)
+		}
+		if asc {
+			require.Equal([][]byte{{1}, {1}, {1}, {1}, {1}, {2}}, keys)
+			require.Equal([][]byte{{0}, {1}, {2}, {3}, {4}, {0}}, values)
+		} else {
+			require.Equal([][]byte{{2}, {1}, {1}, {1}, {1}, {1}}, keys)
+			require.Equal([][]byte{{0}, {4}, {3}, {2}, {1}, {0}}, values)
+		}
+	}
+
+	stream := &collectStreamServer{ctx: ctx}
+	require.NoError(s.Stream(&remote.RangeReq{TxId: id, Table: kv.AccountChangeSet, OrderAscend: true, Limit: 3, PageSize: 2}, stream))
+	require.Equal([][]byte{{0}, {1}}, stream.batches[0].Values)
+	require.Equal([][]byte{{2}}, stream.batches[1].Values)
+
+	// `to` is checked on every batch
+	stream = &collectStreamServer{ctx: ctx}
+	require.NoError(s.Stream(&remote.RangeReq{TxId: id, Table: kv.AccountChangeSet, FromPrefix: []byte{1}, ToPrefix: []byte{2}, OrderAscend: true, Limit: -1, PageSize: 2}, stream))
+	require.Equal(3, len(stream.batches))
+	require.Equal([][]byte{{4}}, stream.batches[2].Values)
+	stream = &collectStreamServer{ctx: ctx}
+	require.NoError(s.Stream(&remote.RangeReq{TxId: id, Table: kv.AccountChangeSet, FromPrefix: []byte{2}, ToPrefix: []byte{1}, OrderAscend: false, Limit: -1, PageSize: 2}, stream))
+	require.Equal(1, len(stream.batches))
+	require.Equal([][]byte{{0}}, stream.batches[0].Values)
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	stream = &collectStreamServer{ctx: canceledCtx}
+	require.ErrorIs(s.Stream(&remote.RangeReq{TxId: id, Table: kv.AccountChangeSet, OrderAscend: true, Limit: -1}, stream), context.Canceled)
+	require.Empty(stream.batches)
+}
+
+func TestKvServer_Commit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fix me on win please")
+	}
+
+	require, ctx, db := require.New(t), context.Background(), memdb.NewTestDB(t)
+	s := NewKvServer(ctx, db, nil, nil, log.New()).WithWritableTables(kv.HeaderNumber)
+	var diff []byte
+	require.NoError(db.View(ctx, func(tx kv.Tx) (err error) {
+		batch := memdb.NewMemoryBatch(tx, t.TempDir())
+		defer batch.Rollback()
+		require.NoError(batch.Put(kv.HeaderNumber, []byte{1}, []byte{1}))
+		memDiff, err := batch.Diff()
+		require.NoError(err)
+		diff, err = memDiff.MarshalBinary()
+		return err
+	}))
+
+	_, err := s.Commit(ctx, &remote.CommitReq{Diff: diff})
+	require.Equal(codes.InvalidArgument, status.Code(err))
+
+	var viewID uint64
+	require.NoError(db.View(ctx, func(tx kv.Tx) error {
+		viewID = tx.ViewID()
+		return nil
+	}))
+	reply, err := s.Commit(ctx, &remote.CommitReq{ViewId: viewID, Diff: diff})
+	require.NoError(err)
+	require.Greater(reply.ViewId, viewID)
+
+	// view is outdated after previous commit
+	_, err = s.Commit(ctx, &remote.CommitReq{ViewId: viewID, Diff: diff})
+	require.Equal(codes.Aborted, status.Code(err))
+}
+
+type stateChangesServer struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *remote.StateChangeBatch
+}
+
+func (s *stateChangesServer) Context() context.Context { return s.ctx }
+func (s *stateChangesServer) Send(b *remote.StateChangeBatch) error {
+	s.sent <- b
+	return nil
+}
+
+func TestKvServer_StateChangesHandshake(t *testing.T) {
+	ctx := context.Background()
+	s := NewKvServer(ctx, memdb.NewTestDB(t), nil, nil, log.New())
+	newAction := remote.Action(100) // unknown for all consumers
+	batch := &remote.StateChangeBatch{ChangeBatch: []*remote.StateChange{{Changes: []*remote.AccountChange{{Action: remote.Action_UPSERT}, {Action: newAction}}}}}
+	subscribe := func(req *remote.StateChangeRequest) (*stateChangesServer, chan error, context.CancelFunc) {
+		streamCtx, cancel := context.WithCancel(ctx)
+		stream, done := &stateChangesServer{ctx: streamCtx, sent: make(chan *remote.StateChangeBatch, 1)}, make(chan error, 1)
+		go func() { done <- s.StateChanges(req, stream) }()
+		require.Eventually(t, func() bool { return s.stateChangeStreams.Len() == 1 }, time.Second, time.Millisecond)
+		return stream, done, cancel
+	}
+
+	// legacy consumer would panic on unknown action: it's not sent, but stream stays open
+	stream, done, cancel := subscribe(&remote.StateChangeRequest{})
+	s.SendStateChanges(ctx, batch)
+	changes := (<-stream.sent).ChangeBatch[0].Changes
+	require.Equal(t, 1, len(changes))
+	require.Equal(t, remote.Action_UPSERT, changes[0].Action)
+	require.Equal(t, 2, len(batch.ChangeBatch[0].Changes)) // shared batch is not modified
+	require.Equal(t, 1, s.stateChangeStreams.Len())
+	cancel()
+	require.NoError(t, <-done)
+	require.Eventually(t, func() bool { return s.stateChangeStreams.Len() == 0 }, time.Second, time.Millisecond)
+
+	// consumer which did handshake tolerates unknown actions
+	stream, done, cancel = subscribe(&remote.StateChangeRequest{SupportedActions: []remote.Action{remote.Action_UPSERT}})
+	s.SendStateChanges(ctx, batch)
+	require.Equal(t, newAction, (<-stream.sent).ChangeBatch[0].Changes[1].Action)
+	cancel()
+	require.NoError(t, <-done)
+}
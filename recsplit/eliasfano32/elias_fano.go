@@ -266,20 +266,41 @@ func (ef *EliasFano) Count() uint64 {
 func (ef *EliasFano) Iterator() *EliasFanoIter {
 	return &EliasFanoIter{ef: ef, upperMask: 1, upperStep: uint64(1) << ef.l, lowerBits: ef.lowerBits, upperBits: ef.upperBits, count: ef.count, l: ef.l, lowerBitsMask: ef.lowerBitsMask}
 }
-func (ef *EliasFano) ReverseIterator() *iter.ArrStream[uint64] {
-	//TODO: this is very un-optimal, need implement proper reverse-iterator
-	it := ef.Iterator()
-	var values []uint64
-	for it.HasNext() {
-		v, err := it.Next()
-		if err != nil {
-			panic(err)
-		}
-		values = append(values, v)
+func (ef *EliasFano) ReverseIterator() *EliasFanoReverseIter {
+	return &EliasFanoReverseIter{ef: ef, idx: int64(ef.count)}
+}
+
+var _ iter.SeekableU64 = (*EliasFanoReverseIter)(nil) // compile-time interface check
+
+// EliasFanoReverseIter - iterates values from biggest to smallest. Every value is found by index (see Get)
+type EliasFanoReverseIter struct {
+	ef  *EliasFano
+	idx int64 // index of next value, -1 - no more values
+}
+
+func (efi *EliasFanoReverseIter) HasNext() bool { return efi.idx >= 0 }
+
+func (efi *EliasFanoReverseIter) Next() (uint64, error) {
+	v := efi.ef.Get(uint64(efi.idx))
+	efi.idx--
+	return v, nil
+}
+
+// Seek - next value will be the biggest one which is <= n
+func (efi *EliasFanoReverseIter) Seek(n uint64) {
+	v, i, ok := efi.ef.search(n)
+	switch {
+	case !ok: // n > Max
+		efi.idx = int64(efi.ef.count)
+	case v == n:
+		efi.idx = int64(i)
+	default:
+		efi.idx = int64(i) - 1
 	}
-	return iter.ReverseArray[uint64](values)
 }
 
+var _ iter.SeekableU64 = (*EliasFanoIter)(nil) // compile-time interface check
+
 type EliasFanoIter struct {
 	ef        *EliasFano
 	lowerBits []uint64
@@ -226,6 +226,30 @@ func TestIterator(t *testing.T) {
 		iter2.Seek(1024)
 		require.False(t, iter2.HasNext())
 	})
+
+	t.Run("reverse seek", func(t *testing.T) {
+		it := ef.ReverseIterator()
+		it.Seek(1024)
+		n, err := it.Next()
+		require.NoError(t, err)
+		require.Equal(t, 62, int(n))
+
+		it.Seek(40)
+		n, err = it.Next()
+		require.NoError(t, err)
+		require.Equal(t, 39, int(n))
+		n, err = it.Next()
+		require.NoError(t, err)
+		require.Equal(t, 37, int(n))
+
+		it.Seek(4)
+		n, err = it.Next()
+		require.NoError(t, err)
+		require.Equal(t, 4, int(n))
+
+		it.Seek(0)
+		require.False(t, it.HasNext())
+	})
 }
 
 func TestIteratorAndSeekAreBasedOnSameFields(t *testing.T) {
@@ -43,6 +43,8 @@ import (
 	"github.com/ledgerwatch/erigon-lib/compress"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/bitmapdb"
+	"github.com/ledgerwatch/erigon-lib/kv/iter"
+	"github.com/ledgerwatch/erigon-lib/kv/order"
 	"github.com/ledgerwatch/erigon-lib/recsplit"
 )
 
@@ -51,6 +53,7 @@ type filesItem struct {
 	decompressor *compress.Decompressor
 	index        *recsplit.Index
 	bindex       *BtIndex
+	existence    *ExistenceFilter // optional, may be nil
 	startTxNum   uint64
 	endTxNum     uint64
 
@@ -110,6 +113,15 @@ func (i *filesItem) closeFilesAndRemove() {
 		}
 		i.bindex = nil
 	}
+	if i.existence != nil {
+		i.existence.Close()
+		if !i.frozen {
+			if err := os.Remove(i.existence.FilePath); err != nil {
+				log.Trace("close", "err", err, "file", i.existence.FileName)
+			}
+		}
+		i.existence = nil
+	}
 }
 
 type DomainStats struct {
@@ -346,6 +358,17 @@ func (d *Domain) openFiles() (err error) {
 				}
 				//totalKeys += item.bindex.KeyCount()
 			}
+			if item.existence == nil {
+				existencePath := filepath.Join(d.dir, fmt.Sprintf("%s.%d-%d.kvei", d.filenameBase, fromStep, toStep))
+				if dir.FileExist(existencePath) {
+					if f, fErr := OpenExistenceFilter(existencePath); fErr != nil {
+						// filter is optional: file is still readable without it
+						d.logger.Warn("Domain.openFiles: skip existence filter", "err", fErr, "file", existencePath)
+					} else {
+						item.existence = f
+					}
+				}
+			}
 		}
 		return true
 	})
@@ -387,6 +410,10 @@ func (d *Domain) closeWhatNotInList(fNames []string) {
 			item.bindex.Close()
 			item.bindex = nil
 		}
+		if item.existence != nil {
+			item.existence.Close()
+			item.existence = nil
+		}
 		d.files.Delete(item)
 	}
 }
@@ -586,13 +613,14 @@ func ctxItemLess(i, j ctxItem) bool { //nolint
 
 // DomainContext allows accesing the same domain from multiple go-routines
 type DomainContext struct {
-	d       *Domain
-	files   []ctxItem
-	getters []*compress.Getter
-	readers []*BtIndex
-	hc      *HistoryContext
-	keyBuf  [60]byte // 52b key and 8b for inverted step
-	numBuf  [8]byte
+	d          *Domain
+	files      []ctxItem
+	getters    []*compress.Getter
+	readers    []*BtIndex
+	idxReaders []*recsplit.IndexReader
+	hc         *HistoryContext
+	keyBuf     [60]byte // 52b key and 8b for inverted step
+	numBuf     [8]byte
 }
 
 func (dc *DomainContext) statelessGetter(i int) *compress.Getter {
@@ -619,6 +647,19 @@ func (dc *DomainContext) statelessBtree(i int) *BtIndex {
 	return r
 }
 
+// statelessIdxReader - reader of .kvi, file must have it (see ctxItem.src.index)
+func (dc *DomainContext) statelessIdxReader(i int) *recsplit.IndexReader {
+	if dc.idxReaders == nil {
+		dc.idxReaders = make([]*recsplit.IndexReader, len(dc.files))
+	}
+	r := dc.idxReaders[i]
+	if r == nil {
+		r = dc.files[i].src.index.GetReaderFromPool()
+		dc.idxReaders[i] = r
+	}
+	return r
+}
+
 func (d *Domain) collectFilesStats() (datsz, idxsz, files uint64) {
 	d.History.files.Walk(func(items []*filesItem) bool {
 		for _, item := range items {
@@ -940,13 +981,15 @@ func (d *Domain) collate(ctx context.Context, step, txFrom, txTo uint64, roTx kv
 }
 
 type StaticFiles struct {
-	valuesDecomp    *compress.Decompressor
-	valuesIdx       *recsplit.Index
-	valuesBt        *BtIndex
-	historyDecomp   *compress.Decompressor
-	historyIdx      *recsplit.Index
-	efHistoryDecomp *compress.Decompressor
-	efHistoryIdx    *recsplit.Index
+	valuesDecomp       *compress.Decompressor
+	valuesIdx          *recsplit.Index
+	valuesBt           *BtIndex
+	valuesExistence    *ExistenceFilter
+	historyDecomp      *compress.Decompressor
+	historyIdx         *recsplit.Index
+	efHistoryDecomp    *compress.Decompressor
+	efHistoryIdx       *recsplit.Index
+	efHistoryExistence *ExistenceFilter
 }
 
 func (sf StaticFiles) Close() {
@@ -959,6 +1002,9 @@ func (sf StaticFiles) Close() {
 	if sf.valuesBt != nil {
 		sf.valuesBt.Close()
 	}
+	if sf.valuesExistence != nil {
+		sf.valuesExistence.Close()
+	}
 	if sf.historyDecomp != nil {
 		sf.historyDecomp.Close()
 	}
@@ -971,6 +1017,9 @@ func (sf StaticFiles) Close() {
 	if sf.efHistoryIdx != nil {
 		sf.efHistoryIdx.Close()
 	}
+	if sf.efHistoryExistence != nil {
+		sf.efHistoryExistence.Close()
+	}
 }
 
 // buildFiles performs potentially resource intensive operations of creating
@@ -988,6 +1037,7 @@ func (d *Domain) buildFiles(ctx context.Context, step uint64, collation Collatio
 	valuesComp := collation.valuesComp
 	var valuesDecomp *compress.Decompressor
 	var valuesIdx *recsplit.Index
+	var valuesExistence *ExistenceFilter
 	closeComp := true
 	defer func() {
 		if closeComp {
@@ -1001,6 +1051,9 @@ func (d *Domain) buildFiles(ctx context.Context, step uint64, collation Collatio
 			if valuesIdx != nil {
 				valuesIdx.Close()
 			}
+			if valuesExistence != nil {
+				valuesExistence.Close()
+			}
 		}
 	}()
 	if d.noFsync {
@@ -1037,15 +1090,26 @@ func (d *Domain) buildFiles(ctx context.Context, step uint64, collation Collatio
 		}
 	}
 
+	if d.withExistenceFilter {
+		existenceFileName := strings.TrimSuffix(valuesIdxFileName, "kvi") + "kvei"
+		p := ps.AddNew(existenceFileName, uint64(valuesDecomp.Count()/2))
+		defer ps.Delete(p)
+		if valuesExistence, err = buildExistenceFilterThenOpen(ctx, valuesDecomp, filepath.Join(d.dir, existenceFileName), p, d.noFsync); err != nil {
+			return StaticFiles{}, fmt.Errorf("build %s values existence filter: %w", d.filenameBase, err)
+		}
+	}
+
 	closeComp = false
 	return StaticFiles{
-		valuesDecomp:    valuesDecomp,
-		valuesIdx:       valuesIdx,
-		valuesBt:        bt,
-		historyDecomp:   hStaticFiles.historyDecomp,
-		historyIdx:      hStaticFiles.historyIdx,
-		efHistoryDecomp: hStaticFiles.efHistoryDecomp,
-		efHistoryIdx:    hStaticFiles.efHistoryIdx,
+		valuesDecomp:       valuesDecomp,
+		valuesIdx:          valuesIdx,
+		valuesBt:           bt,
+		valuesExistence:    valuesExistence,
+		historyDecomp:      hStaticFiles.historyDecomp,
+		historyIdx:         hStaticFiles.historyIdx,
+		efHistoryDecomp:    hStaticFiles.efHistoryDecomp,
+		efHistoryIdx:       hStaticFiles.efHistoryIdx,
+		efHistoryExistence: hStaticFiles.efHistoryExistence,
 	}, nil
 }
 
@@ -1053,7 +1117,9 @@ func (d *Domain) missedIdxFiles() (l []*filesItem) {
 	d.files.Walk(func(items []*filesItem) bool { // don't run slow logic while iterating on btree
 		for _, item := range items {
 			fromStep, toStep := item.startTxNum/d.aggregationStep, item.endTxNum/d.aggregationStep
-			if !dir.FileExist(filepath.Join(d.dir, fmt.Sprintf("%s.%d-%d.bt", d.filenameBase, fromStep, toStep))) {
+			if !dir.FileExist(filepath.Join(d.dir, fmt.Sprintf("%s.%d-%d.kvi", d.filenameBase, fromStep, toStep))) ||
+				!dir.FileExist(filepath.Join(d.dir, fmt.Sprintf("%s.%d-%d.bt", d.filenameBase, fromStep, toStep))) ||
+				(d.withExistenceFilter && !dir.FileExist(filepath.Join(d.dir, fmt.Sprintf("%s.%d-%d.kvei", d.filenameBase, fromStep, toStep)))) {
 				l = append(l, item)
 			}
 		}
@@ -1062,24 +1128,58 @@ func (d *Domain) missedIdxFiles() (l []*filesItem) {
 	return l
 }
 
-// BuildMissedIndices - produce .efi/.vi/.kvi from .ef/.v/.kv
+func (d *Domain) buildKvi(ctx context.Context, item *filesItem, p *background.Progress) (err error) {
+	fromStep, toStep := item.startTxNum/d.aggregationStep, item.endTxNum/d.aggregationStep
+	fName := fmt.Sprintf("%s.%d-%d.kvi", d.filenameBase, fromStep, toStep)
+	idxPath := filepath.Join(d.dir, fName)
+	p.Name.Store(&fName)
+	p.Total.Store(uint64(item.decompressor.Count()))
+	return buildIndex(ctx, item.decompressor, idxPath, d.tmpdir, item.decompressor.Count()/2, false, p, d.logger, d.noFsync)
+}
+
+func (d *Domain) buildBt(item *filesItem, p *background.Progress) (err error) {
+	fromStep, toStep := item.startTxNum/d.aggregationStep, item.endTxNum/d.aggregationStep
+	fName := fmt.Sprintf("%s.%d-%d.bt", d.filenameBase, fromStep, toStep)
+	idxPath := filepath.Join(d.dir, fName)
+	p.Name.Store(&fName)
+	p.Total.Store(uint64(item.decompressor.Count()))
+	if err := BuildBtreeIndexWithDecompressor(idxPath, item.decompressor, p, d.tmpdir, d.logger); err != nil {
+		return fmt.Errorf("failed to build btree index for %s:  %w", item.decompressor.FileName(), err)
+	}
+	return nil
+}
+
+// BuildMissedIndices - produce .efi/.efei/.vi/.kvi/.bt/.kvei from .ef/.v/.kv
 func (d *Domain) BuildMissedIndices(ctx context.Context, g *errgroup.Group, ps *background.ProgressSet) (err error) {
 	d.History.BuildMissedIndices(ctx, g, ps)
-	d.InvertedIndex.BuildMissedIndices(ctx, g, ps)
 	for _, item := range d.missedIdxFiles() {
-		//TODO: build .kvi
 		fitem := item
-		g.Go(func() error {
-			idxPath := filepath.Join(fitem.decompressor.FilePath(), fitem.decompressor.FileName())
-			idxPath = strings.TrimSuffix(idxPath, "kv") + "bt"
-
-			p := ps.AddNew("fixme", uint64(fitem.decompressor.Count()))
-			defer ps.Delete(p)
-			if err := BuildBtreeIndexWithDecompressor(idxPath, fitem.decompressor, p, d.tmpdir, d.logger); err != nil {
-				return fmt.Errorf("failed to build btree index for %s:  %w", fitem.decompressor.FileName(), err)
-			}
-			return nil
-		})
+		fromStep, toStep := fitem.startTxNum/d.aggregationStep, fitem.endTxNum/d.aggregationStep
+		if !dir.FileExist(filepath.Join(d.dir, fmt.Sprintf("%s.%d-%d.kvi", d.filenameBase, fromStep, toStep))) {
+			g.Go(func() error {
+				p := &background.Progress{}
+				ps.Add(p)
+				defer ps.Delete(p)
+				return d.buildKvi(ctx, fitem, p)
+			})
+		}
+		if !dir.FileExist(filepath.Join(d.dir, fmt.Sprintf("%s.%d-%d.bt", d.filenameBase, fromStep, toStep))) {
+			g.Go(func() error {
+				p := &background.Progress{}
+				ps.Add(p)
+				defer ps.Delete(p)
+				return d.buildBt(fitem, p)
+			})
+		}
+		existencePath := filepath.Join(d.dir, fmt.Sprintf("%s.%d-%d.kvei", d.filenameBase, fromStep, toStep))
+		if d.withExistenceFilter && !dir.FileExist(existencePath) {
+			g.Go(func() error {
+				p := &background.Progress{}
+				ps.Add(p)
+				defer ps.Delete(p)
+				return buildExistenceFilter(ctx, fitem.decompressor, existencePath, p, d.noFsync)
+			})
+		}
 	}
 	return nil
 }
@@ -1152,16 +1252,18 @@ func buildIndex(ctx context.Context, d *compress.Decompressor, idxPath, tmpdir s
 
 func (d *Domain) integrateFiles(sf StaticFiles, txNumFrom, txNumTo uint64) {
 	d.History.integrateFiles(HistoryFiles{
-		historyDecomp:   sf.historyDecomp,
-		historyIdx:      sf.historyIdx,
-		efHistoryDecomp: sf.efHistoryDecomp,
-		efHistoryIdx:    sf.efHistoryIdx,
+		historyDecomp:      sf.historyDecomp,
+		historyIdx:         sf.historyIdx,
+		efHistoryDecomp:    sf.efHistoryDecomp,
+		efHistoryIdx:       sf.efHistoryIdx,
+		efHistoryExistence: sf.efHistoryExistence,
 	}, txNumFrom, txNumTo)
 
 	fi := newFilesItem(txNumFrom, txNumTo, d.aggregationStep)
 	fi.decompressor = sf.valuesDecomp
 	fi.index = sf.valuesIdx
 	fi.bindex = sf.valuesBt
+	fi.existence = sf.valuesExistence
 	d.files.Set(fi)
 
 	d.reCalcRoFiles()
@@ -1358,34 +1460,71 @@ func (d *Domain) warmup(ctx context.Context, txFrom, limit uint64, tx kv.Tx) err
 
 var COMPARE_INDEXES = false // if true, will compare values from Btree and INvertedIndex
 
+// readFromFiles - files without key are skipped by existence filter (.kvei). Exact lookups go to .kvi (recsplit),
+// .bt is used only if file has no .kvi
 func (dc *DomainContext) readFromFiles(filekey []byte, fromTxNum uint64) ([]byte, bool, error) {
-	var val []byte
-	var found bool
-
+	hash := existenceHash(filekey)
 	for i := len(dc.files) - 1; i >= 0; i-- {
 		if dc.files[i].endTxNum < fromTxNum {
 			break
 		}
-		reader := dc.statelessBtree(i)
-		if reader.Empty() {
+		existence := dc.files[i].src.existence
+		if existence != nil && !existence.ContainsHash(hash) {
+			mxExistenceFilterSkip.Inc()
 			continue
 		}
-		cur, err := reader.Seek(filekey)
+		v, found, err := dc.readFromFile(i, filekey)
 		if err != nil {
-			//return nil, false, nil //TODO: uncomment me
 			return nil, false, err
 		}
-		if cur == nil {
-			continue
+		if existence != nil {
+			if found {
+				mxExistenceFilterHit.Inc()
+			} else {
+				mxExistenceFilterFalsePositive.Inc()
+			}
 		}
-
-		if bytes.Equal(cur.Key(), filekey) {
-			val = cur.Value()
-			found = true
-			break
+		if found {
+			return v, true, nil
 		}
 	}
-	return val, found, nil
+	return nil, false, nil
+}
+
+func (dc *DomainContext) readFromFile(i int, filekey []byte) ([]byte, bool, error) {
+	if dc.files[i].src.index == nil {
+		return dc.readFromBtree(i, filekey)
+	}
+	reader := dc.statelessIdxReader(i)
+	if reader.Empty() {
+		return nil, false, nil
+	}
+	offset := reader.Lookup(filekey)
+	g := dc.statelessGetter(i)
+	g.Reset(offset)
+	if !g.HasNext() {
+		return nil, false, nil
+	}
+	if ok, _ := g.Match(filekey); !ok { // recsplit returns some offset for keys which are not in file
+		return nil, false, nil
+	}
+	v, _ := g.Next(nil)
+	return v, true, nil
+}
+
+func (dc *DomainContext) readFromBtree(i int, filekey []byte) ([]byte, bool, error) {
+	reader := dc.statelessBtree(i)
+	if reader.Empty() {
+		return nil, false, nil
+	}
+	cur, err := reader.Seek(filekey)
+	if err != nil {
+		return nil, false, err
+	}
+	if cur == nil || !bytes.Equal(cur.Key(), filekey) {
+		return nil, false, nil
+	}
+	return cur.Value(), true, nil
 }
 
 // historyBeforeTxNum searches history for a value of specified key before txNum
@@ -1476,100 +1615,305 @@ func (dc *DomainContext) Close() {
 			item.src.closeFilesAndRemove()
 		}
 	}
+	for _, r := range dc.idxReaders {
+		r.Close()
+	}
 	dc.hc.Close()
 }
 
 // IteratePrefix iterates over key-value pairs of the domain that start with given prefix
 // Such iteration is not intended to be used in public API, therefore it uses read-write transaction
-// inside the domain. Another version of this for public API use needs to be created, that uses
-// roTx instead and supports ending the iterations before it reaches the end.
+// inside the domain. Public API must use Range.
 func (dc *DomainContext) IteratePrefix(prefix []byte, it func(k, v []byte)) error {
 	dc.d.stats.HistoryQueries.Add(1)
 
-	var cp CursorHeap
-	heap.Init(&cp)
-	var k, v []byte
-	var err error
-	keysCursor, err := dc.d.tx.CursorDupSort(dc.d.keysTable)
+	to, _ := kv.NextSubtree(prefix)
+	kvIt, err := dc.Range(prefix, to, math.MaxUint64, order.Asc, -1, dc.d.tx)
 	if err != nil {
 		return err
 	}
-	defer keysCursor.Close()
-	if k, v, err = keysCursor.Seek(prefix); err != nil {
-		return err
-	}
-	if bytes.HasPrefix(k, prefix) {
-		keySuffix := make([]byte, len(k)+8)
-		copy(keySuffix, k)
-		copy(keySuffix[len(k):], v)
-		step := ^binary.BigEndian.Uint64(v)
-		txNum := step * dc.d.aggregationStep
-		if v, err = dc.d.tx.GetOne(dc.d.valsTable, keySuffix); err != nil {
+	defer kvIt.(iter.Closer).Close()
+	for kvIt.HasNext() {
+		k, v, err := kvIt.Next()
+		if err != nil {
 			return err
 		}
-		heap.Push(&cp, &CursorItem{t: DB_CURSOR, key: common.Copy(k), val: common.Copy(v), c: keysCursor, endTxNum: txNum, reverse: true})
+		it(k, v)
 	}
+	return nil
+}
 
+// Range - key-value pairs of domain as of `ts` (state before txNum `ts`, same as GetBeforeTxNum), ts=math.MaxUint64 means latest state.
+// Merges DB, all files and history (for `ts` in the past): on same key newest step wins. Deleted keys are skipped.
+// Asc:  [fromKey, toKey) AND fromKey < toKey
+// Desc: [fromKey, toKey) AND fromKey > toKey (like kv.Tx.RangeDescend)
+// nil means unbounded, limit -1 means unlimited.
+// Desc order is supported only for latest state: history iterators support only Asc order (.ef files can't be read backwards)
+func (dc *DomainContext) Range(fromKey, toKey []byte, ts uint64, asc order.By, limit int, roTx kv.Tx) (iter.KV, error) {
+	if !asc && ts != math.MaxUint64 {
+		return nil, fmt.Errorf("%s: Range in Desc order is not supported yet for ts=%d: only for latest state", dc.d.filenameBase, ts)
+	}
+	if asc && fromKey != nil && toKey != nil && bytes.Compare(fromKey, toKey) >= 0 {
+		return nil, fmt.Errorf("fromKey=%x must be lexicographicaly before toKey=%x", fromKey, toKey)
+	}
+	if !asc && fromKey != nil && toKey != nil && bytes.Compare(fromKey, toKey) <= 0 {
+		return nil, fmt.Errorf("toKey=%x must be lexicographicaly before fromKey=%x", toKey, fromKey)
+	}
+	it := &DomainRangeIter{h: &domainRangeHeap{asc: bool(asc)}, from: fromKey, to: toKey, asc: bool(asc), limit: limit}
+	if err := it.addDB(dc, roTx); err != nil {
+		it.Close()
+		return nil, err
+	}
 	for i, item := range dc.files {
-		bg := dc.statelessBtree(i)
-		if bg.Empty() {
-			continue
+		it.addFile(dc.statelessBtree(i), item.endTxNum)
+	}
+	if ts != math.MaxUint64 {
+		if err := it.addHistory(dc.hc, ts, roTx); err != nil {
+			it.Close()
+			return nil, err
 		}
+	}
+	it.advance()
+	if it.err != nil {
+		it.Close()
+		return nil, it.err
+	}
+	return it, nil
+}
+
+// domainRangeItem - head of one source of DomainRangeIter
+type domainRangeItem struct {
+	key, val []byte
+	endTxNum uint64                                   // on same key - item with bigger endTxNum wins
+	next     func() (k, v []byte, ok bool, err error) // next pair of source in order of iteration
+}
 
-		cursor, err := bg.Seek(prefix)
+type domainRangeHeap struct {
+	items []*domainRangeItem
+	asc   bool
+}
+
+func (h *domainRangeHeap) Len() int { return len(h.items) }
+func (h *domainRangeHeap) Less(i, j int) bool {
+	if cmp := bytes.Compare(h.items[i].key, h.items[j].key); cmp != 0 {
+		return (cmp < 0) == h.asc
+	}
+	return h.items[i].endTxNum > h.items[j].endTxNum
+}
+func (h *domainRangeHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *domainRangeHeap) Push(x interface{}) { h.items = append(h.items, x.(*domainRangeItem)) }
+func (h *domainRangeHeap) Pop() interface{} {
+	old := h.items
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	h.items = old[0 : n-1]
+	return x
+}
+
+// DomainRangeIter - multi-way merge of domain sources (DB, files, history), see DomainContext.Range
+type DomainRangeIter struct {
+	h       *domainRangeHeap
+	closers []func()
+
+	from, to []byte
+	asc      bool
+	limit    int
+
+	nextK, nextV []byte
+	hasNext      bool
+	err          error
+}
+
+// inRange - checks `to` bound, `from` bound is guaranteed by initial positioning of sources
+func (it *DomainRangeIter) inRange(k []byte) bool {
+	if it.to == nil {
+		return true
+	}
+	if it.asc {
+		return bytes.Compare(k, it.to) < 0
+	}
+	return bytes.Compare(k, it.to) > 0
+}
+
+func (it *DomainRangeIter) push(item *domainRangeItem, k, v []byte) {
+	if !it.inRange(k) {
+		return
+	}
+	item.key, item.val = common.Copy(k), common.Copy(v)
+	heap.Push(it.h, item)
+}
+
+// addDB - latest values of keys in DB. Deleted key has empty value: it hides older values from files
+func (it *DomainRangeIter) addDB(dc *DomainContext, roTx kv.Tx) error {
+	keysCursor, err := roTx.CursorDupSort(dc.d.keysTable)
+	if err != nil {
+		return err
+	}
+	it.closers = append(it.closers, keysCursor.Close)
+	var keySuffix []byte
+	// k is positioned to some dup of key: newest step is first dup (step is inverted)
+	read := func(k []byte, err error) ([]byte, []byte, uint64, bool, error) {
+		if err != nil || k == nil {
+			return nil, nil, 0, false, err
+		}
+		invStep, err := keysCursor.FirstDup()
 		if err != nil {
-			continue
+			return nil, nil, 0, false, err
+		}
+		keySuffix = append(append(keySuffix[:0], k...), invStep...)
+		v, err := roTx.GetOne(dc.d.valsTable, keySuffix)
+		if err != nil {
+			return nil, nil, 0, false, err
+		}
+		return k, v, (^binary.BigEndian.Uint64(invStep) + 1) * dc.d.aggregationStep, true, nil
+	}
+
+	var k []byte
+	switch {
+	case it.asc && it.from == nil:
+		k, _, err = keysCursor.First()
+	case it.asc:
+		k, _, err = keysCursor.Seek(it.from)
+	case it.from == nil:
+		k, _, err = keysCursor.Last()
+	default:
+		if k, _, err = keysCursor.Seek(it.from); err == nil && (k == nil || !bytes.Equal(k, it.from)) {
+			if k == nil {
+				k, _, err = keysCursor.Last()
+			} else {
+				k, _, err = keysCursor.PrevNoDup()
+			}
 		}
+	}
+	k, v, endTxNum, ok, err := read(k, err)
+	if err != nil || !ok {
+		return err
+	}
+	item := &domainRangeItem{endTxNum: endTxNum}
+	item.next = func() ([]byte, []byte, bool, error) {
+		var k []byte
+		var err error
+		if it.asc {
+			k, _, err = keysCursor.NextNoDup()
+		} else {
+			k, _, err = keysCursor.PrevNoDup()
+		}
+		k, v, endTxNum, ok, err := read(k, err)
+		item.endTxNum = endTxNum
+		return k, v, ok, err
+	}
+	it.push(item, k, v)
+	return nil
+}
 
-		g := dc.statelessGetter(i)
-		key := cursor.Key()
-		if bytes.HasPrefix(key, prefix) {
-			val := cursor.Value()
-			heap.Push(&cp, &CursorItem{t: FILE_CURSOR, key: key, val: val, dg: g, endTxNum: item.endTxNum, reverse: true})
-		}
-	}
-	for cp.Len() > 0 {
-		lastKey := common.Copy(cp[0].key)
-		lastVal := common.Copy(cp[0].val)
-		// Advance all the items that have this key (including the top)
-		for cp.Len() > 0 && bytes.Equal(cp[0].key, lastKey) {
-			ci1 := cp[0]
-			switch ci1.t {
-			case FILE_CURSOR:
-				if ci1.dg.HasNext() {
-					ci1.key, _ = ci1.dg.Next(ci1.key[:0])
-					if bytes.HasPrefix(ci1.key, prefix) {
-						ci1.val, _ = ci1.dg.Next(ci1.val[:0])
-						heap.Fix(&cp, 0)
-					} else {
-						heap.Pop(&cp)
-					}
-				} else {
-					heap.Pop(&cp)
-				}
-			case DB_CURSOR:
-				k, v, err = ci1.c.NextNoDup()
-				if err != nil {
-					return err
-				}
-				if k != nil && bytes.HasPrefix(k, prefix) {
-					ci1.key = common.Copy(k)
-					keySuffix := make([]byte, len(k)+8)
-					copy(keySuffix, k)
-					copy(keySuffix[len(k):], v)
-					if v, err = dc.d.tx.GetOne(dc.d.valsTable, keySuffix); err != nil {
-						return err
-					}
-					ci1.val = common.Copy(v)
-					heap.Fix(&cp, 0)
-				} else {
-					heap.Pop(&cp)
-				}
+// addFile - values of .kv file, read by btree index
+func (it *DomainRangeIter) addFile(bt *BtIndex, endTxNum uint64) {
+	if bt.Empty() {
+		return
+	}
+	var c *Cursor
+	var err error
+	if it.from != nil {
+		if c, err = bt.Seek(it.from); err != nil {
+			it.err = err
+			return
+		}
+	} else if it.asc {
+		c = bt.OrdinalLookup(0)
+	}
+	if !it.asc {
+		if c == nil { // all keys are before `from`
+			c = bt.OrdinalLookup(bt.KeyCount() - 1)
+		} else if it.from != nil && !bytes.Equal(c.Key(), it.from) {
+			if c.Ordinal() == 0 {
+				return
 			}
+			c = bt.OrdinalLookup(c.Ordinal() - 1)
+		}
+	}
+	if c == nil {
+		return
+	}
+	item := &domainRangeItem{endTxNum: endTxNum}
+	item.next = func() ([]byte, []byte, bool, error) {
+		if it.asc {
+			if !c.Next() {
+				return nil, nil, false, nil
+			}
+			return c.Key(), c.Value(), true, nil
+		}
+		if c.Ordinal() == 0 {
+			return nil, nil, false, nil
+		}
+		if c = bt.OrdinalLookup(c.Ordinal() - 1); c == nil {
+			return nil, nil, false, nil
 		}
-		if len(lastVal) > 0 {
-			it(lastKey, lastVal)
+		return c.Key(), c.Value(), true, nil
+	}
+	it.push(item, c.Key(), c.Value())
+}
+
+// addHistory - values as of `ts` of keys changed after `ts`. Has highest priority: newer values must be hidden.
+// Empty value means: key didn't exist at `ts`
+func (it *DomainRangeIter) addHistory(hc *HistoryContext, ts uint64, roTx kv.Tx) error {
+	item := &domainRangeItem{endTxNum: math.MaxUint64}
+	hist := hc.WalkAsOf(ts, it.from, it.to, roTx, -1) // Asc only, see Range
+	if c, ok := hist.(iter.Closer); ok {
+		it.closers = append(it.closers, c.Close)
+	}
+	item.next = func() ([]byte, []byte, bool, error) {
+		if !hist.HasNext() {
+			return nil, nil, false, nil
 		}
+		k, v, err := hist.Next()
+		return k, v, err == nil, err
+	}
+	k, v, ok, err := item.next()
+	if err != nil || !ok {
+		return err
 	}
+	it.push(item, k, v)
 	return nil
 }
+
+func (it *DomainRangeIter) advance() {
+	it.hasNext = false
+	for it.err == nil && it.h.Len() > 0 {
+		top := it.h.items[0]
+		k, v := top.key, top.val
+		// advance all the items that have this key (including the top)
+		for it.h.Len() > 0 && bytes.Equal(it.h.items[0].key, k) {
+			item := heap.Pop(it.h).(*domainRangeItem)
+			nextK, nextV, ok, err := item.next()
+			if err != nil {
+				it.err = err
+				return
+			}
+			if ok {
+				it.push(item, nextK, nextV)
+			}
+		}
+		if len(v) > 0 {
+			it.nextK, it.nextV, it.hasNext = k, v, true
+			return
+		}
+	}
+}
+
+func (it *DomainRangeIter) HasNext() bool { return it.err != nil || (it.limit != 0 && it.hasNext) }
+func (it *DomainRangeIter) Next() ([]byte, []byte, error) {
+	if it.err != nil {
+		return nil, nil, it.err
+	}
+	it.limit--
+	k, v := it.nextK, it.nextV
+	it.advance()
+	return k, v, nil
+}
+func (it *DomainRangeIter) Close() {
+	for _, c := range it.closers {
+		c()
+	}
+	it.closers = nil
+}
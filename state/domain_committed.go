@@ -379,6 +379,9 @@ func (d *DomainCommitted) mergeFiles(ctx context.Context, oldFiles SelectedStati
 				if indexIn.bindex != nil {
 					indexIn.bindex.Close()
 				}
+				if indexIn.existence != nil {
+					indexIn.existence.Close()
+				}
 			}
 			if historyIn != nil {
 				if historyIn.decompressor != nil {
@@ -401,6 +404,9 @@ func (d *DomainCommitted) mergeFiles(ctx context.Context, oldFiles SelectedStati
 				if valuesIn.bindex != nil {
 					valuesIn.bindex.Close()
 				}
+				if valuesIn.existence != nil {
+					valuesIn.existence.Close()
+				}
 			}
 		}
 	}()
@@ -543,6 +549,13 @@ func (d *DomainCommitted) mergeFiles(ctx context.Context, oldFiles SelectedStati
 		if err != nil {
 			return nil, nil, nil, fmt.Errorf("create btindex %s [%d-%d]: %w", d.filenameBase, r.valuesStartTxNum, r.valuesEndTxNum, err)
 		}
+
+		if d.withExistenceFilter {
+			existencePath := strings.TrimSuffix(idxPath, "kvi") + "kvei"
+			if valuesIn.existence, err = buildExistenceFilterThenOpen(ctx, valuesIn.decompressor, existencePath, p, d.noFsync); err != nil {
+				return nil, nil, nil, fmt.Errorf("merge %s existence filter [%d-%d]: %w", d.filenameBase, r.valuesStartTxNum, r.valuesEndTxNum, err)
+			}
+		}
 	}
 	closeItem = false
 	d.stats.MergesCount++
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -30,9 +31,12 @@ import (
 	"github.com/ledgerwatch/log/v3"
 	"github.com/stretchr/testify/require"
 	btree2 "github.com/tidwall/btree"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/iter"
 	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	"github.com/ledgerwatch/erigon-lib/kv/order"
 	"github.com/ledgerwatch/erigon-lib/recsplit"
 )
 
@@ -419,6 +423,96 @@ func TestIterationMultistep(t *testing.T) {
 	require.Equal(t, []string{"value1", "value1", "value1"}, vals)
 }
 
+func TestDomainRange(t *testing.T) {
+	logger := log.New()
+	_, db, d, txs := filledDomain(t, logger)
+	ctx := context.Background()
+	tx, err := db.BeginRw(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	d.SetTx(tx)
+	// Leave the last 2 aggregation steps un-collated: data is in files and in DB
+	collateAndMerge(t, db, tx, d, txs)
+	err = tx.Commit()
+	require.NoError(t, err)
+
+	roTx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	defer roTx.Rollback()
+	dc := d.MakeContext()
+	defer dc.Close()
+	require.NotEmpty(t, dc.files)
+	c, err := roTx.Cursor(d.valsTable)
+	require.NoError(t, err)
+	inDB, err := c.Count()
+	require.NoError(t, err)
+	c.Close()
+	require.NotZero(t, inDB)
+
+	key := func(keyNum uint64) []byte {
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], keyNum)
+		return k[:]
+	}
+	// expected state before txNum `ts`: key changes value on every txNum which is multiple of the key
+	expect := func(ts uint64, fromNum, toNum uint64, asc order.By, limit int) (keys, vals []string) {
+		lastTxNum := txs
+		if ts != math.MaxUint64 {
+			lastTxNum = ts - 1
+		}
+		for i := uint64(0); i < toNum-fromNum; i++ {
+			keyNum := fromNum + i
+			if !asc {
+				keyNum = toNum - 1 - i
+			}
+			if lastTxNum < keyNum || limit == len(keys) {
+				continue
+			}
+			keys, vals = append(keys, fmt.Sprintf("%x", key(keyNum))), append(vals, fmt.Sprintf("%x", key(lastTxNum/keyNum)))
+		}
+		return keys, vals
+	}
+	toStrings := func(it iter.KV, err error) (keys, vals []string) {
+		require.NoError(t, err)
+		defer it.(iter.Closer).Close()
+		for it.HasNext() {
+			k, v, err := it.Next()
+			require.NoError(t, err)
+			keys, vals = append(keys, fmt.Sprintf("%x", k)), append(vals, fmt.Sprintf("%x", v))
+		}
+		return keys, vals
+	}
+	for _, ts := range []uint64{math.MaxUint64, txs + 1, 990, 500, 17, 5, 1} {
+		label := fmt.Sprintf("ts=%d", ts)
+		expectKeys, expectVals := expect(ts, 1, 32, order.Asc, -1)
+		keys, vals := toStrings(dc.Range(nil, nil, ts, order.Asc, -1, roTx))
+		require.Equal(t, expectKeys, keys, label)
+		require.Equal(t, expectVals, vals, label)
+
+		// [5, 20)
+		expectKeys, expectVals = expect(ts, 5, 20, order.Asc, 7)
+		keys, vals = toStrings(dc.Range(key(5), key(20), ts, order.Asc, 7, roTx))
+		require.Equal(t, expectKeys, keys, label)
+		require.Equal(t, expectVals, vals, label)
+
+		if ts != math.MaxUint64 { // history can't be iterated in Desc order
+			_, err := dc.Range(nil, nil, ts, order.Desc, 10, roTx)
+			require.ErrorContains(t, err, "not supported", label)
+			continue
+		}
+		expectKeys, expectVals = expect(ts, 1, 32, order.Desc, -1)
+		keys, vals = toStrings(dc.Range(nil, nil, ts, order.Desc, -1, roTx))
+		require.Equal(t, expectKeys, keys, label)
+		require.Equal(t, expectVals, vals, label)
+
+		// [19, 4) in Desc order
+		expectKeys, expectVals = expect(ts, 5, 20, order.Desc, 7)
+		keys, vals = toStrings(dc.Range(key(19), key(4), ts, order.Desc, 7, roTx))
+		require.Equal(t, expectKeys, keys, label)
+		require.Equal(t, expectVals, vals, label)
+	}
+}
+
 func collateAndMerge(t *testing.T, db kv.RwDB, tx kv.RwTx, d *Domain, txs uint64) {
 	t.Helper()
 
@@ -524,6 +618,171 @@ func TestDomain_ScanFiles(t *testing.T) {
 	checkHistory(t, db, d, txs)
 }
 
+func TestDomain_MissedKvi(t *testing.T) {
+	logger := log.New()
+	path, db, d, txs := filledDomain(t, logger)
+	collateAndMerge(t, db, nil, d, txs)
+
+	kvis, err := filepath.Glob(filepath.Join(path, "*.kvi"))
+	require.NoError(t, err)
+	require.NotEmpty(t, kvis)
+	for _, f := range kvis {
+		require.NoError(t, os.Remove(f))
+	}
+	txNum := d.txNum
+	d.closeWhatNotInList([]string{})
+	require.NoError(t, d.OpenFolder())
+	d.SetTxNum(txNum)
+	require.NotEmpty(t, d.missedIdxFiles())
+	checkHistory(t, db, d, txs) // files without .kvi are read by .bt
+
+	g, ctx := errgroup.WithContext(context.Background())
+	require.NoError(t, d.BuildMissedIndices(ctx, g, background.NewProgressSet()))
+	require.NoError(t, g.Wait())
+	require.Empty(t, d.missedIdxFiles())
+
+	d.closeWhatNotInList([]string{})
+	require.NoError(t, d.OpenFolder())
+	d.SetTxNum(txNum)
+	d.files.Walk(func(items []*filesItem) bool {
+		for _, item := range items {
+			require.NotNil(t, item.index)
+		}
+		return true
+	})
+	checkHistory(t, db, d, txs)
+
+	dc := d.MakeContext()
+	defer dc.Close()
+	for keyNum := uint64(1); keyNum <= 64; keyNum++ {
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], keyNum)
+		v, found, err := dc.readFromFiles(k[:], 0)
+		require.NoError(t, err)
+		for i := len(dc.files) - 1; i >= 0 && !found; i-- {
+			_, btFound, err := dc.readFromBtree(i, k[:])
+			require.NoError(t, err)
+			require.False(t, btFound, keyNum)
+		}
+		if keyNum > 31 {
+			require.False(t, found, keyNum)
+			continue
+		}
+		require.True(t, found, keyNum)
+		require.NotEmpty(t, v)
+	}
+}
+
+func TestDomain_ExistenceFilter(t *testing.T) {
+	logger := log.New()
+	path, db, d, txs := filledDomain(t, logger)
+	d.EnableExistenceFilter()
+	collateAndMerge(t, db, nil, d, txs)
+
+	d.files.Walk(func(items []*filesItem) bool {
+		for _, item := range items {
+			require.NotNil(t, item.existence)
+		}
+		return true
+	})
+	d.History.InvertedIndex.files.Walk(func(items []*filesItem) bool {
+		for _, item := range items {
+			require.NotNil(t, item.existence)
+		}
+		return true
+	})
+
+	dc := d.MakeContext()
+	skipped := mxExistenceFilterSkip.Get()
+	for keyNum := uint64(1); keyNum <= 64; keyNum++ {
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], keyNum)
+		for i := range dc.files {
+			_, inFile, err := dc.readFromBtree(i, k[:])
+			require.NoError(t, err)
+			if inFile {
+				require.True(t, dc.files[i].src.existence.Contains(k[:]), keyNum) // no false-negatives
+			}
+		}
+		_, found, err := dc.readFromFiles(k[:], 0)
+		require.NoError(t, err)
+		require.Equal(t, keyNum <= 31, found, keyNum)
+	}
+	require.Greater(t, mxExistenceFilterSkip.Get(), skipped)
+	dc.Close()
+
+	// filters are optional: files without them are readable, missed filters are re-built
+	for _, pattern := range []string{"*.kvei", "*.efei"} {
+		files, err := filepath.Glob(filepath.Join(path, pattern))
+		require.NoError(t, err)
+		require.NotEmpty(t, files)
+		for _, f := range files {
+			require.NoError(t, os.Remove(f))
+		}
+	}
+	txNum := d.txNum
+	d.closeWhatNotInList([]string{})
+	d.History.InvertedIndex.closeWhatNotInList([]string{})
+	require.NoError(t, d.OpenFolder())
+	d.SetTxNum(txNum)
+	checkHistory(t, db, d, txs)
+
+	g, ctx := errgroup.WithContext(context.Background())
+	require.NoError(t, d.BuildMissedIndices(ctx, g, background.NewProgressSet()))
+	require.NoError(t, g.Wait())
+	require.Empty(t, d.missedIdxFiles())
+	require.Empty(t, d.History.InvertedIndex.missedIdxFiles())
+
+	d.closeWhatNotInList([]string{})
+	d.History.InvertedIndex.closeWhatNotInList([]string{})
+	require.NoError(t, d.OpenFolder())
+	d.SetTxNum(txNum)
+	d.files.Walk(func(items []*filesItem) bool {
+		for _, item := range items {
+			require.NotNil(t, item.existence)
+		}
+		return true
+	})
+	checkHistory(t, db, d, txs)
+
+	// corrupted filter is skipped: file is still opened and readable. Corrupt first and last file in walk order:
+	// outcome must not depend on which filter is read last
+	corrupt := func(files *btree2.BTreeG[*filesItem]) {
+		var paths []string
+		files.Walk(func(items []*filesItem) bool {
+			for _, item := range items {
+				paths = append(paths, item.existence.FilePath)
+			}
+			return true
+		})
+		require.Greater(t, len(paths), 1)
+		for _, p := range []string{paths[0], paths[len(paths)-1]} {
+			require.NoError(t, os.WriteFile(p, []byte{1, 2, 3}, 0644))
+		}
+	}
+	corrupt(d.files)
+	corrupt(d.History.InvertedIndex.files)
+	d.closeWhatNotInList([]string{})
+	d.History.InvertedIndex.closeWhatNotInList([]string{})
+	require.NoError(t, d.OpenFolder())
+	d.SetTxNum(txNum)
+	withoutFilter := func(files *btree2.BTreeG[*filesItem]) (n int) {
+		files.Walk(func(items []*filesItem) bool {
+			for _, item := range items {
+				require.NotNil(t, item.decompressor)
+				if item.existence == nil {
+					n++
+				}
+			}
+			return true
+		})
+		return n
+	}
+	require.Equal(t, 2, withoutFilter(d.files))
+	require.Equal(t, 2, withoutFilter(d.History.InvertedIndex.files))
+	checkHistory(t, db, d, txs)
+}
+
 func TestDomain_Delete(t *testing.T) {
 	logger := log.New()
 	_, db, d := testDbAndDomain(t, logger)
@@ -568,6 +827,19 @@ func TestDomain_Delete(t *testing.T) {
 		require.Nil(val, label)
 		//}
 	}
+	// deleted key is not visible in latest state, but visible in the past
+	for _, ts := range []uint64{math.MaxUint64, 999, 998, 500} {
+		it, err := dc.Range(nil, nil, ts, order.Asc, -1, tx)
+		require.NoError(err)
+		keys, vals, err := iter.ToKVArray(it)
+		require.NoError(err)
+		if ts == math.MaxUint64 || ts%2 == 0 { // state after odd txNum
+			require.Empty(keys, ts)
+			continue
+		}
+		require.Equal([][]byte{[]byte("key1")}, keys, ts)
+		require.Equal([][]byte{[]byte("value1")}, vals, ts)
+	}
 }
 
 func filledDomainFixedSize(t *testing.T, keysCount, txCount uint64, logger log.Logger) (string, kv.RwDB, *Domain, map[string][]bool) {
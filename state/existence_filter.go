@@ -0,0 +1,176 @@
+/*
+   Copyright 2022 Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package state
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/VictoriaMetrics/metrics"
+	bloomfilter "github.com/holiman/bloomfilter/v2"
+	"github.com/spaolacci/murmur3"
+
+	"github.com/ledgerwatch/erigon-lib/common/background"
+	"github.com/ledgerwatch/erigon-lib/compress"
+)
+
+var (
+	// skip - file was not touched, hit - key was found in file, false_positive - filter said "maybe", but file has no key
+	mxExistenceFilterSkip          = metrics.GetOrCreateCounter(`domain_existence_filter{result="skip"}`)
+	mxExistenceFilterHit           = metrics.GetOrCreateCounter(`domain_existence_filter{result="hit"}`)
+	mxExistenceFilterFalsePositive = metrics.GetOrCreateCounter(`domain_existence_filter{result="false_positive"}`)
+)
+
+// existenceFilterFPRate - probability of false-positive. 1% costs ~9.6 bits per key
+const existenceFilterFPRate = 0.01
+
+// ExistenceFilter - bloom filter over keys of one static file (.kvei for .kv, .efei for .ef).
+// Allows to skip file without touching it's index: `false` - key is definitely not in file, `true` - key may be in file.
+// Filter is optional: readers must work without it (files of older versions don't have it).
+type ExistenceFilter struct {
+	filter   *bloomfilter.Filter
+	empty    bool
+	FileName string
+	FilePath string
+	noFsync  bool // fsync is enabled by default, but tests can manually disable
+}
+
+func NewExistenceFilter(keysCount uint64, filePath string) (*ExistenceFilter, error) {
+	_, fileName := filepath.Split(filePath)
+	e := &ExistenceFilter{FilePath: filePath, FileName: fileName}
+	if keysCount == 0 {
+		e.empty = true
+		return e, nil
+	}
+	var err error
+	e.filter, err = bloomfilter.NewOptimal(keysCount, existenceFilterFPRate)
+	if err != nil {
+		return nil, fmt.Errorf("NewExistenceFilter %s: %w", fileName, err)
+	}
+	return e, nil
+}
+
+func existenceHash(key []byte) uint64 { return murmur3.Sum64(key) }
+
+func (e *ExistenceFilter) AddHash(hash uint64) {
+	if e.empty {
+		return
+	}
+	e.filter.AddHash(hash)
+}
+
+// ContainsHash - hash must be produced by existenceHash. Allows to hash key once and check many files
+func (e *ExistenceFilter) ContainsHash(hash uint64) bool {
+	if e.empty {
+		return false
+	}
+	return e.filter.ContainsHash(hash)
+}
+
+func (e *ExistenceFilter) Contains(key []byte) bool { return e.ContainsHash(existenceHash(key)) }
+
+// Build - writes filter to disk: to tmp file, then rename. Empty filter is empty file
+func (e *ExistenceFilter) Build() error {
+	tmpFilePath := e.FilePath + ".tmp"
+	f, err := os.Create(tmpFilePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if !e.empty {
+		w := bufio.NewWriter(f)
+		if _, err = e.filter.WriteTo(w); err != nil {
+			return fmt.Errorf("write %s: %w", e.FileName, err)
+		}
+		if err = w.Flush(); err != nil {
+			return err
+		}
+	}
+	if !e.noFsync {
+		if err = f.Sync(); err != nil {
+			return err
+		}
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFilePath, e.FilePath)
+}
+
+// DisableFsync - just for tests
+func (e *ExistenceFilter) DisableFsync() { e.noFsync = true }
+
+func OpenExistenceFilter(filePath string) (*ExistenceFilter, error) {
+	_, fileName := filepath.Split(filePath)
+	e := &ExistenceFilter{FilePath: filePath, FileName: fileName}
+	st, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if st.Size() == 0 {
+		e.empty = true
+		return e, nil
+	}
+	if e.filter, _, err = bloomfilter.ReadFile(filePath); err != nil {
+		return nil, fmt.Errorf("OpenExistenceFilter %s: %w", fileName, err)
+	}
+	return e, nil
+}
+
+// Close - filter is fully in RAM, nothing to release. Exists for symmetry with other indices of filesItem
+func (e *ExistenceFilter) Close() {}
+
+// buildExistenceFilter - adds all keys of .kv/.ef file (every even word) to filter
+func buildExistenceFilter(ctx context.Context, d *compress.Decompressor, filePath string, p *background.Progress, noFsync bool) error {
+	_, fileName := filepath.Split(filePath)
+	p.Name.Store(&fileName)
+	p.Processed.Store(0)
+	p.Total.Store(uint64(d.Count() / 2))
+
+	e, err := NewExistenceFilter(uint64(d.Count()/2), filePath)
+	if err != nil {
+		return err
+	}
+	if noFsync {
+		e.DisableFsync()
+	}
+	defer d.EnableMadvNormal().DisableReadAhead()
+
+	word := make([]byte, 0, 256)
+	g := d.MakeGetter()
+	g.Reset(0)
+	for g.HasNext() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		word, _ = g.Next(word[:0])
+		e.AddHash(existenceHash(word))
+		g.Skip() // value
+		p.Processed.Add(1)
+	}
+	return e.Build()
+}
+
+func buildExistenceFilterThenOpen(ctx context.Context, d *compress.Decompressor, filePath string, p *background.Progress, noFsync bool) (*ExistenceFilter, error) {
+	if err := buildExistenceFilter(ctx, d, filePath, p, noFsync); err != nil {
+		return nil, err
+	}
+	return OpenExistenceFilter(filePath)
+}
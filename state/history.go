@@ -278,6 +278,10 @@ func (h *History) Close() {
 	h.reCalcRoFiles()
 }
 
+// EnableExistenceFilter - build .efei of history index (and .kvei of domain) for new files. Costs disk and RAM (~10 bits per key),
+// but every miss in file is answered without index lookup. Already existing filters are used anyway.
+func (h *History) EnableExistenceFilter() { h.withExistenceFilter = true }
+
 func (h *History) Files() (res []string) {
 	h.files.Walk(func(items []*filesItem) bool {
 		for _, item := range items {
@@ -762,10 +766,11 @@ func (h *History) collate(step, txFrom, txTo uint64, roTx kv.Tx) (HistoryCollati
 }
 
 type HistoryFiles struct {
-	historyDecomp   *compress.Decompressor
-	historyIdx      *recsplit.Index
-	efHistoryDecomp *compress.Decompressor
-	efHistoryIdx    *recsplit.Index
+	historyDecomp      *compress.Decompressor
+	historyIdx         *recsplit.Index
+	efHistoryDecomp    *compress.Decompressor
+	efHistoryIdx       *recsplit.Index
+	efHistoryExistence *ExistenceFilter
 }
 
 func (sf HistoryFiles) Close() {
@@ -781,6 +786,9 @@ func (sf HistoryFiles) Close() {
 	if sf.efHistoryIdx != nil {
 		sf.efHistoryIdx.Close()
 	}
+	if sf.efHistoryExistence != nil {
+		sf.efHistoryExistence.Close()
+	}
 }
 func (h *History) reCalcRoFiles() {
 	roFiles := ctxFiles(h.files)
@@ -796,6 +804,7 @@ func (h *History) buildFiles(ctx context.Context, step uint64, collation History
 	}
 	var historyDecomp, efHistoryDecomp *compress.Decompressor
 	var historyIdx, efHistoryIdx *recsplit.Index
+	var efHistoryExistence *ExistenceFilter
 	var efHistoryComp *compress.Compressor
 	var rs *recsplit.RecSplit
 	closeComp := true
@@ -819,6 +828,9 @@ func (h *History) buildFiles(ctx context.Context, step uint64, collation History
 			if efHistoryIdx != nil {
 				efHistoryIdx.Close()
 			}
+			if efHistoryExistence != nil {
+				efHistoryExistence.Close()
+			}
 			if rs != nil {
 				rs.Close()
 			}
@@ -902,6 +914,14 @@ func (h *History) buildFiles(ctx context.Context, step uint64, collation History
 	if efHistoryIdx, err = buildIndexThenOpen(ctx, efHistoryDecomp, efHistoryIdxPath, h.tmpdir, len(keys), false /* values */, p, h.logger, h.noFsync); err != nil {
 		return HistoryFiles{}, fmt.Errorf("build %s ef history idx: %w", h.filenameBase, err)
 	}
+	if h.withExistenceFilter {
+		efHistoryExistenceFileName := fmt.Sprintf("%s.%d-%d.efei", h.filenameBase, step, step+1)
+		p := ps.AddNew(efHistoryExistenceFileName, uint64(len(keys)))
+		defer ps.Delete(p)
+		if efHistoryExistence, err = buildExistenceFilterThenOpen(ctx, efHistoryDecomp, filepath.Join(h.dir, efHistoryExistenceFileName), p, h.noFsync); err != nil {
+			return HistoryFiles{}, fmt.Errorf("build %s ef history existence filter: %w", h.filenameBase, err)
+		}
+	}
 	if rs, err = recsplit.NewRecSplit(recsplit.RecSplitArgs{
 		KeyCount:   collation.historyCount,
 		Enums:      false,
@@ -954,17 +974,19 @@ func (h *History) buildFiles(ctx context.Context, step uint64, collation History
 	}
 	closeComp = false
 	return HistoryFiles{
-		historyDecomp:   historyDecomp,
-		historyIdx:      historyIdx,
-		efHistoryDecomp: efHistoryDecomp,
-		efHistoryIdx:    efHistoryIdx,
+		historyDecomp:      historyDecomp,
+		historyIdx:         historyIdx,
+		efHistoryDecomp:    efHistoryDecomp,
+		efHistoryIdx:       efHistoryIdx,
+		efHistoryExistence: efHistoryExistence,
 	}, nil
 }
 
 func (h *History) integrateFiles(sf HistoryFiles, txNumFrom, txNumTo uint64) {
 	h.InvertedIndex.integrateFiles(InvertedFiles{
-		decomp: sf.efHistoryDecomp,
-		index:  sf.efHistoryIdx,
+		decomp:    sf.efHistoryDecomp,
+		index:     sf.efHistoryIdx,
+		existence: sf.efHistoryExistence,
 	}, txNumFrom, txNumTo)
 
 	fi := newFilesItem(txNumFrom, txNumTo, h.aggregationStep)
@@ -1207,7 +1229,13 @@ func (hc *HistoryContext) GetNoState(key []byte, txNum uint64) ([]byte, bool, er
 	var foundEndTxNum uint64
 	var foundStartTxNum uint64
 	var found bool
+	hash := existenceHash(key)
 	var findInFile = func(item ctxItem) bool {
+		existence := item.src.existence
+		if existence != nil && !existence.ContainsHash(hash) {
+			mxExistenceFilterSkip.Inc()
+			return true
+		}
 		reader := hc.ic.statelessIdxReader(item.i)
 		if reader.Empty() {
 			return true
@@ -1218,11 +1246,17 @@ func (hc *HistoryContext) GetNoState(key []byte, txNum uint64) ([]byte, bool, er
 		k, _ := g.NextUncompressed()
 
 		if !bytes.Equal(k, key) {
+			if existence != nil {
+				mxExistenceFilterFalsePositive.Inc()
+			}
 			//if bytes.Equal(key, hex.MustDecodeString("009ba32869045058a3f05d6f3dd2abb967e338f6")) {
 			//	fmt.Printf("not in this shard: %x, %d, %d-%d\n", k, txNum, item.startTxNum/hc.h.aggregationStep, item.endTxNum/hc.h.aggregationStep)
 			//}
 			return true
 		}
+		if existence != nil {
+			mxExistenceFilterHit.Inc()
+		}
 		eliasVal, _ := g.NextUncompressed()
 		ef, _ := eliasfano32.ReadEliasFano(eliasVal)
 		n, ok := ef.Search(txNum)
@@ -1455,6 +1489,11 @@ func (hc *HistoryContext) WalkAsOf(startTxNum uint64, from, to []byte, roTx kv.T
 	return iter.UnionKV(hi, dbit, limit)
 }
 
+var _ iter.SeekableKV = (*StateAsOfIterF)(nil)          // compile-time interface check
+var _ iter.SeekableKV = (*StateAsOfIterDB)(nil)         // compile-time interface check
+var _ iter.SeekableKV = (*HistoryChangesIterFiles)(nil) // compile-time interface check
+var _ iter.SeekableKV = (*HistoryChangesIterDB)(nil)    // compile-time interface check
+
 // StateAsOfIter - returns state range at given time in history
 type StateAsOfIterF struct {
 	hc    *HistoryContext
@@ -1471,11 +1510,21 @@ type StateAsOfIterF struct {
 	compressVals bool
 
 	k, v, kBackup, vBackup []byte
+	err                    error
 }
 
 func (hi *StateAsOfIterF) Close() {
 }
 
+// Seek - skips keys before `k`. Values of skipped keys are not decoded
+func (hi *StateAsOfIterF) Seek(k []byte) {
+	if hi.err != nil || hi.nextKey == nil || bytes.Compare(hi.nextKey, k) >= 0 {
+		return
+	}
+	seekReconHeap(&hi.h, k, hi.to, hi.compressVals)
+	hi.err = hi.advanceInFiles()
+}
+
 func (hi *StateAsOfIterF) advanceInFiles() error {
 	for hi.h.Len() > 0 {
 		top := heap.Pop(&hi.h).(*ReconItem)
@@ -1532,10 +1581,16 @@ func (hi *StateAsOfIterF) advanceInFiles() error {
 }
 
 func (hi *StateAsOfIterF) HasNext() bool {
+	if hi.err != nil {
+		return true
+	}
 	return hi.limit != 0 && hi.nextKey != nil
 }
 
 func (hi *StateAsOfIterF) Next() ([]byte, []byte, error) {
+	if hi.err != nil {
+		return nil, nil, hi.err
+	}
 	hi.limit--
 	hi.k, hi.v = append(hi.k[:0], hi.nextKey...), append(hi.v[:0], hi.nextVal...)
 
@@ -1573,6 +1628,18 @@ func (hi *StateAsOfIterDB) Close() {
 	}
 }
 
+// Seek - skips keys before `k` by re-positioning cursor
+func (hi *StateAsOfIterDB) Seek(k []byte) {
+	if hi.err != nil || hi.nextKey == nil || bytes.Compare(hi.nextKey, k) >= 0 {
+		return
+	}
+	if hi.largeValues {
+		hi.err = hi.seekLargeVals(append(common.Copy(k), hi.startTxKey[:]...))
+		return
+	}
+	hi.err = hi.seekSmallVals(k)
+}
+
 func (hi *StateAsOfIterDB) advance() (err error) {
 	// not large:
 	//   keys: txNum -> key1+key2
@@ -1610,6 +1677,11 @@ func (hi *StateAsOfIterDB) advanceLargeVals() error {
 
 		seek = append(next, hi.startTxKey[:]...)
 	}
+	return hi.seekLargeVals(seek)
+}
+
+// seekLargeVals - `seek` is key+startTxNum
+func (hi *StateAsOfIterDB) seekLargeVals(seek []byte) error {
 	for k, v, err := hi.valsC.Seek(seek); k != nil; k, v, err = hi.valsC.Seek(seek) {
 		if err != nil {
 			return err
@@ -1644,6 +1716,10 @@ func (hi *StateAsOfIterDB) advanceSmallVals() error {
 		}
 		seek = next
 	}
+	return hi.seekSmallVals(seek)
+}
+
+func (hi *StateAsOfIterDB) seekSmallVals(seek []byte) error {
 	for k, _, err := hi.valsCDup.Seek(seek); k != nil; k, _, err = hi.valsCDup.NextNoDup() {
 		if err != nil {
 			return err
@@ -1688,6 +1764,35 @@ func (hi *StateAsOfIterDB) Next() ([]byte, []byte, error) {
 	return hi.kBackup, hi.vBackup, nil
 }
 
+// seekReconHeap - moves getters of all items to first key >= `seek`, skipping values without decoding them.
+// Items which reached end of file or `to` are removed from heap
+func seekReconHeap(h *ReconHeap, seek, to []byte, compressVals bool) {
+	items := (*h)[:0]
+	for _, item := range *h {
+		ok := true
+		for ok && bytes.Compare(item.key, seek) < 0 {
+			if compressVals {
+				item.g.Skip()
+			} else {
+				item.g.SkipUncompressed()
+			}
+			if ok = item.g.HasNext(); ok {
+				if compressVals {
+					item.key, _ = item.g.Next(nil)
+				} else {
+					item.key, _ = item.g.NextUncompressed()
+				}
+			}
+		}
+		if !ok || (to != nil && bytes.Compare(item.key, to) >= 0) {
+			continue
+		}
+		items = append(items, item)
+	}
+	*h = items
+	heap.Init(h)
+}
+
 func (hc *HistoryContext) iterateChangedFrozen(fromTxNum, toTxNum int, asc order.By, limit int) (iter.KV, error) {
 	if asc == false {
 		panic("not supported yet")
@@ -1789,6 +1894,15 @@ type HistoryChangesIterFiles struct {
 func (hi *HistoryChangesIterFiles) Close() {
 }
 
+// Seek - skips keys before `k`. Values of skipped keys are not decoded
+func (hi *HistoryChangesIterFiles) Seek(k []byte) {
+	if hi.err != nil || hi.nextKey == nil || bytes.Compare(hi.nextKey, k) >= 0 {
+		return
+	}
+	seekReconHeap(&hi.h, k, nil, hi.compressVals)
+	hi.err = hi.advance()
+}
+
 func (hi *HistoryChangesIterFiles) advance() error {
 	for hi.h.Len() > 0 {
 		top := heap.Pop(&hi.h).(*ReconItem)
@@ -1894,6 +2008,24 @@ func (hi *HistoryChangesIterDB) Close() {
 		hi.valsCDup.Close()
 	}
 }
+
+// Seek - skips keys before `k` by re-positioning cursor
+func (hi *HistoryChangesIterDB) Seek(k []byte) {
+	if hi.err != nil || hi.nextKey == nil || bytes.Compare(hi.nextKey, k) >= 0 {
+		return
+	}
+	if hi.largeValues {
+		hi.err = hi.seekLargeVals(append(common.Copy(k), hi.startTxKey[:]...))
+		return
+	}
+	k, _, err := hi.valsCDup.Seek(k)
+	if err != nil {
+		hi.err = err
+		return
+	}
+	hi.err = hi.advanceSmallValsFrom(k)
+}
+
 func (hi *HistoryChangesIterDB) advance() (err error) {
 	// not large:
 	//   keys: txNum -> key1+key2
@@ -1931,6 +2063,11 @@ func (hi *HistoryChangesIterDB) advanceLargeVals() error {
 
 		seek = append(next, hi.startTxKey[:]...)
 	}
+	return hi.seekLargeVals(seek)
+}
+
+// seekLargeVals - `seek` is key+startTxNum
+func (hi *HistoryChangesIterDB) seekLargeVals(seek []byte) error {
 	for k, v, err := hi.valsC.Seek(seek); k != nil; k, v, err = hi.valsC.Seek(seek) {
 		if err != nil {
 			return err
@@ -1970,6 +2107,11 @@ func (hi *HistoryChangesIterDB) advanceSmallVals() (err error) {
 			return err
 		}
 	}
+	return hi.advanceSmallValsFrom(k)
+}
+
+// advanceSmallValsFrom - `k` is current key of cursor
+func (hi *HistoryChangesIterDB) advanceSmallValsFrom(k []byte) (err error) {
 	for ; k != nil; k, _, err = hi.valsCDup.NextNoDup() {
 		if err != nil {
 			return err
@@ -2170,7 +2312,28 @@ func (hc *HistoryContext) idxRangeRecent(key []byte, startTxNum, endTxNum int, a
 				return binary.BigEndian.Uint64(k[len(k)-8:]), nil
 			})
 		} else {
-			panic("implement me")
+			from := make([]byte, len(key)+8)
+			copy(from, key)
+			fromTxNum := uint64(math.MaxUint64)
+			if startTxNum >= 0 {
+				fromTxNum = uint64(startTxNum)
+			}
+			binary.BigEndian.PutUint64(from[len(key):], fromTxNum)
+
+			to := common.Copy(key)
+			if endTxNum >= 0 {
+				to = make([]byte, len(key)+8)
+				copy(to, key)
+				binary.BigEndian.PutUint64(to[len(key):], uint64(endTxNum))
+			}
+
+			it, err := roTx.RangeDescend(hc.h.historyValsTable, from, to, limit)
+			if err != nil {
+				return nil, err
+			}
+			dbIt = iter.TransformKV2U64(it, func(k, _ []byte) (uint64, error) {
+				return binary.BigEndian.Uint64(k[len(k)-8:]), nil
+			})
 		}
 	} else {
 		if asc {
@@ -2191,7 +2354,19 @@ func (hc *HistoryContext) idxRangeRecent(key []byte, startTxNum, endTxNum int, a
 				return binary.BigEndian.Uint64(v), nil
 			})
 		} else {
-			panic("implement me")
+			// values are `txNum+val` and `val` can be empty - byte-bounds of RangeDupSort can't express inclusive `startTxNum`
+			it := &RecentInvertedIdxIter{
+				key:         key,
+				startTxNum:  startTxNum,
+				endTxNum:    endTxNum,
+				limit:       limit,
+				orderAscend: asc,
+				roTx:        roTx,
+				indexTable:  hc.h.historyValsTable,
+				hasNext:     true,
+			}
+			it.advance()
+			dbIt = it
 		}
 	}
 
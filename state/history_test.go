@@ -467,6 +467,130 @@ func TestHistoryScanFiles(t *testing.T) {
 	})
 }
 
+func TestHistoryIdxRange(t *testing.T) {
+	logger := log.New()
+	ctx := context.Background()
+	check := func(t *testing.T, h *History, db kv.RwDB) {
+		t.Helper()
+		roTx, err := db.BeginRo(ctx)
+		require.NoError(t, err)
+		defer roTx.Rollback()
+		hc := h.MakeContext()
+		defer hc.Close()
+
+		for keyNum := uint64(1); keyNum <= uint64(31); keyNum++ {
+			var k [8]byte
+			binary.BigEndian.PutUint64(k[:], keyNum)
+			k[0] = 1
+			it, err := hc.IdxRange(k[:], -1, -1, order.Asc, -1, roTx)
+			require.NoError(t, err)
+			values := iter.ToArrU64Must(it)
+			require.NotEmpty(t, values)
+
+			it, err = hc.IdxRange(k[:], -1, -1, order.Desc, -1, roTx)
+			require.NoError(t, err)
+			iter.ExpectEqualU64(t, iter.ReverseArray(values), it)
+
+			it, err = hc.IdxRange(k[:], -1, -1, order.Desc, 2, roTx)
+			require.NoError(t, err)
+			iter.ExpectEqualU64(t, iter.ReverseArray(values[len(values)-2:]), it)
+
+			it, err = hc.IdxRange(k[:], 500, 100, order.Desc, -1, roTx)
+			require.NoError(t, err)
+			expect := iter.FilterU64(iter.ReverseArray(values), func(n uint64) bool { return n <= 500 && n > 100 })
+			iter.ExpectEqualU64(t, expect, it)
+
+			it, err = hc.IdxRange(k[:], 500, -1, order.Desc, -1, roTx)
+			require.NoError(t, err)
+			expect = iter.FilterU64(iter.ReverseArray(values), func(n uint64) bool { return n <= 500 })
+			iter.ExpectEqualU64(t, expect, it)
+		}
+	}
+	for _, largeValues := range []bool{true, false} {
+		t.Run(fmt.Sprintf("largeValues=%t", largeValues), func(t *testing.T) {
+			_, db, h, txs := filledHistory(t, largeValues, logger)
+			check(t, h, db) // db only
+			collateAndMergeHistory(t, db, h, txs)
+			check(t, h, db) // files and db
+		})
+	}
+}
+
+func TestHistorySeek(t *testing.T) {
+	logger := log.New()
+	ctx := context.Background()
+	collect := func(t *testing.T, it iter.KV) (keys, vals []string) {
+		t.Helper()
+		for it.HasNext() {
+			k, v, err := it.Next()
+			require.NoError(t, err)
+			keys, vals = append(keys, string(k)), append(vals, string(v))
+		}
+		return keys, vals
+	}
+	checkKV := func(t *testing.T, newIt func() iter.KV) {
+		t.Helper()
+		keys, vals := collect(t, newIt())
+		require.Greater(t, len(keys), 4)
+		for _, i := range []int{0, 1, len(keys) / 2, len(keys) - 1} {
+			it := newIt()
+			it.(iter.SeekableKV).Seek([]byte(keys[i]))
+			gotKeys, gotVals := collect(t, it)
+			require.Equal(t, keys[i:], gotKeys)
+			require.Equal(t, vals[i:], gotVals)
+
+			it = newIt()
+			it.(iter.SeekableKV).Seek(append([]byte(keys[i]), 0)) // not existing key
+			gotKeys, _ = collect(t, it)
+			require.Equal(t, keys[i+1:], append([]string{}, gotKeys...))
+		}
+	}
+	check := func(t *testing.T, h *History, db kv.RwDB) {
+		t.Helper()
+		roTx, err := db.BeginRo(ctx)
+		require.NoError(t, err)
+		defer roTx.Rollback()
+		hc := h.MakeContext()
+		defer hc.Close()
+
+		checkKV(t, func() iter.KV {
+			it, err := hc.HistoryRange(2, 200, order.Asc, -1, roTx)
+			require.NoError(t, err)
+			return it
+		})
+		checkKV(t, func() iter.KV { return hc.WalkAsOf(500, nil, nil, roTx, -1) })
+
+		for keyNum := uint64(1); keyNum <= uint64(31); keyNum += 10 {
+			var k [8]byte
+			binary.BigEndian.PutUint64(k[:], keyNum)
+			k[0] = 1
+			for _, asc := range []order.By{order.Asc, order.Desc} {
+				it, err := hc.IdxRange(k[:], -1, -1, asc, -1, roTx)
+				require.NoError(t, err)
+				values := iter.ToArrU64Must(it)
+				mid := values[len(values)/2]
+
+				it, err = hc.IdxRange(k[:], -1, -1, asc, -1, roTx)
+				require.NoError(t, err)
+				seekable, ok := it.(iter.SeekableU64)
+				if !ok { // some db-only ranges are not seekable
+					continue
+				}
+				seekable.Seek(mid)
+				iter.ExpectEqualU64(t, iter.Array(values[len(values)/2:]), it)
+			}
+		}
+	}
+	for _, largeValues := range []bool{true, false} {
+		t.Run(fmt.Sprintf("largeValues=%t", largeValues), func(t *testing.T) {
+			_, db, h, txs := filledHistory(t, largeValues, logger)
+			check(t, h, db) // db only
+			collateAndMergeHistory(t, db, h, txs)
+			check(t, h, db) // files and db
+		})
+	}
+}
+
 func TestIterateChanged(t *testing.T) {
 	logger := log.New()
 	logEvery := time.NewTicker(30 * time.Second)
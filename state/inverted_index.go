@@ -77,6 +77,8 @@ type InvertedIndex struct {
 	logger     log.Logger
 
 	noFsync bool // fsync is enabled by default, but tests can manually disable
+
+	withExistenceFilter bool // .efei/.kvei are not built by default, see History.EnableExistenceFilter
 }
 
 func NewInvertedIndex(
@@ -259,7 +261,8 @@ func (ii *InvertedIndex) missedIdxFiles() (l []*filesItem) {
 	ii.files.Walk(func(items []*filesItem) bool {
 		for _, item := range items {
 			fromStep, toStep := item.startTxNum/ii.aggregationStep, item.endTxNum/ii.aggregationStep
-			if !dir.FileExist(filepath.Join(ii.dir, fmt.Sprintf("%s.%d-%d.efi", ii.filenameBase, fromStep, toStep))) {
+			if !dir.FileExist(filepath.Join(ii.dir, fmt.Sprintf("%s.%d-%d.efi", ii.filenameBase, fromStep, toStep))) ||
+				(ii.withExistenceFilter && !dir.FileExist(filepath.Join(ii.dir, fmt.Sprintf("%s.%d-%d.efei", ii.filenameBase, fromStep, toStep)))) {
 				l = append(l, item)
 			}
 		}
@@ -275,9 +278,21 @@ func (ii *InvertedIndex) buildEfi(ctx context.Context, item *filesItem, p *backg
 	p.Name.Store(&fName)
 	p.Total.Store(uint64(item.decompressor.Count()))
 	//ii.logger.Info("[snapshots] build idx", "file", fName)
+	if dir.FileExist(idxPath) {
+		return nil
+	}
 	return buildIndex(ctx, item.decompressor, idxPath, ii.tmpdir, item.decompressor.Count()/2, false, p, ii.logger, ii.noFsync)
 }
 
+func (ii *InvertedIndex) buildEfei(ctx context.Context, item *filesItem, p *background.Progress) (err error) {
+	fromStep, toStep := item.startTxNum/ii.aggregationStep, item.endTxNum/ii.aggregationStep
+	idxPath := filepath.Join(ii.dir, fmt.Sprintf("%s.%d-%d.efei", ii.filenameBase, fromStep, toStep))
+	if !ii.withExistenceFilter || dir.FileExist(idxPath) {
+		return nil
+	}
+	return buildExistenceFilter(ctx, item.decompressor, idxPath, p, ii.noFsync)
+}
+
 // BuildMissedIndices - produce .efi/.vi/.kvi from .ef/.v/.kv
 func (ii *InvertedIndex) BuildMissedIndices(ctx context.Context, g *errgroup.Group, ps *background.ProgressSet) {
 	missedFiles := ii.missedIdxFiles()
@@ -287,7 +302,10 @@ func (ii *InvertedIndex) BuildMissedIndices(ctx context.Context, g *errgroup.Gro
 			p := &background.Progress{}
 			ps.Add(p)
 			defer ps.Delete(p)
-			return ii.buildEfi(ctx, item, p)
+			if err := ii.buildEfi(ctx, item, p); err != nil {
+				return err
+			}
+			return ii.buildEfei(ctx, item, p)
 		})
 	}
 }
@@ -324,6 +342,18 @@ func (ii *InvertedIndex) openFiles() error {
 				}
 				totalKeys += item.index.KeyCount()
 			}
+			if item.existence != nil {
+				continue
+			}
+			existencePath := filepath.Join(ii.dir, fmt.Sprintf("%s.%d-%d.efei", ii.filenameBase, fromStep, toStep))
+			if dir.FileExist(existencePath) {
+				if f, fErr := OpenExistenceFilter(existencePath); fErr != nil {
+					// filter is optional: file is still readable without it
+					ii.logger.Warn("InvertedIndex.openFiles: skip existence filter", "err", fErr, "file", existencePath)
+				} else {
+					item.existence = f
+				}
+			}
 		}
 		return true
 	})
@@ -361,6 +391,10 @@ func (ii *InvertedIndex) closeWhatNotInList(fNames []string) {
 			item.index.Close()
 			item.index = nil
 		}
+		if item.existence != nil {
+			item.existence.Close()
+			item.existence = nil
+		}
 		ii.files.Delete(item)
 	}
 }
@@ -616,7 +650,7 @@ func (ic *InvertedIndexContext) recentIterateRange(key []byte, startTxNum, endTx
 			return iter.EmptyU64, nil
 		}
 	} else {
-		isFrozenRange := len(ic.files) > 0 && startTxNum >= 0 && ic.files[len(ic.files)-1].endTxNum >= uint64(startTxNum)
+		isFrozenRange := len(ic.files) > 0 && startTxNum >= 0 && ic.files[len(ic.files)-1].endTxNum > uint64(startTxNum)
 		if isFrozenRange {
 			return iter.EmptyU64, nil
 		}
@@ -697,6 +731,9 @@ func (ic *InvertedIndexContext) iterateRangeFrozen(key []byte, startTxNum, endTx
 	return it, nil
 }
 
+var _ iter.SeekableU64 = (*FrozenInvertedIdxIter)(nil) // compile-time interface check
+var _ iter.SeekableU64 = (*RecentInvertedIdxIter)(nil) // compile-time interface check
+
 // FrozenInvertedIdxIter allows iteration over range of tx numbers
 // Iteration is not implmented via callback function, because there is often
 // a requirement for interators to be composable (for example, to implement AND and OR for indices)
@@ -755,6 +792,32 @@ func (it *FrozenInvertedIdxIter) next() uint64 {
 	return n
 }
 
+// Seek - skips items before `n` (in order of iteration). Only moves forward: does nothing if next item is already not before `n`
+func (it *FrozenInvertedIdxIter) Seek(n uint64) {
+	if it.err != nil || !it.hasNext {
+		return
+	}
+	if it.orderAscend {
+		if it.nextN >= n {
+			return
+		}
+		if it.startTxNum < 0 || uint64(it.startTxNum) < n {
+			it.startTxNum = int(n)
+		}
+	} else {
+		if it.nextN <= n {
+			return
+		}
+		if it.startTxNum < 0 || uint64(it.startTxNum) > n {
+			it.startTxNum = int(n)
+		}
+	}
+	if efIt, ok := it.efIt.(iter.Seekable[uint64]); ok {
+		efIt.Seek(n)
+	}
+	it.advanceInFiles()
+}
+
 func (it *FrozenInvertedIdxIter) advanceInFiles() {
 	for {
 		for it.efIt == nil { //TODO: this loop may be optimized by LocalityIndex
@@ -778,12 +841,15 @@ func (it *FrozenInvertedIdxIter) advanceInFiles() {
 					}
 					it.efIt = efiter
 				} else {
-					it.efIt = it.ef.ReverseIterator()
+					efiter := it.ef.ReverseIterator()
+					if it.startTxNum >= 0 {
+						efiter.Seek(uint64(it.startTxNum))
+					}
+					it.efIt = efiter
 				}
 			}
 		}
 
-		//TODO: add seek method
 		//Asc:  [from, to) AND from > to
 		//Desc: [from, to) AND from < to
 		if it.orderAscend {
@@ -806,7 +872,7 @@ func (it *FrozenInvertedIdxIter) advanceInFiles() {
 					it.hasNext = false
 					return
 				}
-				if it.startTxNum >= 0 && int(n) <= it.startTxNum {
+				if it.startTxNum < 0 || int(n) <= it.startTxNum {
 					it.hasNext = true
 					it.nextN = n
 					return
@@ -849,12 +915,13 @@ func (it *RecentInvertedIdxIter) advanceInDB() {
 	var err error
 	if it.cursor == nil {
 		if it.cursor, err = it.roTx.CursorDupSort(it.indexTable); err != nil {
-			// TODO pass error properly around
-			panic(err)
+			it.err = err
+			return
 		}
 		var k []byte
 		if k, _, err = it.cursor.SeekExact(it.key); err != nil {
-			panic(err)
+			it.err = err
+			return
 		}
 		if k == nil {
 			it.hasNext = false
@@ -862,47 +929,59 @@ func (it *RecentInvertedIdxIter) advanceInDB() {
 		}
 		//Asc:  [from, to) AND from > to
 		//Desc: [from, to) AND from < to
-		var keyBytes [8]byte
-		if it.startTxNum > 0 {
-			binary.BigEndian.PutUint64(keyBytes[:], uint64(it.startTxNum))
-		}
-		if v, err = it.cursor.SeekBothRange(it.key, keyBytes[:]); err != nil {
-			panic(err)
-		}
-		if v == nil {
-			if !it.orderAscend {
-				_, v, _ = it.cursor.PrevDup()
-				if err != nil {
-					panic(err)
-				}
+		if !it.orderAscend && it.startTxNum < 0 {
+			if v, err = it.cursor.LastDup(); err != nil {
+				it.err = err
+				return
 			}
-			if v == nil {
-				it.hasNext = false
+		} else {
+			var keyBytes [8]byte
+			if it.startTxNum > 0 {
+				binary.BigEndian.PutUint64(keyBytes[:], uint64(it.startTxNum))
+			}
+			if v, err = it.cursor.SeekBothRange(it.key, keyBytes[:]); err != nil {
+				it.err = err
 				return
 			}
+			if v == nil && !it.orderAscend { // all values are smaller than startTxNum
+				if _, _, err = it.cursor.SeekExact(it.key); err != nil {
+					it.err = err
+					return
+				}
+				if v, err = it.cursor.LastDup(); err != nil {
+					it.err = err
+					return
+				}
+			}
+		}
+		if v == nil {
+			it.hasNext = false
+			return
 		}
 	} else {
 		if it.orderAscend {
 			_, v, err = it.cursor.NextDup()
-			if err != nil {
-				// TODO pass error properly around
-				panic(err)
-			}
 		} else {
 			_, v, err = it.cursor.PrevDup()
-			if err != nil {
-				panic(err)
-			}
+		}
+		if err != nil {
+			it.err = err
+			return
 		}
 	}
+	it.advanceFrom(v)
+}
 
+// advanceFrom - `v` is current value of cursor
+func (it *RecentInvertedIdxIter) advanceFrom(v []byte) {
+	var err error
 	//Asc:  [from, to) AND from > to
 	//Desc: [from, to) AND from < to
 	if it.orderAscend {
 		for ; v != nil; _, v, err = it.cursor.NextDup() {
 			if err != nil {
-				// TODO pass error properly around
-				panic(err)
+				it.err = err
+				return
 			}
 			n := binary.BigEndian.Uint64(v)
 			if it.endTxNum >= 0 && int(n) >= it.endTxNum {
@@ -918,25 +997,65 @@ func (it *RecentInvertedIdxIter) advanceInDB() {
 	} else {
 		for ; v != nil; _, v, err = it.cursor.PrevDup() {
 			if err != nil {
-				// TODO pass error properly around
-				panic(err)
+				it.err = err
+				return
 			}
 			n := binary.BigEndian.Uint64(v)
 			if int(n) <= it.endTxNum {
 				it.hasNext = false
 				return
 			}
-			if it.startTxNum >= 0 && int(n) <= it.startTxNum {
+			if it.startTxNum < 0 || int(n) <= it.startTxNum {
 				it.hasNext = true
 				it.nextN = n
 				return
 			}
 		}
 	}
+	if err != nil {
+		it.err = err
+		return
+	}
 
 	it.hasNext = false
 }
 
+// Seek - skips items before `n` (in order of iteration) by re-positioning cursor
+func (it *RecentInvertedIdxIter) Seek(n uint64) {
+	if it.err != nil || !it.hasNext {
+		return
+	}
+	if (it.orderAscend && it.nextN >= n) || (!it.orderAscend && it.nextN <= n) {
+		return
+	}
+	if it.orderAscend && (it.startTxNum < 0 || uint64(it.startTxNum) < n) || !it.orderAscend && (it.startTxNum < 0 || uint64(it.startTxNum) > n) {
+		it.startTxNum = int(n)
+	}
+	var seek [8]byte
+	binary.BigEndian.PutUint64(seek[:], n)
+	v, err := it.cursor.SeekBothRange(it.key, seek[:])
+	if err != nil {
+		it.err = err
+		return
+	}
+	if !it.orderAscend {
+		if v == nil { // all values are smaller than n
+			if _, _, err = it.cursor.SeekExact(it.key); err != nil {
+				it.err = err
+				return
+			}
+			v, err = it.cursor.LastDup()
+		} else if binary.BigEndian.Uint64(v) > n {
+			_, v, err = it.cursor.PrevDup()
+		}
+		if err != nil {
+			it.err = err
+			return
+		}
+	}
+	it.advanceFrom(v)
+}
+
 func (it *RecentInvertedIdxIter) advance() {
 	if it.orderAscend {
 		if it.hasNext {
@@ -1155,8 +1274,9 @@ func (ii *InvertedIndex) collate(ctx context.Context, txFrom, txTo uint64, roTx
 }
 
 type InvertedFiles struct {
-	decomp *compress.Decompressor
-	index  *recsplit.Index
+	decomp    *compress.Decompressor
+	index     *recsplit.Index
+	existence *ExistenceFilter
 }
 
 func (sf InvertedFiles) Close() {
@@ -1166,6 +1286,9 @@ func (sf InvertedFiles) Close() {
 	if sf.index != nil {
 		sf.index.Close()
 	}
+	if sf.existence != nil {
+		sf.existence.Close()
+	}
 }
 
 func (ii *InvertedIndex) buildFiles(ctx context.Context, step uint64, bitmaps map[string]*roaring64.Bitmap, ps *background.ProgressSet) (InvertedFiles, error) {
@@ -1246,6 +1369,7 @@ func (ii *InvertedIndex) integrateFiles(sf InvertedFiles, txNumFrom, txNumTo uin
 	fi := newFilesItem(txNumFrom, txNumTo, ii.aggregationStep)
 	fi.decompressor = sf.decomp
 	fi.index = sf.index
+	fi.existence = sf.existence
 	ii.files.Set(fi)
 
 	ii.reCalcRoFiles()
@@ -304,6 +304,19 @@ func checkRanges(t *testing.T, db kv.RwDB, ii *InvertedIndex, txs uint64) {
 			expect := iter.FilterU64(iter.ReverseArray(values), func(k uint64) bool { return k <= 102 && k > 100 })
 			iter.ExpectEqualU64(t, expect, it)
 		})
+		t.Run("seek", func(t *testing.T) {
+			it, err := ic.iterateRangeFrozen(k[:], 0, 976, order.Asc, -1)
+			require.NoError(t, err)
+			defer it.Close()
+			it.Seek(500)
+			iter.ExpectEqualU64(t, iter.FilterU64(iter.Array(values), func(k uint64) bool { return k >= 500 }), it)
+
+			it, err = ic.iterateRangeFrozen(k[:], 976-1, 0, order.Desc, -1)
+			require.NoError(t, err)
+			defer it.Close()
+			it.Seek(500)
+			iter.ExpectEqualU64(t, iter.FilterU64(iter.ReverseArray(values), func(k uint64) bool { return k <= 500 }), it)
+		})
 	}
 	// Now check ranges that require access to DB
 	roTx, err := db.BeginRo(ctx)
@@ -330,6 +343,18 @@ func checkRanges(t *testing.T, db kv.RwDB, ii *InvertedIndex, txs uint64) {
 		arr := iter.ToArrU64Must(reverseStream)
 		expect := iter.ToArrU64Must(iter.ReverseArray(values))
 		require.Equal(t, expect, arr)
+
+		all, err := ic.IdxRange(k[:], -1, -1, order.Asc, -1, roTx)
+		require.NoError(t, err)
+		allValues := iter.ToArrU64Must(all)
+		unboundedDesc, err := ic.IdxRange(k[:], -1, -1, order.Desc, -1, roTx)
+		require.NoError(t, err)
+		iter.ExpectEqualU64(t, iter.ReverseArray(allValues), unboundedDesc)
+
+		unboundedStartDesc, err := ic.IdxRange(k[:], -1, 400-1, order.Desc, -1, roTx)
+		require.NoError(t, err)
+		expect = iter.ToArrU64Must(iter.FilterU64(iter.ReverseArray(allValues), func(k uint64) bool { return k >= 400 }))
+		iter.ExpectEqualU64(t, iter.Array(expect), unboundedStartDesc)
 	}
 }
 
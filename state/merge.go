@@ -477,6 +477,9 @@ func (d *Domain) mergeFiles(ctx context.Context, valuesFiles, indexFiles, histor
 				if indexIn.bindex != nil {
 					indexIn.bindex.Close()
 				}
+				if indexIn.existence != nil {
+					indexIn.existence.Close()
+				}
 			}
 			if historyIn != nil {
 				if historyIn.decompressor != nil {
@@ -499,6 +502,9 @@ func (d *Domain) mergeFiles(ctx context.Context, valuesFiles, indexFiles, histor
 				if valuesIn.bindex != nil {
 					valuesIn.bindex.Close()
 				}
+				if valuesIn.existence != nil {
+					valuesIn.existence.Close()
+				}
 			}
 		}
 	}()
@@ -650,6 +656,15 @@ func (d *Domain) mergeFiles(ctx context.Context, valuesFiles, indexFiles, histor
 			return nil, nil, nil, fmt.Errorf("merge %s btindex2 [%d-%d]: %w", d.filenameBase, r.valuesStartTxNum, r.valuesEndTxNum, err)
 		}
 		valuesIn.bindex = bt
+
+		if d.withExistenceFilter {
+			existenceFileName := strings.TrimSuffix(idxFileName, "kvi") + "kvei"
+			p = ps.AddNew("merge "+existenceFileName, uint64(keyCount))
+			defer ps.Delete(p)
+			if valuesIn.existence, err = buildExistenceFilterThenOpen(ctx, valuesIn.decompressor, filepath.Join(d.dir, existenceFileName), p, d.noFsync); err != nil {
+				return nil, nil, nil, fmt.Errorf("merge %s existence filter [%d-%d]: %w", d.filenameBase, r.valuesStartTxNum, r.valuesEndTxNum, err)
+			}
+		}
 	}
 	closeItem = false
 	d.stats.MergesCount++
@@ -681,6 +696,9 @@ func (ii *InvertedIndex) mergeFiles(ctx context.Context, files []*filesItem, sta
 				if outItem.index != nil {
 					outItem.index.Close()
 				}
+				if outItem.existence != nil {
+					outItem.existence.Close()
+				}
 				outItem = nil
 			}
 		}
@@ -792,6 +810,14 @@ func (ii *InvertedIndex) mergeFiles(ctx context.Context, files []*filesItem, sta
 	if outItem.index, err = buildIndexThenOpen(ctx, outItem.decompressor, idxPath, ii.tmpdir, keyCount, false /* values */, p, ii.logger, ii.noFsync); err != nil {
 		return nil, fmt.Errorf("merge %s buildIndex [%d-%d]: %w", ii.filenameBase, startTxNum, endTxNum, err)
 	}
+	if ii.withExistenceFilter {
+		existenceFileName := fmt.Sprintf("%s.%d-%d.efei", ii.filenameBase, startTxNum/ii.aggregationStep, endTxNum/ii.aggregationStep)
+		p = ps.AddNew("merge "+existenceFileName, uint64(keyCount))
+		defer ps.Delete(p)
+		if outItem.existence, err = buildExistenceFilterThenOpen(ctx, outItem.decompressor, filepath.Join(ii.dir, existenceFileName), p, ii.noFsync); err != nil {
+			return nil, fmt.Errorf("merge %s existence filter [%d-%d]: %w", ii.filenameBase, startTxNum, endTxNum, err)
+		}
+	}
 	closeItem = false
 	return outItem, nil
 }
@@ -806,6 +832,9 @@ func (h *History) mergeFiles(ctx context.Context, indexFiles, historyFiles []*fi
 			if indexIn != nil {
 				indexIn.decompressor.Close()
 				indexIn.index.Close()
+				if indexIn.existence != nil {
+					indexIn.existence.Close()
+				}
 			}
 		}
 	}()